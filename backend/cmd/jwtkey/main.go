@@ -0,0 +1,62 @@
+// Command jwtkey generates an Ed25519 key for signing access tokens.
+//
+//	go run ./cmd/jwtkey               # print the private key PEM (for JWT_PRIVATE_KEYS)
+//	go run ./cmd/jwtkey -dir keys     # write keys/<date>-<kid>.pem (for JWT_KEYS_DIR)
+//	go run ./cmd/jwtkey -retire keys/2026-01-01-abc.pem  # write the verify-only .pub.pem
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"spacegame-backend/internal/service"
+)
+
+func main() {
+	dir := flag.String("dir", "", "directory to write the key to (prints to stdout if empty)")
+	retire := flag.String("retire", "", "private key file to convert into a verify-only public key")
+	flag.Parse()
+
+	if *retire != "" {
+		data, err := os.ReadFile(*retire)
+		if err != nil {
+			log.Fatal(err)
+		}
+		pub, err := service.PublicKeyPEM(data)
+		if err != nil {
+			log.Fatal(err)
+		}
+		out := strings.TrimSuffix(*retire, ".pem") + ".pub.pem"
+		if err := os.WriteFile(out, pub, 0o644); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("wrote %s — delete %s once the new key is signing\n", out, *retire)
+		return
+	}
+
+	keyPEM, kid, err := service.GenerateKeyPEM()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *dir == "" {
+		fmt.Fprintf(os.Stderr, "kid: %s\n", kid)
+		fmt.Print(string(keyPEM))
+		return
+	}
+
+	if err := os.MkdirAll(*dir, 0o700); err != nil {
+		log.Fatal(err)
+	}
+	// Date prefix: files load in name order and the last private key signs
+	out := filepath.Join(*dir, time.Now().UTC().Format("20060102-150405")+"-"+kid+".pem")
+	if err := os.WriteFile(out, keyPEM, 0o600); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("wrote %s (kid %s)\n", out, kid)
+}
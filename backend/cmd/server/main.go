@@ -45,14 +45,42 @@ func main() {
 	fleetRepo := repository.NewFleetRepository(db)
 	chatRepo := repository.NewChatRepository(db)
 	marketRepo := repository.NewMarketRepository(db)
+	accountTokenRepo := repository.NewAccountTokenRepository(db)
+	twoFactorRepo := repository.NewTwoFactorRepository(db)
+	loginAttemptRepo := repository.NewLoginAttemptRepository(db)
+	banRepo := repository.NewBanRepository(db)
+	roleRepo := repository.NewRoleRepository(db)
+	gameServerRepo := repository.NewGameServerRepository(db)
+	adminTokenRepo := repository.NewAdminTokenRepository(db)
+	adminAuditRepo := repository.NewAdminAuditRepository(db)
+	snapshotRepo := repository.NewPlayerSnapshotRepository(db)
+	ledgerRepo := repository.NewCreditLedgerRepository(db)
+	leaderboardRepo := repository.NewLeaderboardRepository(db)
+	playSessionRepo := repository.NewPlaySessionRepository(db)
+	achievementRepo := repository.NewAchievementRepository(db)
+	friendRepo := repository.NewFriendRepository(db)
+	tradeRepo := repository.NewTradeRepository(db)
+	mailRepo := repository.NewMailRepository(db)
 
 	// Services
-	authSvc := service.NewAuthService(playerRepo, sessionRepo, cfg.JWTSecret)
-	playerSvc := service.NewPlayerService(playerRepo)
-	corpSvc := service.NewCorporationService(corpRepo, playerRepo)
 	wsHub := service.NewWSHub()
 
-	marketSvc := service.NewMarketService(marketRepo, playerRepo)
+	// Achievements (evaluated by the event, market and corporation services)
+	achievementSvc := service.NewAchievementService(achievementRepo, playerRepo, wsHub)
+
+	// Friends & presence (friends are told when a player connects or joins the game)
+	presenceSvc := service.NewPresenceService(friendRepo, playerRepo, wsHub)
+	wsHub.OnConnect(presenceSvc.Connected)
+	friendSvc := service.NewFriendService(friendRepo, playerRepo, presenceSvc, wsHub)
+
+	// In-game mail (attachments held until claimed; the market pays sellers by mail)
+	mailSvc := service.NewMailService(mailRepo, playerRepo, corpRepo, friendSvc, wsHub)
+
+	corpSvc := service.NewCorporationService(corpRepo, playerRepo, achievementSvc, friendSvc)
+	marketSvc := service.NewMarketService(marketRepo, playerRepo, achievementSvc, mailSvc)
+
+	// Player-to-player trades (blocks apply, swaps are atomic)
+	tradeSvc := service.NewTradeService(tradeRepo, playerRepo, friendSvc, wsHub)
 
 	// Discord webhook service
 	webhookSvc := service.NewDiscordWebhookService(
@@ -65,7 +93,62 @@ func main() {
 	)
 
 	// Event service (records + dispatches to Discord)
-	eventSvc := service.NewEventService(eventRepo, webhookSvc)
+	eventSvc := service.NewEventService(eventRepo, webhookSvc, achievementSvc)
+
+	// Player state (saves are versioned and snapshotted)
+	playerSvc := service.NewPlayerService(playerRepo, snapshotRepo, eventSvc, achievementSvc, cfg.ClientEconomyLegacy)
+
+	// Mailer (SMTP when configured, otherwise emails are written to the outbox dir)
+	var mailer service.Mailer
+	if cfg.SMTPHost != "" {
+		mailer = service.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom)
+	} else {
+		log.Printf("SMTP not configured — emails go to outbox %q", cfg.MailOutboxDir)
+		mailer = service.NewOutboxMailer(cfg.MailOutboxDir)
+	}
+
+	// Bans (in-memory set checked on every authenticated request)
+	banSvc := service.NewBanService(banRepo, playerRepo, sessionRepo, wsHub, eventSvc)
+	if err := banSvc.Refresh(context.Background()); err != nil {
+		log.Fatalf("Failed to load bans: %v", err)
+	}
+
+	// Roles & permissions (in-memory, checked by RequireRole/RequirePermission)
+	permSvc := service.NewPermissionService(roleRepo, sessionRepo, eventSvc)
+	if err := permSvc.Refresh(context.Background()); err != nil {
+		log.Fatalf("Failed to load roles: %v", err)
+	}
+
+	// Game server identities (in-memory, checked on every /server request)
+	gameServerSvc := service.NewGameServerService(gameServerRepo, eventSvc)
+	if err := gameServerSvc.Refresh(context.Background()); err != nil {
+		log.Fatalf("Failed to load game servers: %v", err)
+	}
+
+	jwtKeys, err := service.LoadKeySet(cfg.JWTPrivateKeys, cfg.JWTKeysDir, cfg.JWTSigningKID, cfg.IsDevelopment())
+	if err != nil {
+		log.Fatalf("Failed to load JWT keys: %v", err)
+	}
+
+	authSvc := service.NewAuthService(playerRepo, sessionRepo, accountTokenRepo, twoFactorRepo, loginAttemptRepo, eventSvc, mailer, cfg.AccountURL, jwtKeys, cfg.JWTSecret, cfg.JWTLegacyUntil)
+
+	// Admin principals (staff accounts, API tokens) and audit log
+	adminSvc := service.NewAdminService(adminTokenRepo, adminAuditRepo, authSvc, banSvc, permSvc, eventSvc)
+
+	// Personal data export & account deletion
+	accountSvc := service.NewAccountService(
+		playerRepo, sessionRepo, loginAttemptRepo, banRepo, fleetRepo, marketRepo,
+		chatRepo, corpRepo, discordRepo, eventRepo, ledgerRepo, playSessionRepo, friendRepo, tradeRepo, mailRepo, authSvc, eventSvc, achievementSvc, wsHub, mailer,
+	)
+
+	// Credit ledger (statements, reconciliation)
+	ledgerSvc := service.NewCreditLedgerService(ledgerRepo)
+
+	// Public leaderboards (recomputed in the background, see below)
+	leaderboardSvc := service.NewLeaderboardService(leaderboardRepo)
+
+	// Play sessions (from game server heartbeats)
+	playSessionSvc := service.NewPlaySessionService(playSessionRepo)
 
 	// Discord bot (optional — starts only if token is configured)
 	discordBot, err := discord.NewBot(
@@ -111,23 +194,42 @@ func main() {
 	pub := v1.Group("/public")
 	pub.Get("/stats", publicH.Stats)
 
+	leaderboardH := handler.NewLeaderboardHandler(leaderboardSvc)
+	pub.Get("/leaderboards", leaderboardH.List)
+	pub.Get("/leaderboards/:board", leaderboardH.Get)
+
 	// Changelog (public GET, admin POST)
 	changelogH := handler.NewChangelogHandler(changelogRepo, webhookSvc)
 	v1.Get("/changelog", changelogH.List)
 
-	// Auth (public)
+	authMw := middleware.Auth(authSvc, banSvc)
+
+	// Auth (public, except resending the verification email)
 	authH := handler.NewAuthHandler(authSvc)
+	app.Get("/.well-known/jwks.json", authH.JWKS)
 	auth := v1.Group("/auth")
 	auth.Post("/register", middleware.RateLimit(5, time.Minute), authH.Register)
 	auth.Post("/login", middleware.RateLimit(10, time.Minute), authH.Login)
 	auth.Post("/refresh", middleware.RateLimit(20, time.Minute), authH.Refresh)
 	auth.Post("/logout", authH.Logout)
+	auth.Post("/2fa/verify", middleware.RateLimit(10, time.Minute), authH.VerifyTwoFactor)
+	auth.Post("/password/forgot", middleware.RateLimit(3, time.Minute), authH.ForgotPassword)
+	auth.Post("/password/reset", middleware.RateLimit(5, time.Minute), authH.ResetPassword)
+	auth.Post("/email/verify", middleware.RateLimit(10, time.Minute), authH.VerifyEmail)
+	auth.Post("/email/resend", authMw, middleware.RateLimit(3, time.Minute), authH.ResendVerification)
 
 	// Server-to-server (game server key auth) — registered BEFORE protected group
-	server := v1.Group("/server", middleware.ServerKey(cfg.ServerKey))
-	serverH := handler.NewServerHandler(authSvc, playerSvc, playerRepo)
+	legacyServerKey := ""
+	if cfg.ServerKeyLegacy {
+		legacyServerKey = cfg.ServerKey
+	}
+	server := v1.Group("/server", middleware.ServerAuth(gameServerSvc, legacyServerKey))
+	serverH := handler.NewServerHandler(authSvc, banSvc, playerSvc, playSessionSvc, presenceSvc)
 	server.Post("/validate-token", serverH.ValidateToken)
 	server.Post("/save-state", serverH.SaveState)
+	server.Patch("/save-state", serverH.PatchState)
+	server.Post("/save-states", serverH.SaveStates)
+	server.Post("/economy", serverH.SaveEconomy)
 	server.Post("/heartbeat", serverH.Heartbeat)
 	// Game server events
 	eventH := handler.NewEventHandler(eventSvc)
@@ -142,25 +244,110 @@ func main() {
 	chatH := handler.NewChatHandler(chatRepo)
 	server.Post("/chat/messages", chatH.PostMessage)
 	server.Get("/chat/history", chatH.GetHistory)
+	// Player blocks, to filter live chat and whispers
+	friendH := handler.NewFriendHandler(friendSvc)
+	server.Post("/blocks", friendH.ServerBlocks)
 
 	// Admin — registered BEFORE protected group
-	admin := v1.Group("/admin", middleware.AdminKey(cfg.AdminKey))
-	adminH := handler.NewAdminHandler(playerRepo, corpRepo, wsHub)
-	admin.Get("/stats", adminH.Stats)
-	admin.Post("/announce", adminH.Announce)
-	admin.Post("/changelog", changelogH.Create)
+	// Callers: staff accounts / API tokens (Authorization: Bearer) or the legacy X-Admin-Key.
+	// Every request is written to the admin audit log.
+	legacyAdminKey := ""
+	if cfg.AdminKeyLegacy {
+		legacyAdminKey = cfg.AdminKey
+	}
+	admin := v1.Group("/admin", middleware.AdminAuth(adminSvc, legacyAdminKey), middleware.Audit(adminSvc))
+	adminH := handler.NewAdminHandler(playerRepo, corpRepo, wsHub, authSvc, banSvc)
+	admin.Get("/stats", middleware.RequireScope(service.PermStatsRead), adminH.Stats)
+	playSessionH := handler.NewPlaySessionHandler(playSessionSvc)
+	admin.Get("/analytics/concurrency", middleware.RequireScope(service.PermStatsRead), playSessionH.Concurrency)
+	admin.Get("/analytics/retention", middleware.RequireScope(service.PermStatsRead), playSessionH.Retention)
+	admin.Get("/players/:id/sessions", middleware.RequireScope(service.PermPlayersView), playSessionH.PlayerSessions)
+	admin.Post("/announce", middleware.RequireScope(service.PermAnnounce), adminH.Announce)
+	admin.Get("/players/:id/login-attempts", middleware.RequireScope(service.PermPlayersView), adminH.LoginActivity)
+	admin.Post("/players/:id/unlock", middleware.RequireScope(service.PermPlayersUnlock), middleware.RequireOutrank(permSvc, "id"), adminH.UnlockAccount)
+	snapshotH := handler.NewPlayerSnapshotHandler(playerSvc)
+	admin.Get("/players/:id/snapshots", middleware.RequireScope(service.PermPlayersView), snapshotH.List)
+	admin.Get("/players/:id/snapshots/:snapshotId", middleware.RequireScope(service.PermPlayersView), snapshotH.Get)
+	admin.Get("/players/:id/snapshots/:snapshotId/diff", middleware.RequireScope(service.PermPlayersView), snapshotH.Diff)
+	admin.Post("/players/:id/snapshots/:snapshotId/restore", middleware.RequireScope(service.PermPlayersRestore), middleware.RequireOutrank(permSvc, "id"), snapshotH.Restore)
+	ledgerH := handler.NewCreditLedgerHandler(ledgerSvc)
+	admin.Get("/players/:id/credits", middleware.RequireScope(service.PermEconomyAudit), ledgerH.PlayerStatement)
+	admin.Get("/economy/reconcile", middleware.RequireScope(service.PermEconomyAudit), ledgerH.Reconcile)
+	tradeH := handler.NewTradeHandler(tradeSvc)
+	admin.Get("/players/:id/trades", middleware.RequireScope(service.PermEconomyAudit), tradeH.PlayerHistory)
+	admin.Get("/players/:id/bans", middleware.RequireScope(service.PermBansView), adminH.ListBans)
+	admin.Post("/players/:id/bans", middleware.RequireScope(service.PermBansIssue), middleware.RequireOutrank(permSvc, "id"), adminH.IssueBan)
+	admin.Post("/bans/:banId/lift", middleware.RequireScope(service.PermBansLift), middleware.RequireBanOutrank(permSvc, banSvc), adminH.LiftBan)
+	admin.Post("/bans/:banId/notes", middleware.RequireScope(service.PermBansIssue), middleware.RequireBanOutrank(permSvc, banSvc), adminH.AddBanAppealNote)
+	admin.Post("/changelog", middleware.RequireScope(service.PermChangelog), changelogH.Create)
+	roleH := handler.NewRoleHandler(permSvc)
+	admin.Get("/roles", middleware.RequireScope(service.PermRolesManage), roleH.ListRoles)
+	admin.Put("/players/:id/role", middleware.RequireScope(service.PermRolesManage), middleware.RequireOutrank(permSvc, "id"), roleH.SetRole)
+	gameServerH := handler.NewGameServerHandler(gameServerSvc)
+	admin.Get("/game-servers", middleware.RequireScope(service.PermGameServers), gameServerH.List)
+	admin.Post("/game-servers", middleware.RequireScope(service.PermGameServers), gameServerH.Register)
+	admin.Post("/game-servers/:id/rotate", middleware.RequireScope(service.PermGameServers), gameServerH.RotateSecret)
+	admin.Post("/game-servers/:id/revoke", middleware.RequireScope(service.PermGameServers), gameServerH.Revoke)
+	adminAccessH := handler.NewAdminAccessHandler(adminSvc)
+	admin.Get("/tokens", middleware.RequireScope(service.PermAdminTokens), adminAccessH.ListTokens)
+	admin.Post("/tokens", middleware.RequireScope(service.PermAdminTokens), adminAccessH.CreateToken)
+	admin.Delete("/tokens/:id", middleware.RequireScope(service.PermAdminTokens), adminAccessH.RevokeToken)
+	admin.Get("/audit", middleware.RequireScope(service.PermAuditRead), adminAccessH.AuditLog)
 
 	// JWT-protected routes — use explicit groups per resource instead of a
 	// catch-all Group("") which in Fiber acts like Use() and blocks public routes.
-	authMw := middleware.Auth(cfg.JWTSecret)
 
 	// Player
 	playerH := handler.NewPlayerHandler(playerSvc)
 	player := v1.Group("/player", authMw)
 	player.Get("/state", playerH.GetState)
 	player.Put("/state", playerH.SaveState)
+	player.Patch("/state", playerH.PatchState)
 	player.Get("/profile/:id", playerH.GetProfile)
 
+	achievementH := handler.NewAchievementHandler(achievementSvc)
+	player.Get("/achievements", achievementH.Mine)
+
+	// Player sessions (signed-in devices)
+	player.Get("/sessions", authH.ListSessions)
+	player.Delete("/sessions", authH.RevokeAllSessions)
+	player.Delete("/sessions/:id", authH.RevokeSession)
+	// Two-factor authentication
+	player.Post("/2fa/setup", authH.SetupTwoFactor)
+	player.Post("/2fa/enable", middleware.RateLimit(10, time.Minute), authH.EnableTwoFactor)
+	player.Post("/2fa/disable", middleware.RateLimit(10, time.Minute), authH.DisableTwoFactor)
+	player.Post("/2fa/recovery-codes", middleware.RateLimit(10, time.Minute), authH.RegenerateRecoveryCodes)
+
+	player.Get("/permissions", roleH.MyPermissions)
+
+	// Credit history
+	player.Get("/credits", ledgerH.MyStatement)
+
+	// Personal data export & account deletion
+	accountH := handler.NewAccountHandler(accountSvc)
+	player.Get("/export", middleware.RateLimit(2, time.Minute), accountH.Export)
+	player.Get("/deletion", accountH.DeletionStatus)
+	player.Post("/deletion", middleware.RateLimit(5, time.Minute), accountH.RequestDeletion)
+	player.Delete("/deletion", accountH.CancelDeletion)
+
+	// Staff tools — same actions as /admin, from a player account with the right role
+	mod := v1.Group("/mod", authMw, middleware.RequireRole(permSvc, "moderator"), middleware.Audit(adminSvc))
+	mod.Get("/chat/history", middleware.RequirePermission(permSvc, service.PermChatRead), chatH.GetHistory)
+	mod.Get("/players/:id/login-attempts", middleware.RequirePermission(permSvc, service.PermPlayersView), adminH.LoginActivity)
+	mod.Get("/players/:id/sessions", middleware.RequirePermission(permSvc, service.PermPlayersView), playSessionH.PlayerSessions)
+	mod.Post("/players/:id/unlock", middleware.RequirePermission(permSvc, service.PermPlayersUnlock), middleware.RequireOutrank(permSvc, "id"), adminH.UnlockAccount)
+	mod.Get("/players/:id/snapshots", middleware.RequirePermission(permSvc, service.PermPlayersView), snapshotH.List)
+	mod.Get("/players/:id/snapshots/:snapshotId", middleware.RequirePermission(permSvc, service.PermPlayersView), snapshotH.Get)
+	mod.Get("/players/:id/snapshots/:snapshotId/diff", middleware.RequirePermission(permSvc, service.PermPlayersView), snapshotH.Diff)
+	mod.Post("/players/:id/snapshots/:snapshotId/restore", middleware.RequirePermission(permSvc, service.PermPlayersRestore), middleware.RequireOutrank(permSvc, "id"), snapshotH.Restore)
+	mod.Get("/players/:id/bans", middleware.RequirePermission(permSvc, service.PermBansView), adminH.ListBans)
+	mod.Post("/players/:id/bans", middleware.RequirePermission(permSvc, service.PermBansIssue), middleware.RequireOutrank(permSvc, "id"), adminH.IssueBan)
+	mod.Post("/bans/:banId/lift", middleware.RequirePermission(permSvc, service.PermBansLift), middleware.RequireBanOutrank(permSvc, banSvc), adminH.LiftBan)
+	mod.Post("/bans/:banId/notes", middleware.RequirePermission(permSvc, service.PermBansIssue), middleware.RequireBanOutrank(permSvc, banSvc), adminH.AddBanAppealNote)
+	mod.Post("/announce", middleware.RequirePermission(permSvc, service.PermAnnounce), adminH.Announce)
+	mod.Get("/roles", middleware.RequirePermission(permSvc, service.PermRolesManage), roleH.ListRoles)
+	mod.Put("/players/:id/role", middleware.RequirePermission(permSvc, service.PermRolesManage), middleware.RequireOutrank(permSvc, "id"), roleH.SetRole)
+
 	// Player bug reports & Discord linking
 	bugH := handler.NewBugReportHandler(eventSvc)
 	player.Post("/bug-report", bugH.Submit)
@@ -195,6 +382,35 @@ func main() {
 	corporations.Post("/:id/applications", corpH.Apply)
 	corporations.Put("/:id/applications/:aid", corpH.HandleApplication)
 
+	// Friends
+	friends := v1.Group("/friends", authMw)
+	friends.Get("/", friendH.List)
+	friends.Post("/requests", middleware.RateLimit(20, time.Minute), friendH.SendRequest)
+	friends.Post("/requests/:id/accept", friendH.Accept)
+	friends.Post("/requests/:id/decline", friendH.Decline)
+	friends.Put("/blocked/:id", friendH.Block)
+	friends.Delete("/blocked/:id", friendH.Unblock)
+	friends.Delete("/:id", friendH.Remove)
+
+	// Trades
+	trades := v1.Group("/trades", authMw)
+	trades.Get("/", tradeH.List)
+	trades.Post("/", middleware.RateLimit(20, time.Minute), tradeH.Open)
+	trades.Get("/history", tradeH.History)
+	trades.Get("/:id", tradeH.Get)
+	trades.Put("/:id/offer", tradeH.SetOffer)
+	trades.Post("/:id/confirm", tradeH.Confirm)
+	trades.Delete("/:id", tradeH.Cancel)
+
+	// Mail
+	mailH := handler.NewMailHandler(mailSvc)
+	mail := v1.Group("/mail", authMw)
+	mail.Get("/", mailH.Inbox)
+	mail.Post("/", middleware.RateLimit(10, time.Minute), mailH.Send)
+	mail.Get("/:id", mailH.Read)
+	mail.Post("/:id/claim", mailH.Claim)
+	mail.Delete("/:id", mailH.Delete)
+
 	// Market (HDV)
 	marketH := handler.NewMarketHandler(marketSvc)
 	market := v1.Group("/market", authMw)
@@ -207,7 +423,7 @@ func main() {
 	market.Delete("/listings/:id", marketH.Cancel)
 
 	// WebSocket
-	wsH := handler.NewWSHandler(wsHub, cfg.JWTSecret)
+	wsH := handler.NewWSHandler(wsHub, authSvc, banSvc)
 	app.Get("/ws", wsH.Upgrade)
 
 	// Start hub
@@ -227,6 +443,155 @@ func main() {
 		}
 	}()
 
+	// Background: purge expired password reset / email verification tokens (runs hourly)
+	go func() {
+		ticker := time.NewTicker(1 * time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			deleted, err := accountTokenRepo.DeleteExpired(context.Background())
+			if err != nil {
+				log.Printf("Account token cleanup error: %v", err)
+			} else if deleted > 0 {
+				log.Printf("Account token cleanup: deleted %d expired tokens", deleted)
+			}
+		}
+	}()
+
+	// Background: purge login attempts older than 30 days (runs hourly)
+	go func() {
+		ticker := time.NewTicker(1 * time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			deleted, err := loginAttemptRepo.DeleteOlderThan(context.Background(), 30)
+			if err != nil {
+				log.Printf("Login attempt cleanup error: %v", err)
+			} else if deleted > 0 {
+				log.Printf("Login attempt cleanup: deleted %d old attempts", deleted)
+			}
+		}
+	}()
+
+	// Background: reload game servers and purge expired request nonces
+	go func() {
+		ticker := time.NewTicker(1 * time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := gameServerSvc.Refresh(context.Background()); err != nil {
+				log.Printf("Game server refresh error: %v", err)
+			}
+			if _, err := gameServerSvc.PurgeNonces(context.Background()); err != nil {
+				log.Printf("Game server nonce cleanup error: %v", err)
+			}
+		}
+	}()
+
+	// Background: purge daily state snapshots older than 30 days (runs hourly)
+	go func() {
+		ticker := time.NewTicker(1 * time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			deleted, err := playerSvc.PurgeDailySnapshots(context.Background())
+			if err != nil {
+				log.Printf("Snapshot cleanup error: %v", err)
+			} else if deleted > 0 {
+				log.Printf("Snapshot cleanup: deleted %d daily snapshots", deleted)
+			}
+		}
+	}()
+
+	// Background: check balances against the credit ledger (runs daily)
+	go func() {
+		ticker := time.NewTicker(24 * time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := ledgerSvc.Reconcile(context.Background()); err != nil {
+				log.Printf("Credit reconciliation error: %v", err)
+			}
+		}
+	}()
+
+	// Background: recompute the public leaderboards (at startup, then every 15 minutes)
+	go func() {
+		leaderboardSvc.Refresh(context.Background())
+		ticker := time.NewTicker(15 * time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			leaderboardSvc.Refresh(context.Background())
+		}
+	}()
+
+	// Background: close trades left open past their expiry
+	go func() {
+		ticker := time.NewTicker(1 * time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := tradeSvc.ExpireOld(context.Background()); err != nil {
+				log.Printf("Trade expiry error: %v", err)
+			}
+		}
+	}()
+
+	// Background: return unclaimed mail attachments and delete expired mail (runs hourly)
+	go func() {
+		ticker := time.NewTicker(1 * time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			deleted, err := mailSvc.ProcessExpired(context.Background())
+			if err != nil {
+				log.Printf("Mail expiry error: %v", err)
+			} else if deleted > 0 {
+				log.Printf("Mail expiry: deleted %d expired mails", deleted)
+			}
+		}
+	}()
+
+	// Background: end play sessions whose game server stopped sending heartbeats
+	go func() {
+		ticker := time.NewTicker(1 * time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := playSessionSvc.CloseIdle(context.Background()); err != nil {
+				log.Printf("Play session cleanup error: %v", err)
+			}
+		}
+	}()
+
+	// Background: run account deletions whose grace period is over (runs hourly)
+	go func() {
+		ticker := time.NewTicker(1 * time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			deleted, err := accountSvc.ProcessDueDeletions(context.Background())
+			if err != nil {
+				log.Printf("Account deletion error: %v", err)
+			} else if deleted > 0 {
+				log.Printf("Account deletion: deleted %d accounts", deleted)
+			}
+		}
+	}()
+
+	// Background: reload bans (expiries, scheduled bans, bans issued by other instances)
+	go func() {
+		ticker := time.NewTicker(30 * time.Second)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := banSvc.Refresh(context.Background()); err != nil {
+				log.Printf("Ban refresh error: %v", err)
+			}
+		}
+	}()
+
+	// Background: reload role permissions (edited directly in the database)
+	go func() {
+		ticker := time.NewTicker(5 * time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := permSvc.Refresh(context.Background()); err != nil {
+				log.Printf("Role refresh error: %v", err)
+			}
+		}
+	}()
+
 	// Background: expire old market listings (runs every 10 minutes)
 	go func() {
 		ticker := time.NewTicker(10 * time.Minute)
@@ -0,0 +1,63 @@
+// Command upgradestates rewrites every stored save whose JSON sections are below their
+// current schema version (see internal/stateschema). Saves are also upgraded when they
+// are loaded, so this can run at any time after a deploy.
+//
+//	go run ./cmd/upgradestates              # upgrade every outdated save
+//	go run ./cmd/upgradestates -dry-run     # only count them
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"spacegame-backend/internal/config"
+	"spacegame-backend/internal/database"
+	"spacegame-backend/internal/repository"
+)
+
+func main() {
+	batch := flag.Int("batch", 500, "players loaded per query")
+	dryRun := flag.Bool("dry-run", false, "count outdated saves without writing them")
+	flag.Parse()
+
+	ctx := context.Background()
+	cfg := config.Load()
+	db, err := database.NewPool(ctx, cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+	playerRepo := repository.NewPlayerRepository(db)
+
+	var outdated, upgraded, failed int
+	after := ""
+	for {
+		ids, err := playerRepo.ListOutdatedSchemaIDs(ctx, after, *batch)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(ids) == 0 {
+			break
+		}
+		after = ids[len(ids)-1]
+		outdated += len(ids)
+		if *dryRun {
+			continue
+		}
+		for _, id := range ids {
+			if _, err := playerRepo.UpgradeStoredSchemas(ctx, id); err != nil {
+				log.Printf("[SCHEMA] failed to upgrade save of %s: %v", id, err)
+				failed++
+				continue
+			}
+			upgraded++
+		}
+	}
+
+	if *dryRun {
+		log.Printf("[SCHEMA] %d outdated saves", outdated)
+		return
+	}
+	log.Printf("[SCHEMA] upgraded %d of %d outdated saves (%d failed)", upgraded, outdated, failed)
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"spacegame-backend/internal/model"
+	"spacegame-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AccountHandler struct {
+	accountSvc *service.AccountService
+}
+
+func NewAccountHandler(accountSvc *service.AccountService) *AccountHandler {
+	return &AccountHandler{accountSvc: accountSvc}
+}
+
+// Export downloads everything stored about the player as one JSON file.
+// GET /api/v1/player/export
+func (h *AccountHandler) Export(c *fiber.Ctx) error {
+	playerID := c.Locals("player_id").(string)
+
+	export, err := h.accountSvc.Export(c.Context(), playerID)
+	if err != nil {
+		log.Printf("[ACCOUNT] export failed for %s: %v", playerID, err)
+		return c.Status(500).JSON(fiber.Map{"error": "failed to export account data"})
+	}
+
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to export account data"})
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+	c.Attachment("imperion-account-" + time.Now().UTC().Format("20060102") + ".json")
+	return c.Send(data)
+}
+
+// DeletionStatus tells whether the account is scheduled for deletion.
+// GET /api/v1/player/deletion
+func (h *AccountHandler) DeletionStatus(c *fiber.Ctx) error {
+	playerID := c.Locals("player_id").(string)
+
+	status, err := h.accountSvc.DeletionStatus(c.Context(), playerID)
+	if err != nil {
+		return accountError(c, err)
+	}
+	return c.JSON(status)
+}
+
+// RequestDeletion schedules the account for deletion (password, plus code with 2FA).
+// POST /api/v1/player/deletion
+func (h *AccountHandler) RequestDeletion(c *fiber.Ctx) error {
+	playerID := c.Locals("player_id").(string)
+
+	var req model.AccountDeletionRequest
+	if err := c.BodyParser(&req); err != nil || req.Password == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "password is required"})
+	}
+
+	status, err := h.accountSvc.RequestDeletion(c.Context(), playerID, req.Password, req.Code)
+	if err != nil {
+		return accountError(c, err)
+	}
+	return c.Status(202).JSON(status)
+}
+
+// CancelDeletion aborts a pending account deletion.
+// DELETE /api/v1/player/deletion
+func (h *AccountHandler) CancelDeletion(c *fiber.Ctx) error {
+	playerID := c.Locals("player_id").(string)
+
+	if err := h.accountSvc.CancelDeletion(c.Context(), playerID); err != nil {
+		return accountError(c, err)
+	}
+	return c.JSON(fiber.Map{"ok": true})
+}
+
+func accountError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, service.ErrDeletionNotScheduled) {
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	}
+	return authError(c, err)
+}
@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"spacegame-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AchievementHandler struct {
+	achievementSvc *service.AchievementService
+}
+
+func NewAchievementHandler(achievementSvc *service.AchievementService) *AchievementHandler {
+	return &AchievementHandler{achievementSvc: achievementSvc}
+}
+
+// Mine returns every achievement with the caller's unlock time (unlocked_at absent if locked).
+// GET /api/v1/player/achievements
+func (h *AchievementHandler) Mine(c *fiber.Ctx) error {
+	achievements, err := h.achievementSvc.ForPlayer(c.Context(), c.Locals("player_id").(string))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to get achievements"})
+	}
+	return c.JSON(fiber.Map{"achievements": achievements})
+}
@@ -2,6 +2,8 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"strconv"
 
 	"spacegame-backend/internal/model"
 	"spacegame-backend/internal/repository"
@@ -14,10 +16,12 @@ type AdminHandler struct {
 	playerRepo *repository.PlayerRepository
 	corpRepo   *repository.CorporationRepository
 	wsHub      *service.WSHub
+	authSvc    *service.AuthService
+	banSvc     *service.BanService
 }
 
-func NewAdminHandler(playerRepo *repository.PlayerRepository, corpRepo *repository.CorporationRepository, wsHub *service.WSHub) *AdminHandler {
-	return &AdminHandler{playerRepo: playerRepo, corpRepo: corpRepo, wsHub: wsHub}
+func NewAdminHandler(playerRepo *repository.PlayerRepository, corpRepo *repository.CorporationRepository, wsHub *service.WSHub, authSvc *service.AuthService, banSvc *service.BanService) *AdminHandler {
+	return &AdminHandler{playerRepo: playerRepo, corpRepo: corpRepo, wsHub: wsHub, authSvc: authSvc, banSvc: banSvc}
 }
 
 func (h *AdminHandler) Stats(c *fiber.Ctx) error {
@@ -50,3 +54,114 @@ func (h *AdminHandler) Announce(c *fiber.Ctx) error {
 
 	return c.JSON(fiber.Map{"ok": true, "online": h.wsHub.OnlineCount()})
 }
+
+// LoginActivity returns a player's lockout state and recent login attempts.
+// GET /api/v1/admin/players/:id/login-attempts
+func (h *AdminHandler) LoginActivity(c *fiber.Ctx) error {
+	state, attempts, err := h.authSvc.LoginActivity(c.Context(), c.Params("id"))
+	if err != nil {
+		return authError(c, err)
+	}
+	return c.JSON(fiber.Map{"lock": state, "attempts": attempts})
+}
+
+// UnlockAccount clears a player's failed logins and lockout.
+// POST /api/v1/admin/players/:id/unlock
+func (h *AdminHandler) UnlockAccount(c *fiber.Ctx) error {
+	if err := h.authSvc.UnlockAccount(c.Context(), c.Params("id")); err != nil {
+		return authError(c, err)
+	}
+	return c.JSON(fiber.Map{"ok": true})
+}
+
+// ListBans returns a player's ban history.
+// GET /api/v1/admin/players/:id/bans
+func (h *AdminHandler) ListBans(c *fiber.Ctx) error {
+	bans, err := h.banSvc.ListForPlayer(c.Context(), c.Params("id"))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to list bans"})
+	}
+	return c.JSON(fiber.Map{"bans": bans})
+}
+
+// IssueBan bans a player and disconnects them immediately.
+// POST /api/v1/admin/players/:id/bans
+func (h *AdminHandler) IssueBan(c *fiber.Ctx) error {
+	var req model.IssueBanRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	req.IssuedBy = actorName(c, req.IssuedBy)
+	ban, err := h.banSvc.Issue(c.Context(), c.Params("id"), &req)
+	if err != nil {
+		return banError(c, err)
+	}
+	return c.Status(201).JSON(ban)
+}
+
+// LiftBan ends a ban early.
+// POST /api/v1/admin/bans/:banId/lift
+func (h *AdminHandler) LiftBan(c *fiber.Ctx) error {
+	banID, err := strconv.ParseInt(c.Params("banId"), 10, 64)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid ban id"})
+	}
+
+	var req model.LiftBanRequest
+	_ = c.BodyParser(&req)
+
+	ban, err := h.banSvc.Lift(c.Context(), banID, actorName(c, req.LiftedBy), req.Note)
+	if err != nil {
+		return banError(c, err)
+	}
+	return c.JSON(ban)
+}
+
+// AddBanAppealNote appends a note to a ban's appeal notes.
+// POST /api/v1/admin/bans/:banId/notes
+func (h *AdminHandler) AddBanAppealNote(c *fiber.Ctx) error {
+	banID, err := strconv.ParseInt(c.Params("banId"), 10, 64)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid ban id"})
+	}
+
+	var req model.BanAppealNoteRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	ban, err := h.banSvc.AddAppealNote(c.Context(), banID, actorName(c, req.Author), req.Note)
+	if err != nil {
+		return banError(c, err)
+	}
+	return c.JSON(ban)
+}
+
+// actorName identifies who performs a staff action: the authenticated account or
+// API token, or the name given in the request with the legacy X-Admin-Key.
+func actorName(c *fiber.Ctx, given string) string {
+	if principal, ok := c.Locals("admin").(*model.AdminPrincipal); ok && principal.Type == model.AdminActorKey {
+		if given != "" {
+			return given
+		}
+		return principal.Name
+	}
+	if username, ok := c.Locals("username").(string); ok && username != "" {
+		return username
+	}
+	return "admin-key"
+}
+
+func banError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrPlayerNotFound):
+		return c.Status(404).JSON(fiber.Map{"error": "player not found"})
+	case errors.Is(err, service.ErrBanNotFound):
+		return c.Status(404).JSON(fiber.Map{"error": "ban not found or already lifted"})
+	case errors.Is(err, service.ErrInvalidBan), errors.Is(err, service.ErrBanReasonSize), errors.Is(err, service.ErrEmptyNote):
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+	}
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"errors"
+	"time"
+
+	"spacegame-backend/internal/model"
+	"spacegame-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AdminAccessHandler manages admin API tokens and exposes the admin audit log.
+type AdminAccessHandler struct {
+	adminSvc *service.AdminService
+}
+
+func NewAdminAccessHandler(adminSvc *service.AdminService) *AdminAccessHandler {
+	return &AdminAccessHandler{adminSvc: adminSvc}
+}
+
+// ListTokens returns every admin API token.
+// GET /api/v1/admin/tokens
+func (h *AdminAccessHandler) ListTokens(c *fiber.Ctx) error {
+	tokens, err := h.adminSvc.ListTokens(c.Context())
+	if err != nil {
+		return adminAccessError(c, err)
+	}
+	return c.JSON(fiber.Map{"tokens": tokens})
+}
+
+// CreateToken issues a scoped API token. The token is only returned in this response.
+// POST /api/v1/admin/tokens
+func (h *AdminAccessHandler) CreateToken(c *fiber.Ctx) error {
+	var req model.CreateAdminTokenRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	token, err := h.adminSvc.CreateToken(c.Context(), c.Locals("admin").(*model.AdminPrincipal), &req)
+	if err != nil {
+		return adminAccessError(c, err)
+	}
+	return c.Status(201).JSON(token)
+}
+
+// RevokeToken disables an admin API token.
+// DELETE /api/v1/admin/tokens/:id
+func (h *AdminAccessHandler) RevokeToken(c *fiber.Ctx) error {
+	if err := h.adminSvc.RevokeToken(c.Context(), c.Locals("admin").(*model.AdminPrincipal), c.Params("id")); err != nil {
+		return adminAccessError(c, err)
+	}
+	return c.JSON(fiber.Map{"ok": true})
+}
+
+// AuditLog browses admin actions, newest first. Page with before_id.
+// GET /api/v1/admin/audit?actor=&action=&target=&since=&until=&before_id=&limit=
+func (h *AdminAccessHandler) AuditLog(c *fiber.Ctx) error {
+	filter := model.AdminAuditFilter{
+		Actor:    c.Query("actor"),
+		Action:   c.Query("action"),
+		Target:   c.Query("target"),
+		BeforeID: int64(c.QueryInt("before_id", 0)),
+		Limit:    c.QueryInt("limit", 50),
+	}
+	for param, dst := range map[string]**time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if v := c.Query(param); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{"error": param + " must be an RFC 3339 timestamp"})
+			}
+			*dst = &t
+		}
+	}
+
+	entries, err := h.adminSvc.QueryAudit(c.Context(), filter)
+	if err != nil {
+		return adminAccessError(c, err)
+	}
+	return c.JSON(fiber.Map{"entries": entries})
+}
+
+func adminAccessError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrAdminTokenNotFound):
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrAdminTokenExists):
+		return c.Status(409).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidAdminToken), errors.Is(err, service.ErrUnknownScope):
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrScopeNotHeld):
+		return c.Status(403).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+	}
+}
@@ -2,6 +2,9 @@ package handler
 
 import (
 	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"spacegame-backend/internal/model"
 	"spacegame-backend/internal/service"
@@ -27,7 +30,7 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "username, email and password are required"})
 	}
 
-	resp, err := h.authSvc.Register(c.Context(), &req)
+	resp, err := h.authSvc.Register(c.Context(), &req, sessionMeta(c, req.DeviceLabel))
 	if err != nil {
 		return authError(c, err)
 	}
@@ -45,7 +48,7 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "username and password are required"})
 	}
 
-	resp, err := h.authSvc.Login(c.Context(), &req)
+	resp, err := h.authSvc.Login(c.Context(), &req, sessionMeta(c, req.DeviceLabel))
 	if err != nil {
 		return authError(c, err)
 	}
@@ -63,7 +66,7 @@ func (h *AuthHandler) Refresh(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "refresh_token is required"})
 	}
 
-	tokens, err := h.authSvc.Refresh(c.Context(), req.RefreshToken)
+	tokens, err := h.authSvc.Refresh(c.Context(), req.RefreshToken, sessionMeta(c, req.DeviceLabel))
 	if err != nil {
 		return authError(c, err)
 	}
@@ -84,7 +87,226 @@ func (h *AuthHandler) Logout(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"ok": true})
 }
 
+// JWKS publishes the public keys access tokens are signed with, so game servers
+// can validate tokens locally instead of calling /server/validate-token.
+// GET /.well-known/jwks.json
+func (h *AuthHandler) JWKS(c *fiber.Ctx) error {
+	c.Set("Cache-Control", "public, max-age=300")
+	return c.JSON(h.authSvc.JWKS())
+}
+
+// VerifyTwoFactor exchanges a login challenge token and a TOTP or recovery code for tokens.
+// POST /api/v1/auth/2fa/verify
+func (h *AuthHandler) VerifyTwoFactor(c *fiber.Ctx) error {
+	var req model.TwoFactorVerifyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if req.ChallengeToken == "" || req.Code == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "challenge_token and code are required"})
+	}
+
+	resp, err := h.authSvc.VerifyTwoFactorLogin(c.Context(), req.ChallengeToken, req.Code, sessionMeta(c, req.DeviceLabel))
+	if err != nil {
+		return authError(c, err)
+	}
+	return c.JSON(resp)
+}
+
+// SetupTwoFactor starts TOTP enrolment and returns the secret and provisioning URI.
+// POST /api/v1/player/2fa/setup
+func (h *AuthHandler) SetupTwoFactor(c *fiber.Ctx) error {
+	playerID := c.Locals("player_id").(string)
+
+	setup, err := h.authSvc.SetupTwoFactor(c.Context(), playerID)
+	if err != nil {
+		return authError(c, err)
+	}
+	return c.JSON(setup)
+}
+
+// EnableTwoFactor confirms enrolment with a TOTP code and returns the recovery codes.
+// POST /api/v1/player/2fa/enable
+func (h *AuthHandler) EnableTwoFactor(c *fiber.Ctx) error {
+	playerID := c.Locals("player_id").(string)
+
+	var req model.TwoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil || req.Code == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "code is required"})
+	}
+
+	codes, err := h.authSvc.EnableTwoFactor(c.Context(), playerID, req.Code)
+	if err != nil {
+		return authError(c, err)
+	}
+	return c.JSON(fiber.Map{"ok": true, "recovery_codes": codes})
+}
+
+// DisableTwoFactor turns 2FA off (password + code required).
+// POST /api/v1/player/2fa/disable
+func (h *AuthHandler) DisableTwoFactor(c *fiber.Ctx) error {
+	playerID := c.Locals("player_id").(string)
+
+	var req model.TwoFactorDisableRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if req.Password == "" || req.Code == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "password and code are required"})
+	}
+
+	if err := h.authSvc.DisableTwoFactor(c.Context(), playerID, req.Password, req.Code); err != nil {
+		return authError(c, err)
+	}
+	return c.JSON(fiber.Map{"ok": true})
+}
+
+// RegenerateRecoveryCodes replaces the player's recovery codes.
+// POST /api/v1/player/2fa/recovery-codes
+func (h *AuthHandler) RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	playerID := c.Locals("player_id").(string)
+
+	var req model.TwoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil || req.Code == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "code is required"})
+	}
+
+	codes, err := h.authSvc.RegenerateRecoveryCodes(c.Context(), playerID, req.Code)
+	if err != nil {
+		return authError(c, err)
+	}
+	return c.JSON(fiber.Map{"recovery_codes": codes})
+}
+
+// ForgotPassword mails a password reset link. Always answers ok to avoid leaking which emails exist.
+// POST /api/v1/auth/password/forgot
+func (h *AuthHandler) ForgotPassword(c *fiber.Ctx) error {
+	var req model.ForgotPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if req.Email == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "email is required"})
+	}
+
+	if err := h.authSvc.RequestPasswordReset(c.Context(), req.Email); err != nil {
+		return authError(c, err)
+	}
+	return c.JSON(fiber.Map{"ok": true})
+}
+
+// ResetPassword sets a new password from a reset token.
+// POST /api/v1/auth/password/reset
+func (h *AuthHandler) ResetPassword(c *fiber.Ctx) error {
+	var req model.ResetPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if req.Token == "" || req.Password == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "token and password are required"})
+	}
+
+	if err := h.authSvc.ResetPassword(c.Context(), req.Token, req.Password); err != nil {
+		return authError(c, err)
+	}
+	return c.JSON(fiber.Map{"ok": true})
+}
+
+// VerifyEmail confirms the account email from a verification token.
+// POST /api/v1/auth/email/verify
+func (h *AuthHandler) VerifyEmail(c *fiber.Ctx) error {
+	var req model.VerifyEmailRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if req.Token == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "token is required"})
+	}
+
+	if err := h.authSvc.VerifyEmail(c.Context(), req.Token); err != nil {
+		return authError(c, err)
+	}
+	return c.JSON(fiber.Map{"ok": true})
+}
+
+// ResendVerification mails a new verification link to the authenticated player.
+// POST /api/v1/auth/email/resend
+func (h *AuthHandler) ResendVerification(c *fiber.Ctx) error {
+	playerID := c.Locals("player_id").(string)
+
+	if err := h.authSvc.ResendVerification(c.Context(), playerID); err != nil {
+		return authError(c, err)
+	}
+	return c.JSON(fiber.Map{"ok": true})
+}
+
+// ListSessions returns the player's active sessions (one per signed-in device).
+// GET /api/v1/player/sessions
+func (h *AuthHandler) ListSessions(c *fiber.Ctx) error {
+	playerID := c.Locals("player_id").(string)
+	currentID, _ := c.Locals("session_id").(string)
+
+	sessions, err := h.authSvc.ListSessions(c.Context(), playerID, currentID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to list sessions"})
+	}
+	if sessions == nil {
+		sessions = []model.Session{}
+	}
+	return c.JSON(fiber.Map{"sessions": sessions})
+}
+
+// RevokeSession revokes a single session.
+// DELETE /api/v1/player/sessions/:id
+func (h *AuthHandler) RevokeSession(c *fiber.Ctx) error {
+	playerID := c.Locals("player_id").(string)
+
+	if err := h.authSvc.RevokeSession(c.Context(), playerID, c.Params("id")); err != nil {
+		return authError(c, err)
+	}
+	return c.JSON(fiber.Map{"ok": true})
+}
+
+// RevokeAllSessions signs the player out on every device, including this one.
+// DELETE /api/v1/player/sessions
+func (h *AuthHandler) RevokeAllSessions(c *fiber.Ctx) error {
+	playerID := c.Locals("player_id").(string)
+
+	if err := h.authSvc.RevokeAllSessions(c.Context(), playerID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to revoke sessions"})
+	}
+	return c.JSON(fiber.Map{"ok": true})
+}
+
+// sessionMeta captures the device metadata stored alongside a refresh token.
+func sessionMeta(c *fiber.Ctx, deviceLabel string) model.SessionMeta {
+	return model.SessionMeta{
+		DeviceLabel: truncateRunes(strings.TrimSpace(deviceLabel), 64),
+		IPAddress:   c.IP(),
+		UserAgent:   truncateRunes(c.Get("User-Agent"), 255),
+	}
+}
+
+// truncateRunes cuts s to at most n characters (the VARCHAR limit), never inside one.
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
 func authError(c *fiber.Ctx, err error) error {
+	var throttled *service.LoginThrottledError
+	if errors.As(err, &throttled) {
+		retryAfter := int(throttled.RetryAfter.Seconds()) + 1
+		c.Set("Retry-After", strconv.Itoa(retryAfter))
+		msg := "too many failed login attempts"
+		if throttled.Locked {
+			msg = "account temporarily locked"
+		}
+		return c.Status(429).JSON(fiber.Map{"error": msg, "retry_after": retryAfter})
+	}
+
 	switch {
 	case errors.Is(err, service.ErrInvalidCredentials):
 		return c.Status(401).JSON(fiber.Map{"error": "invalid credentials"})
@@ -98,6 +320,18 @@ func authError(c *fiber.Ctx, err error) error {
 		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	case errors.Is(err, service.ErrInvalidUsername):
 		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrSessionNotFound):
+		return c.Status(404).JSON(fiber.Map{"error": "session not found"})
+	case errors.Is(err, service.ErrAlreadyVerified):
+		return c.Status(409).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrPlayerNotFound):
+		return c.Status(404).JSON(fiber.Map{"error": "player not found"})
+	case errors.Is(err, service.ErrInvalidTwoFactorCode):
+		return c.Status(401).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrTwoFactorAlreadyEnabled):
+		return c.Status(409).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrTwoFactorNotEnabled), errors.Is(err, service.ErrTwoFactorNotSetup):
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	default:
 		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
 	}
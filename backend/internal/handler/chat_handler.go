@@ -2,6 +2,7 @@ package handler
 
 import (
 	"log"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -11,6 +12,8 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
 type ChatHandler struct {
 	chatRepo *repository.ChatRepository
 }
@@ -36,7 +39,7 @@ func (h *ChatHandler) PostMessage(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "channel must be 0-3"})
 	}
 
-	if err := h.chatRepo.InsertMessage(c.Context(), req); err != nil {
+	if err := h.chatRepo.InsertMessage(c.Context(), req, serverOrigin(c)); err != nil {
 		log.Printf("[Chat] PostMessage DB error: %v", err)
 		return c.Status(500).JSON(fiber.Map{"error": "failed to store message"})
 	}
@@ -45,8 +48,9 @@ func (h *ChatHandler) PostMessage(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"ok": true})
 }
 
-// GetHistory returns recent chat messages for the requested channels.
-// GET /api/v1/server/chat/history?channels=0,1,2,3&system_id=5&limit=50
+// GetHistory returns recent chat messages for the requested channels. With player_id (a
+// UUID), messages from players that player blocked, or who blocked them, are left out.
+// GET /api/v1/server/chat/history?channels=0,1,2,3&system_id=5&limit=50&player_id=...
 func (h *ChatHandler) GetHistory(c *fiber.Ctx) error {
 	// Parse channels
 	channelsStr := c.Query("channels", "0,1,2,3")
@@ -70,9 +74,14 @@ func (h *ChatHandler) GetHistory(c *fiber.Ctx) error {
 		limit = 50
 	}
 
-	log.Printf("[Chat] GetHistory: channels=%v system_id=%d limit=%d", channels, systemID, limit)
+	viewerID := c.Query("player_id")
+	if viewerID != "" && !uuidPattern.MatchString(viewerID) {
+		return c.Status(400).JSON(fiber.Map{"error": "player_id must be a UUID"})
+	}
+
+	log.Printf("[Chat] GetHistory: channels=%v system_id=%d limit=%d player_id=%s", channels, systemID, limit, viewerID)
 
-	msgs, err := h.chatRepo.GetHistory(c.Context(), channels, systemID, limit)
+	msgs, err := h.chatRepo.GetHistory(c.Context(), channels, systemID, limit, viewerID)
 	if err != nil {
 		log.Printf("[Chat] GetHistory DB error: %v", err)
 		return c.Status(500).JSON(fiber.Map{"error": "failed to get history"})
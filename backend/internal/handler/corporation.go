@@ -157,12 +157,12 @@ func (h *CorporationHandler) Deposit(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "invalid request body"})
 	}
 
-	newBalance, err := h.corpSvc.Deposit(c.Context(), playerID, corporationID, req.Amount)
+	newBalance, credits, err := h.corpSvc.Deposit(c.Context(), playerID, corporationID, req.Amount)
 	if err != nil {
 		return corporationError(c, err)
 	}
 
-	return c.JSON(fiber.Map{"treasury": newBalance})
+	return c.JSON(fiber.Map{"treasury": newBalance, "credits": credits})
 }
 
 func (h *CorporationHandler) Withdraw(c *fiber.Ctx) error {
@@ -174,12 +174,12 @@ func (h *CorporationHandler) Withdraw(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "invalid request body"})
 	}
 
-	newBalance, err := h.corpSvc.Withdraw(c.Context(), playerID, corporationID, req.Amount)
+	newBalance, credits, err := h.corpSvc.Withdraw(c.Context(), playerID, corporationID, req.Amount)
 	if err != nil {
 		return corporationError(c, err)
 	}
 
-	return c.JSON(fiber.Map{"treasury": newBalance})
+	return c.JSON(fiber.Map{"treasury": newBalance, "credits": credits})
 }
 
 func (h *CorporationHandler) GetActivity(c *fiber.Ctx) error {
@@ -393,8 +393,12 @@ func corporationError(c *fiber.Ctx, err error) error {
 		return c.Status(400).JSON(fiber.Map{"error": "invalid amount"})
 	case errors.Is(err, service.ErrInsufficientFunds):
 		return c.Status(400).JSON(fiber.Map{"error": "insufficient funds"})
+	case errors.Is(err, service.ErrInsufficientCredits):
+		return c.Status(400).JSON(fiber.Map{"error": "insufficient credits"})
 	case errors.Is(err, service.ErrAlreadyApplied):
 		return c.Status(409).JSON(fiber.Map{"error": "already applied to this corporation"})
+	case errors.Is(err, service.ErrBlocked):
+		return c.Status(403).JSON(fiber.Map{"error": err.Error()})
 	case errors.Is(err, service.ErrApplicationNotFound):
 		return c.Status(404).JSON(fiber.Map{"error": "application not found"})
 	case errors.Is(err, service.ErrNameTooLong), errors.Is(err, service.ErrNameTooShort),
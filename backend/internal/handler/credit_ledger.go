@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"strconv"
+
+	"spacegame-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CreditLedgerHandler struct {
+	ledgerSvc *service.CreditLedgerService
+}
+
+func NewCreditLedgerHandler(ledgerSvc *service.CreditLedgerService) *CreditLedgerHandler {
+	return &CreditLedgerHandler{ledgerSvc: ledgerSvc}
+}
+
+// MyStatement returns the caller's credit history (?before_id=&limit=).
+// GET /api/v1/player/credits
+func (h *CreditLedgerHandler) MyStatement(c *fiber.Ctx) error {
+	return h.statement(c, c.Locals("player_id").(string))
+}
+
+// PlayerStatement returns a player's credit history.
+// GET /api/v1/admin/players/:id/credits
+func (h *CreditLedgerHandler) PlayerStatement(c *fiber.Ctx) error {
+	return h.statement(c, c.Params("id"))
+}
+
+// Reconcile lists players whose credits don't match their ledger.
+// GET /api/v1/admin/economy/reconcile
+func (h *CreditLedgerHandler) Reconcile(c *fiber.Ctx) error {
+	result, err := h.ledgerSvc.Reconcile(c.Context())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to reconcile credits"})
+	}
+	return c.JSON(result)
+}
+
+func (h *CreditLedgerHandler) statement(c *fiber.Ctx, playerID string) error {
+	beforeID, _ := strconv.ParseInt(c.Query("before_id"), 10, 64)
+	limit, _ := strconv.Atoi(c.Query("limit", "50"))
+
+	entries, err := h.ledgerSvc.Statement(c.Context(), playerID, beforeID, limit)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to get credit history"})
+	}
+	return c.JSON(fiber.Map{"entries": entries})
+}
@@ -26,24 +26,25 @@ func (h *EventHandler) RecordEvent(c *fiber.Ctx) error {
 	}
 
 	ctx := c.Context()
+	serverID := serverOrigin(c)
 
 	switch req.Type {
 	case "kill":
-		h.eventSvc.RecordKill(ctx, req.Killer, req.Victim, req.Weapon, req.System, req.SystemID)
+		h.eventSvc.RecordKill(ctx, serverID, req.Killer, req.Victim, req.Weapon, req.System, req.SystemID)
 	case "discovery":
-		h.eventSvc.RecordDiscovery(ctx, req.ActorName, req.TargetName, req.System, req.SystemID)
+		h.eventSvc.RecordDiscovery(ctx, serverID, req.ActorName, req.TargetName, req.System, req.SystemID)
 	case "economy":
 		details := ""
 		if req.Details != nil {
 			details = string(req.Details)
 		}
-		h.eventSvc.RecordEconomyEvent(ctx, req.Type, details)
+		h.eventSvc.RecordEconomyEvent(ctx, serverID, req.Type, details)
 	case "corporation_created", "corporation_deleted", "corporation_alliance", "corporation_war":
 		details := ""
 		if req.Details != nil {
 			details = string(req.Details)
 		}
-		h.eventSvc.RecordCorporationEvent(ctx, req.Type, req.ActorName, details)
+		h.eventSvc.RecordCorporationEvent(ctx, serverID, req.Type, req.ActorName, details)
 	default:
 		return c.Status(400).JSON(fiber.Map{"error": "unknown event type: " + req.Type})
 	}
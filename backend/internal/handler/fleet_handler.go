@@ -38,7 +38,7 @@ func (h *FleetHandler) SyncPositions(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "invalid request body"})
 	}
 
-	if err := h.fleetRepo.BatchUpdatePositions(c.Context(), req.Updates); err != nil {
+	if err := h.fleetRepo.BatchUpdatePositions(c.Context(), req.Updates, serverOrigin(c)); err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "failed to sync positions"})
 	}
 
@@ -74,7 +74,7 @@ func (h *FleetHandler) BulkUpsert(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "invalid request body"})
 	}
 
-	if err := h.fleetRepo.BulkUpsertFleetShips(c.Context(), req.Ships); err != nil {
+	if err := h.fleetRepo.BulkUpsertFleetShips(c.Context(), req.Ships, serverOrigin(c)); err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "failed to upsert fleet ships"})
 	}
 
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"errors"
+
+	"spacegame-backend/internal/model"
+	"spacegame-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type FriendHandler struct {
+	friendSvc *service.FriendService
+}
+
+func NewFriendHandler(friendSvc *service.FriendService) *FriendHandler {
+	return &FriendHandler{friendSvc: friendSvc}
+}
+
+// List returns the caller's friends (with presence), friend requests and blocked players.
+// GET /api/v1/friends
+func (h *FriendHandler) List(c *fiber.Ctx) error {
+	list, err := h.friendSvc.List(c.Context(), c.Locals("player_id").(string))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to get friends"})
+	}
+	return c.JSON(list)
+}
+
+// SendRequest sends a friend request by username ("accepted" when it answered theirs).
+// POST /api/v1/friends/requests
+func (h *FriendHandler) SendRequest(c *fiber.Ctx) error {
+	var req model.FriendRequestRequest
+	if err := c.BodyParser(&req); err != nil || req.Username == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "username is required"})
+	}
+	accepted, err := h.friendSvc.SendRequest(c.Context(), c.Locals("player_id").(string), req.Username)
+	if err != nil {
+		return friendError(c, err)
+	}
+	return c.JSON(fiber.Map{"ok": true, "accepted": accepted})
+}
+
+// Accept accepts the friend request sent by :id.
+// POST /api/v1/friends/requests/:id/accept
+func (h *FriendHandler) Accept(c *fiber.Ctx) error {
+	if err := h.friendSvc.Respond(c.Context(), c.Locals("player_id").(string), c.Params("id"), true); err != nil {
+		return friendError(c, err)
+	}
+	return c.JSON(fiber.Map{"ok": true})
+}
+
+// Decline declines the friend request sent by :id.
+// POST /api/v1/friends/requests/:id/decline
+func (h *FriendHandler) Decline(c *fiber.Ctx) error {
+	if err := h.friendSvc.Respond(c.Context(), c.Locals("player_id").(string), c.Params("id"), false); err != nil {
+		return friendError(c, err)
+	}
+	return c.JSON(fiber.Map{"ok": true})
+}
+
+// Remove unfriends :id, or cancels a pending request with them.
+// DELETE /api/v1/friends/:id
+func (h *FriendHandler) Remove(c *fiber.Ctx) error {
+	if err := h.friendSvc.Remove(c.Context(), c.Locals("player_id").(string), c.Params("id")); err != nil {
+		return friendError(c, err)
+	}
+	return c.JSON(fiber.Map{"ok": true})
+}
+
+// Block blocks :id. Trades, friend requests and chat history apply it at once; live chat
+// on a game server follows within a minute (its block cache refreshes with its heartbeat).
+// PUT /api/v1/friends/blocked/:id
+func (h *FriendHandler) Block(c *fiber.Ctx) error {
+	if err := h.friendSvc.Block(c.Context(), c.Locals("player_id").(string), c.Params("id")); err != nil {
+		return friendError(c, err)
+	}
+	return c.JSON(fiber.Map{"ok": true})
+}
+
+// Unblock lifts the block of :id.
+// DELETE /api/v1/friends/blocked/:id
+func (h *FriendHandler) Unblock(c *fiber.Ctx) error {
+	if err := h.friendSvc.Unblock(c.Context(), c.Locals("player_id").(string), c.Params("id")); err != nil {
+		return friendError(c, err)
+	}
+	return c.JSON(fiber.Map{"ok": true})
+}
+
+// ServerBlocks returns the players each connected player blocked, so the game server
+// can filter live chat and whispers in both directions.
+// POST /api/v1/server/blocks
+func (h *FriendHandler) ServerBlocks(c *fiber.Ctx) error {
+	type request struct {
+		PlayerIDs []string `json:"player_ids"`
+	}
+
+	var req request
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if len(req.PlayerIDs) > 1000 {
+		return c.Status(400).JSON(fiber.Map{"error": "at most 1000 player_ids"})
+	}
+	// A malformed ID would fail the whole uuid[] query: it has no blocks, skip it
+	ids := make([]string, 0, len(req.PlayerIDs))
+	for _, id := range req.PlayerIDs {
+		if uuidPattern.MatchString(id) {
+			ids = append(ids, id)
+		}
+	}
+
+	blocks, err := h.friendSvc.BlocksOf(c.Context(), ids)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to get blocks"})
+	}
+	return c.JSON(fiber.Map{"blocks": blocks})
+}
+
+func friendError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrPlayerNotFound), errors.Is(err, service.ErrFriendRequestNotFound),
+		errors.Is(err, service.ErrFriendNotFound), errors.Is(err, service.ErrNotBlocked):
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrAlreadyFriends), errors.Is(err, service.ErrFriendRequestExists),
+		errors.Is(err, service.ErrFriendLimit), errors.Is(err, service.ErrPlayerBlocked):
+		return c.Status(409).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrFriendRequestRefused):
+		return c.Status(403).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrFriendSelf):
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+	}
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"errors"
+
+	"spacegame-backend/internal/model"
+	"spacegame-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type GameServerHandler struct {
+	serverSvc *service.GameServerService
+}
+
+func NewGameServerHandler(serverSvc *service.GameServerService) *GameServerHandler {
+	return &GameServerHandler{serverSvc: serverSvc}
+}
+
+// List returns every registered game server.
+// GET /api/v1/admin/game-servers
+func (h *GameServerHandler) List(c *fiber.Ctx) error {
+	servers, err := h.serverSvc.List(c.Context())
+	if err != nil {
+		return gameServerError(c, err)
+	}
+	return c.JSON(fiber.Map{"servers": servers})
+}
+
+// Register creates a game server identity. The secret is only returned in this response.
+// POST /api/v1/admin/game-servers
+func (h *GameServerHandler) Register(c *fiber.Ctx) error {
+	var req model.RegisterGameServerRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	creds, err := h.serverSvc.Register(c.Context(), req.Name)
+	if err != nil {
+		return gameServerError(c, err)
+	}
+	return c.Status(201).JSON(creds)
+}
+
+// RotateSecret issues a new secret for a game server.
+// POST /api/v1/admin/game-servers/:id/rotate
+func (h *GameServerHandler) RotateSecret(c *fiber.Ctx) error {
+	creds, err := h.serverSvc.RotateSecret(c.Context(), c.Params("id"))
+	if err != nil {
+		return gameServerError(c, err)
+	}
+	return c.JSON(creds)
+}
+
+// Revoke disables a game server (e.g. a compromised host).
+// POST /api/v1/admin/game-servers/:id/revoke
+func (h *GameServerHandler) Revoke(c *fiber.Ctx) error {
+	if err := h.serverSvc.Revoke(c.Context(), c.Params("id")); err != nil {
+		return gameServerError(c, err)
+	}
+	return c.JSON(fiber.Map{"ok": true})
+}
+
+func gameServerError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrGameServerNotFound):
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrGameServerExists):
+		return c.Status(409).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidServerName):
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+	}
+}
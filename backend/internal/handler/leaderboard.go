@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"spacegame-backend/internal/model"
+	"spacegame-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type LeaderboardHandler struct {
+	leaderboardSvc *service.LeaderboardService
+}
+
+func NewLeaderboardHandler(leaderboardSvc *service.LeaderboardService) *LeaderboardHandler {
+	return &LeaderboardHandler{leaderboardSvc: leaderboardSvc}
+}
+
+// List returns the available boards and their periods.
+// GET /api/v1/public/leaderboards
+func (h *LeaderboardHandler) List(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"leaderboards": h.leaderboardSvc.Boards()})
+}
+
+// Get returns a page of a board (?period=daily|weekly|all_time&limit=&offset=).
+// GET /api/v1/public/leaderboards/:board
+func (h *LeaderboardHandler) Get(c *fiber.Ctx) error {
+	limit, _ := strconv.Atoi(c.Query("limit", "50"))
+	offset, _ := strconv.Atoi(c.Query("offset", "0"))
+
+	lb, err := h.leaderboardSvc.Get(c.Context(), c.Params("board"), c.Query("period", model.LeaderboardAllTime), limit, offset)
+	switch {
+	case errors.Is(err, service.ErrUnknownLeaderboard):
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrUnknownPeriod):
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	case err != nil:
+		return c.Status(500).JSON(fiber.Map{"error": "failed to get leaderboard"})
+	}
+	return c.JSON(lb)
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"strconv"
+
+	"spacegame-backend/internal/model"
+	"spacegame-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type MailHandler struct {
+	mailSvc *service.MailService
+}
+
+func NewMailHandler(mailSvc *service.MailService) *MailHandler {
+	return &MailHandler{mailSvc: mailSvc}
+}
+
+// Inbox returns the caller's mail and unread count (?unread=true&limit=).
+// GET /api/v1/mail
+func (h *MailHandler) Inbox(c *fiber.Ctx) error {
+	limit, _ := strconv.Atoi(c.Query("limit", "100"))
+	inbox, err := h.mailSvc.Inbox(c.Context(), c.Locals("player_id").(string), c.QueryBool("unread"), limit)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to get mail"})
+	}
+	return c.JSON(inbox)
+}
+
+// Send mails a player (optionally with credits and items) or the caller's corporation.
+// POST /api/v1/mail
+func (h *MailHandler) Send(c *fiber.Ctx) error {
+	var req model.SendMailRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	sent, err := h.mailSvc.Send(c.Context(), c.Locals("player_id").(string), &req)
+	if err != nil {
+		return mailError(c, err)
+	}
+	return c.Status(201).JSON(fiber.Map{"ok": true, "sent": sent})
+}
+
+// Read returns one of the caller's mails and marks it read.
+// GET /api/v1/mail/:id
+func (h *MailHandler) Read(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return mailError(c, service.ErrMailNotFound)
+	}
+	m, err := h.mailSvc.Read(c.Context(), c.Locals("player_id").(string), id)
+	if err != nil {
+		return mailError(c, err)
+	}
+	return c.JSON(m)
+}
+
+// Claim moves the attached credits and items to the caller.
+// POST /api/v1/mail/:id/claim
+func (h *MailHandler) Claim(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return mailError(c, service.ErrMailNotFound)
+	}
+	m, err := h.mailSvc.Claim(c.Context(), c.Locals("player_id").(string), id)
+	if err != nil {
+		return mailError(c, err)
+	}
+	return c.JSON(m)
+}
+
+// Delete removes one of the caller's mails (attachments must be claimed first).
+// DELETE /api/v1/mail/:id
+func (h *MailHandler) Delete(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return mailError(c, service.ErrMailNotFound)
+	}
+	if err := h.mailSvc.Delete(c.Context(), c.Locals("player_id").(string), id); err != nil {
+		return mailError(c, err)
+	}
+	return c.JSON(fiber.Map{"ok": true})
+}
+
+func mailError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrMailNotFound), errors.Is(err, service.ErrPlayerNotFound):
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrNothingToClaim), errors.Is(err, service.ErrMailHasAttachments),
+		errors.Is(err, service.ErrMailItemsMissing), errors.Is(err, service.ErrInsufficientCredits):
+		return c.Status(409).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrBlocked), errors.Is(err, service.ErrNotCorporationMember),
+		errors.Is(err, service.ErrNotCorporationLeader):
+		return c.Status(403).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrMailSelf), errors.Is(err, service.ErrInvalidMail),
+		errors.Is(err, service.ErrInvalidAttachments), errors.Is(err, service.ErrCorpMailAttachments),
+		errors.Is(err, service.ErrMailRecipientMissing):
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	default:
+		log.Printf("[MAIL] %v", err)
+		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+	}
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"spacegame-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PlaySessionHandler struct {
+	playSessionSvc *service.PlaySessionService
+}
+
+func NewPlaySessionHandler(playSessionSvc *service.PlaySessionService) *PlaySessionHandler {
+	return &PlaySessionHandler{playSessionSvc: playSessionSvc}
+}
+
+// PlayerSessions returns a player's recent play sessions (?limit=).
+// GET /api/v1/admin/players/:id/sessions
+func (h *PlaySessionHandler) PlayerSessions(c *fiber.Ctx) error {
+	limit, _ := strconv.Atoi(c.Query("limit", "50"))
+	sessions, err := h.playSessionSvc.ListForPlayer(c.Context(), c.Params("id"), limit)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to list play sessions"})
+	}
+	return c.JSON(fiber.Map{"sessions": sessions})
+}
+
+// Concurrency returns the number of players online over time
+// (?from=&to= RFC 3339, default the last 7 days; ?interval= Go duration, default 1h).
+// GET /api/v1/admin/analytics/concurrency
+func (h *PlaySessionHandler) Concurrency(c *fiber.Ctx) error {
+	to := time.Now()
+	from := to.AddDate(0, 0, -7)
+	step := time.Hour
+	var err error
+	if v := c.Query("from"); v != "" {
+		if from, err = time.Parse(time.RFC3339, v); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "invalid from"})
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if to, err = time.Parse(time.RFC3339, v); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "invalid to"})
+		}
+	}
+	if v := c.Query("interval"); v != "" {
+		if step, err = time.ParseDuration(v); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "invalid interval"})
+		}
+	}
+
+	points, err := h.playSessionSvc.Concurrency(c.Context(), from, to, step)
+	if errors.Is(err, service.ErrInvalidAnalyticsRange) {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to compute concurrency"})
+	}
+	return c.JSON(fiber.Map{"points": points})
+}
+
+// Retention returns day 1/7/30 retention per registration day (?days=, default 30).
+// GET /api/v1/admin/analytics/retention
+func (h *PlaySessionHandler) Retention(c *fiber.Ctx) error {
+	days, _ := strconv.Atoi(c.Query("days", "30"))
+	cohorts, err := h.playSessionSvc.Retention(c.Context(), days)
+	if errors.Is(err, service.ErrInvalidAnalyticsRange) {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to compute retention"})
+	}
+	return c.JSON(fiber.Map{"cohorts": cohorts})
+}
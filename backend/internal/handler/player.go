@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"errors"
+
 	"spacegame-backend/internal/model"
 	"spacegame-backend/internal/service"
 
@@ -34,11 +36,54 @@ func (h *PlayerHandler) SaveState(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "invalid request body"})
 	}
 
-	if err := h.playerSvc.SaveState(c.Context(), playerID, &state); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "failed to save player state"})
+	username, _ := c.Locals("username").(string)
+	version, ignored, err := h.playerSvc.SaveClientState(c.Context(), playerID, username, &state)
+	if err != nil {
+		return saveStateError(c, err, version, "failed to save player state")
 	}
 
-	return c.JSON(fiber.Map{"ok": true})
+	// ignored lists server-authoritative fields whose client changes were dropped
+	if len(ignored) > 0 {
+		return c.JSON(fiber.Map{"ok": true, "save_version": version, "ignored_fields": ignored})
+	}
+	return c.JSON(fiber.Map{"ok": true, "save_version": version})
+}
+
+// PatchState saves only the sections present in the body (same keys as SaveState).
+// PATCH /api/v1/player/state
+func (h *PlayerHandler) PatchState(c *fiber.Ctx) error {
+	playerID := c.Locals("player_id").(string)
+
+	var patch model.PlayerStatePatch
+	if err := c.BodyParser(&patch); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	username, _ := c.Locals("username").(string)
+	version, ignored, err := h.playerSvc.PatchClientState(c.Context(), playerID, username, &patch)
+	if err != nil {
+		return saveStateError(c, err, version, "failed to save player state")
+	}
+
+	if len(ignored) > 0 {
+		return c.JSON(fiber.Map{"ok": true, "save_version": version, "ignored_fields": ignored})
+	}
+	return c.JSON(fiber.Map{"ok": true, "save_version": version})
+}
+
+// saveStateError maps save failures: a stale save carries the current version so the
+// caller can reload and retry.
+func saveStateError(c *fiber.Ctx, err error, version int64, fallback string) error {
+	switch {
+	case errors.Is(err, service.ErrSaveVersionRequired), errors.Is(err, service.ErrStateUpgrade):
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrStaleSave):
+		return c.Status(409).JSON(fiber.Map{"error": err.Error(), "save_version": version})
+	case errors.Is(err, service.ErrEconomyRejected):
+		return c.Status(422).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(500).JSON(fiber.Map{"error": fallback})
+	}
 }
 
 func (h *PlayerHandler) GetProfile(c *fiber.Ctx) error {
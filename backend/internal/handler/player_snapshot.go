@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"spacegame-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PlayerSnapshotHandler struct {
+	playerSvc *service.PlayerService
+}
+
+func NewPlayerSnapshotHandler(playerSvc *service.PlayerService) *PlayerSnapshotHandler {
+	return &PlayerSnapshotHandler{playerSvc: playerSvc}
+}
+
+// List returns a player's saved state snapshots, newest first.
+// GET /api/v1/admin/players/:id/snapshots
+func (h *PlayerSnapshotHandler) List(c *fiber.Ctx) error {
+	snapshots, err := h.playerSvc.ListSnapshots(c.Context(), c.Params("id"))
+	if err != nil {
+		return snapshotError(c, err)
+	}
+	return c.JSON(fiber.Map{"snapshots": snapshots})
+}
+
+// Get returns a snapshot with its full state.
+// GET /api/v1/admin/players/:id/snapshots/:snapshotId
+func (h *PlayerSnapshotHandler) Get(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("snapshotId"), 10, 64)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid snapshot id"})
+	}
+
+	snap, err := h.playerSvc.GetSnapshot(c.Context(), c.Params("id"), id)
+	if err != nil {
+		return snapshotError(c, err)
+	}
+	return c.JSON(snap)
+}
+
+// Diff compares a snapshot with another one (?against=<snapshotId>) or the current state.
+// GET /api/v1/admin/players/:id/snapshots/:snapshotId/diff
+func (h *PlayerSnapshotHandler) Diff(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("snapshotId"), 10, 64)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid snapshot id"})
+	}
+	var against int64
+	if q := c.Query("against"); q != "" && q != "current" {
+		if against, err = strconv.ParseInt(q, 10, 64); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "invalid against snapshot id"})
+		}
+	}
+
+	diff, err := h.playerSvc.DiffSnapshot(c.Context(), c.Params("id"), id, against)
+	if err != nil {
+		return snapshotError(c, err)
+	}
+	return c.JSON(diff)
+}
+
+// Restore overwrites the player's state with a snapshot.
+// POST /api/v1/admin/players/:id/snapshots/:snapshotId/restore
+func (h *PlayerSnapshotHandler) Restore(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("snapshotId"), 10, 64)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid snapshot id"})
+	}
+
+	version, err := h.playerSvc.RestoreSnapshot(c.Context(), c.Params("id"), id, actorName(c, ""))
+	if err != nil {
+		return snapshotError(c, err)
+	}
+	return c.JSON(fiber.Map{"ok": true, "save_version": version})
+}
+
+func snapshotError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrSnapshotNotFound):
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+	}
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"errors"
+
+	"spacegame-backend/internal/model"
+	"spacegame-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type RoleHandler struct {
+	permSvc *service.PermissionService
+}
+
+func NewRoleHandler(permSvc *service.PermissionService) *RoleHandler {
+	return &RoleHandler{permSvc: permSvc}
+}
+
+// MyPermissions returns the authenticated player's role and permissions (to show staff tools).
+// GET /api/v1/player/permissions
+func (h *RoleHandler) MyPermissions(c *fiber.Ctx) error {
+	role, err := h.permSvc.PlayerRole(c.Context(), c.Locals("player_id").(string))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to get role"})
+	}
+	return c.JSON(fiber.Map{"role": role, "permissions": h.permSvc.Permissions(role)})
+}
+
+// ListRoles returns every role with its permissions.
+// GET /api/v1/admin/roles
+func (h *RoleHandler) ListRoles(c *fiber.Ctx) error {
+	roles, err := h.permSvc.ListRoles(c.Context())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to list roles"})
+	}
+	return c.JSON(fiber.Map{"roles": roles})
+}
+
+// SetRole changes a player's role (they are signed out to pick up the new role).
+// PUT /api/v1/admin/players/:id/role
+func (h *RoleHandler) SetRole(c *fiber.Ctx) error {
+	var req model.SetRoleRequest
+	if err := c.BodyParser(&req); err != nil || req.Role == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "role is required"})
+	}
+	// Nobody grants a role above their own. API tokens have no role: they can only reset
+	// players to the default one. The legacy admin key (bootstrap) is not limited.
+	principal, _ := c.Locals("admin").(*model.AdminPrincipal)
+	if principal == nil || principal.Type != model.AdminActorKey {
+		actorRole := service.RoleDefault
+		if principal == nil || principal.Type == model.AdminActorPlayer {
+			actorID, _ := c.Locals("player_id").(string)
+			if principal != nil {
+				actorID = principal.ID
+			}
+			role, err := h.permSvc.PlayerRole(c.Context(), actorID)
+			if err != nil {
+				return c.Status(500).JSON(fiber.Map{"error": "failed to get role"})
+			}
+			actorRole = role
+		}
+		if !h.permSvc.RoleAtLeast(actorRole, req.Role) {
+			return c.Status(403).JSON(fiber.Map{"error": "cannot grant a role ranked above yours"})
+		}
+	}
+
+	err := h.permSvc.SetPlayerRole(c.Context(), actorName(c, ""), c.Params("id"), req.Role)
+	switch {
+	case errors.Is(err, service.ErrUnknownRole):
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrPlayerNotFound):
+		return c.Status(404).JSON(fiber.Map{"error": "player not found"})
+	case err != nil:
+		return c.Status(500).JSON(fiber.Map{"error": "failed to set role"})
+	}
+	return c.JSON(fiber.Map{"ok": true})
+}
@@ -1,21 +1,25 @@
 package handler
 
 import (
+	"fmt"
+	"log"
+
 	"spacegame-backend/internal/model"
-	"spacegame-backend/internal/repository"
 	"spacegame-backend/internal/service"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 type ServerHandler struct {
-	authSvc    *service.AuthService
-	playerSvc  *service.PlayerService
-	playerRepo *repository.PlayerRepository
+	authSvc        *service.AuthService
+	banSvc         *service.BanService
+	playerSvc      *service.PlayerService
+	playSessionSvc *service.PlaySessionService
+	presenceSvc    *service.PresenceService
 }
 
-func NewServerHandler(authSvc *service.AuthService, playerSvc *service.PlayerService, playerRepo *repository.PlayerRepository) *ServerHandler {
-	return &ServerHandler{authSvc: authSvc, playerSvc: playerSvc, playerRepo: playerRepo}
+func NewServerHandler(authSvc *service.AuthService, banSvc *service.BanService, playerSvc *service.PlayerService, playSessionSvc *service.PlaySessionService, presenceSvc *service.PresenceService) *ServerHandler {
+	return &ServerHandler{authSvc: authSvc, banSvc: banSvc, playerSvc: playerSvc, playSessionSvc: playSessionSvc, presenceSvc: presenceSvc}
 }
 
 // ValidateToken is called by the game server when a player connects via ENet
@@ -30,6 +34,14 @@ func (h *ServerHandler) ValidateToken(c *fiber.Ctx) error {
 		return c.JSON(model.ValidateTokenResponse{Valid: false})
 	}
 
+	if h.banSvc.IsBanned(playerID) {
+		resp := model.ValidateTokenResponse{Valid: false, Banned: true}
+		if ban := h.banSvc.ActiveBan(c.Context(), playerID); ban != nil {
+			resp.Reason = ban.Reason
+		}
+		return c.JSON(resp)
+	}
+
 	return c.JSON(model.ValidateTokenResponse{
 		Valid:    true,
 		PlayerID: playerID,
@@ -38,11 +50,13 @@ func (h *ServerHandler) ValidateToken(c *fiber.Ctx) error {
 	})
 }
 
-// SaveState is called by the game server to save player state on disconnect
+// SaveState is called by the game server to save player state on disconnect.
+// Force overwrites regardless of save_version when the server is authoritative.
 func (h *ServerHandler) SaveState(c *fiber.Ctx) error {
 	type request struct {
 		PlayerID string            `json:"player_id"`
 		State    model.PlayerState `json:"state"`
+		Force    bool              `json:"force"`
 	}
 
 	var req request
@@ -54,17 +68,97 @@ func (h *ServerHandler) SaveState(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "player_id is required"})
 	}
 
-	if err := h.playerSvc.SaveState(c.Context(), req.PlayerID, &req.State); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "failed to save state"})
+	version, err := h.playerSvc.SaveState(c.Context(), req.PlayerID, &req.State, serverOrigin(c), req.Force)
+	if err != nil {
+		return saveStateError(c, err, version, "failed to save state")
+	}
+
+	return c.JSON(fiber.Map{"ok": true, "save_version": version})
+}
+
+// SaveStates saves many players in one request (e.g. on server shutdown). Each player is
+// saved independently; the response has one result per entry, in order.
+// POST /api/v1/server/save-states
+func (h *ServerHandler) SaveStates(c *fiber.Ctx) error {
+	var req struct {
+		Players []model.BatchSaveEntry `json:"players"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if len(req.Players) > service.MaxBatchSave {
+		return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("at most %d players per request", service.MaxBatchSave)})
 	}
 
-	return c.JSON(fiber.Map{"ok": true})
+	results := h.playerSvc.SaveStates(c.Context(), req.Players, serverOrigin(c))
+	saved := 0
+	for _, r := range results {
+		if r.OK {
+			saved++
+		}
+	}
+	return c.JSON(fiber.Map{"ok": saved == len(results), "saved": saved, "results": results})
 }
 
-// Heartbeat is called periodically by the game server to update last_seen_at for connected players
+// PatchState is called by the game server for autosaves: only the sections present in
+// "state" are written. Force works as for SaveState.
+func (h *ServerHandler) PatchState(c *fiber.Ctx) error {
+	type request struct {
+		PlayerID string                 `json:"player_id"`
+		State    model.PlayerStatePatch `json:"state"`
+		Force    bool                   `json:"force"`
+	}
+
+	var req request
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	if req.PlayerID == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "player_id is required"})
+	}
+
+	version, err := h.playerSvc.PatchState(c.Context(), req.PlayerID, &req.State, serverOrigin(c), req.Force)
+	if err != nil {
+		return saveStateError(c, err, version, "failed to save state")
+	}
+
+	return c.JSON(fiber.Map{"ok": true, "save_version": version})
+}
+
+// SaveEconomy is called by the game server with the credits, resources and inventory of
+// one of its clients; they are bounded against the stored values (422 when rejected).
+// POST /api/v1/server/economy
+func (h *ServerHandler) SaveEconomy(c *fiber.Ctx) error {
+	type request struct {
+		PlayerID string                 `json:"player_id"`
+		State    model.PlayerStatePatch `json:"state"`
+	}
+
+	var req request
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	if req.PlayerID == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "player_id is required"})
+	}
+
+	version, err := h.playerSvc.SaveEconomy(c.Context(), req.PlayerID, &req.State, serverOrigin(c))
+	if err != nil {
+		return saveStateError(c, err, version, "failed to save economy")
+	}
+
+	return c.JSON(fiber.Map{"ok": true, "save_version": version})
+}
+
+// Heartbeat is called periodically by the game server to update last_seen_at and play
+// sessions for connected players ("systems" optionally maps player ID to current system).
+// The response lists connected players that are banned ("kick") so the server can drop them.
 func (h *ServerHandler) Heartbeat(c *fiber.Ctx) error {
 	type request struct {
-		PlayerIDs []string `json:"player_ids"`
+		PlayerIDs []string       `json:"player_ids"`
+		Systems   map[string]int `json:"systems"`
 	}
 
 	var req request
@@ -73,12 +167,35 @@ func (h *ServerHandler) Heartbeat(c *fiber.Ctx) error {
 	}
 
 	if len(req.PlayerIDs) == 0 {
+		// Still ends the sessions of this server's players
+		if err := h.playSessionSvc.Heartbeat(c.Context(), serverOrigin(c), nil, nil); err != nil {
+			log.Printf("[SERVER] failed to record play sessions: %v", err)
+		}
 		return c.JSON(fiber.Map{"ok": true})
 	}
 
-	if err := h.playerRepo.UpdateLastSeen(c.Context(), req.PlayerIDs); err != nil {
+	if err := h.presenceSvc.Heartbeat(c.Context(), req.PlayerIDs, serverOrigin(c)); err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "failed to update last seen"})
 	}
+	if err := h.playSessionSvc.Heartbeat(c.Context(), serverOrigin(c), req.PlayerIDs, req.Systems); err != nil {
+		log.Printf("[SERVER] failed to record play sessions: %v", err)
+	}
+
+	kick := []string{}
+	for _, id := range req.PlayerIDs {
+		if h.banSvc.IsBanned(id) {
+			kick = append(kick, id)
+		}
+	}
 
-	return c.JSON(fiber.Map{"ok": true})
+	return c.JSON(fiber.Map{"ok": true, "kick": kick})
+}
+
+// serverOrigin returns the authenticated game server ID, or nil for the legacy shared key.
+func serverOrigin(c *fiber.Ctx) *string {
+	id, _ := c.Locals("server_id").(string)
+	if id == "" {
+		return nil
+	}
+	return &id
 }
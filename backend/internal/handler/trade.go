@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"strconv"
+
+	"spacegame-backend/internal/model"
+	"spacegame-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type TradeHandler struct {
+	tradeSvc *service.TradeService
+}
+
+func NewTradeHandler(tradeSvc *service.TradeService) *TradeHandler {
+	return &TradeHandler{tradeSvc: tradeSvc}
+}
+
+// List returns the caller's open trades.
+// GET /api/v1/trades
+func (h *TradeHandler) List(c *fiber.Ctx) error {
+	trades, err := h.tradeSvc.ListOpen(c.Context(), c.Locals("player_id").(string))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to get trades"})
+	}
+	return c.JSON(fiber.Map{"trades": trades})
+}
+
+// History returns the caller's completed, cancelled and expired trades (?limit=).
+// GET /api/v1/trades/history
+func (h *TradeHandler) History(c *fiber.Ctx) error {
+	return h.history(c, c.Locals("player_id").(string))
+}
+
+// PlayerHistory returns a player's trade log.
+// GET /api/v1/admin/players/:id/trades
+func (h *TradeHandler) PlayerHistory(c *fiber.Ctx) error {
+	playerID := c.Params("id")
+	if !uuidPattern.MatchString(playerID) {
+		return c.Status(400).JSON(fiber.Map{"error": "player id must be a UUID"})
+	}
+	return h.history(c, playerID)
+}
+
+// Open starts a trade with a player by username.
+// POST /api/v1/trades
+func (h *TradeHandler) Open(c *fiber.Ctx) error {
+	var req model.CreateTradeRequest
+	if err := c.BodyParser(&req); err != nil || req.Username == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "username is required"})
+	}
+	trade, err := h.tradeSvc.Open(c.Context(), c.Locals("player_id").(string), req.Username)
+	if err != nil {
+		return tradeError(c, err)
+	}
+	return c.Status(201).JSON(trade)
+}
+
+// Get returns one of the caller's trades.
+// GET /api/v1/trades/:id
+func (h *TradeHandler) Get(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return tradeError(c, service.ErrTradeNotFound)
+	}
+	trade, err := h.tradeSvc.Get(c.Context(), c.Locals("player_id").(string), id)
+	if err != nil {
+		return tradeError(c, err)
+	}
+	return c.JSON(trade)
+}
+
+// SetOffer replaces the caller's offer (credits and items) and clears both confirmations.
+// PUT /api/v1/trades/:id/offer
+func (h *TradeHandler) SetOffer(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return tradeError(c, service.ErrTradeNotFound)
+	}
+	var req model.TradeOfferRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	trade, err := h.tradeSvc.SetOffer(c.Context(), c.Locals("player_id").(string), id, &req)
+	if err != nil {
+		return tradeError(c, err)
+	}
+	return c.JSON(trade)
+}
+
+// Confirm accepts the offers at the given revision; the swap happens once both players did.
+// POST /api/v1/trades/:id/confirm
+func (h *TradeHandler) Confirm(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return tradeError(c, service.ErrTradeNotFound)
+	}
+	var req model.ConfirmTradeRequest
+	if err := c.BodyParser(&req); err != nil || req.Revision <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "revision is required"})
+	}
+	trade, err := h.tradeSvc.Confirm(c.Context(), c.Locals("player_id").(string), id, req.Revision)
+	if err != nil {
+		return tradeError(c, err)
+	}
+	return c.JSON(trade)
+}
+
+// Cancel closes one of the caller's open trades.
+// DELETE /api/v1/trades/:id
+func (h *TradeHandler) Cancel(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return tradeError(c, service.ErrTradeNotFound)
+	}
+	if err := h.tradeSvc.Cancel(c.Context(), c.Locals("player_id").(string), id); err != nil {
+		return tradeError(c, err)
+	}
+	return c.JSON(fiber.Map{"ok": true})
+}
+
+func (h *TradeHandler) history(c *fiber.Ctx, playerID string) error {
+	limit, _ := strconv.Atoi(c.Query("limit", "50"))
+	trades, err := h.tradeSvc.History(c.Context(), playerID, limit)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to get trades"})
+	}
+	return c.JSON(fiber.Map{"trades": trades})
+}
+
+func tradeError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrTradeNotFound), errors.Is(err, service.ErrPlayerNotFound):
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrTradeExists), errors.Is(err, service.ErrTradeLimit),
+		errors.Is(err, service.ErrTradeClosed), errors.Is(err, service.ErrTradeChanged),
+		errors.Is(err, service.ErrTradeItemsMissing), errors.Is(err, service.ErrInsufficientCredits):
+		return c.Status(409).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrBlocked):
+		return c.Status(403).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrTradeSelf), errors.Is(err, service.ErrInvalidTradeOffer):
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	default:
+		log.Printf("[TRADE] %v", err)
+		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+	}
+}
@@ -13,12 +13,13 @@ import (
 )
 
 type WSHandler struct {
-	hub       *service.WSHub
-	jwtSecret string
+	hub     *service.WSHub
+	authSvc *service.AuthService
+	banSvc  *service.BanService
 }
 
-func NewWSHandler(hub *service.WSHub, jwtSecret string) *WSHandler {
-	return &WSHandler{hub: hub, jwtSecret: jwtSecret}
+func NewWSHandler(hub *service.WSHub, authSvc *service.AuthService, banSvc *service.BanService) *WSHandler {
+	return &WSHandler{hub: hub, authSvc: authSvc, banSvc: banSvc}
 }
 
 func (h *WSHandler) Upgrade(c *fiber.Ctx) error {
@@ -29,11 +30,13 @@ func (h *WSHandler) Upgrade(c *fiber.Ctx) error {
 			return c.Status(401).JSON(fiber.Map{"error": "token required"})
 		}
 
-		authSvc := service.NewAuthService(nil, nil, h.jwtSecret)
-		playerID, username, _, err := authSvc.ValidateAccessToken(token)
+		playerID, username, _, err := h.authSvc.ValidateAccessToken(token)
 		if err != nil {
 			return c.Status(401).JSON(fiber.Map{"error": "invalid token"})
 		}
+		if h.banSvc.IsBanned(playerID) {
+			return c.Status(403).JSON(fiber.Map{"error": "account is banned"})
+		}
 
 		c.Locals("player_id", playerID)
 		c.Locals("username", username)
@@ -51,17 +54,39 @@ func (h *WSHandler) handleConnection(c *websocket.Conn) {
 		PlayerID: playerID,
 		Username: username,
 		Send:     make(chan []byte, 256),
+		Done:     make(chan struct{}),
 	}
 
 	h.hub.Register(client)
-	defer h.hub.Unregister(client)
+	defer func() {
+		h.hub.Unregister(client)
+		close(client.Send)
+	}()
 
-	// Writer goroutine
+	// Writer goroutine. Closing the connection ends the reader loop below.
 	go func() {
 		defer c.Close()
-		for msg := range client.Send {
-			if err := c.WriteMessage(websocket.TextMessage, msg); err != nil {
-				break
+		for {
+			select {
+			case msg, ok := <-client.Send:
+				if !ok {
+					return
+				}
+				if err := c.WriteMessage(websocket.TextMessage, msg); err != nil {
+					return
+				}
+			case <-client.Done:
+				// Kicked by the hub: flush what is queued (e.g. the ban notice), then close
+				for {
+					select {
+					case msg, ok := <-client.Send:
+						if !ok || c.WriteMessage(websocket.TextMessage, msg) != nil {
+							return
+						}
+					default:
+						return
+					}
+				}
 			}
 		}
 	}()
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+
+	"spacegame-backend/internal/model"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxAuditPayload caps the request body stored with an audit entry.
+const maxAuditPayload = 16 * 1024
+
+// AdminAuthenticator resolves admin credentials (implemented by service.AdminService).
+type AdminAuthenticator interface {
+	AuthenticateAdmin(ctx context.Context, credential string) (*model.AdminPrincipal, error)
+}
+
+// AuditRecorder stores admin audit entries (implemented by service.AdminService).
+type AuditRecorder interface {
+	RecordAdminAction(ctx context.Context, entry *model.AdminAuditEntry)
+}
+
+// AdminAuth identifies the caller of an /admin route from "Authorization: Bearer"
+// (staff access token or adm_ API token). The shared X-Admin-Key is still accepted,
+// with every scope, while legacyKey is set.
+func AdminAuth(admins AdminAuthenticator, legacyKey string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var principal *model.AdminPrincipal
+
+		auth := c.Get("Authorization")
+		switch {
+		case strings.HasPrefix(auth, "Bearer "):
+			p, err := admins.AuthenticateAdmin(c.Context(), strings.TrimPrefix(auth, "Bearer "))
+			if err != nil {
+				return c.Status(401).JSON(fiber.Map{"error": "invalid admin credentials"})
+			}
+			principal = p
+		case legacyKey != "" && c.Get("X-Admin-Key") == legacyKey:
+			principal = &model.AdminPrincipal{Type: model.AdminActorKey, Name: "admin-key", Scopes: []string{"*"}}
+		default:
+			return c.Status(403).JSON(fiber.Map{"error": "invalid admin key"})
+		}
+
+		c.Locals("admin", principal)
+		c.Locals("username", principal.Name)
+		return c.Next()
+	}
+}
+
+// RequireScope admits admin principals holding scope. Must run after AdminAuth.
+func RequireScope(scope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		principal, _ := c.Locals("admin").(*model.AdminPrincipal)
+		if principal == nil || !principal.Allows(scope) {
+			return c.Status(403).JSON(fiber.Map{"error": "missing permission: " + scope})
+		}
+		return c.Next()
+	}
+}
+
+// Audit records every request of the group in the admin audit log: who (admin
+// principal, or the player on /mod routes), which route, its parameters and body.
+func Audit(recorder AuditRecorder) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		err := c.Next()
+
+		entry := &model.AdminAuditEntry{
+			Action:    c.Method() + " " + c.Route().Path,
+			Target:    routeTarget(c),
+			Path:      truncate(string(c.Request().RequestURI()), 512),
+			Payload:   auditPayload(c.Body()),
+			Status:    c.Response().StatusCode(),
+			IPAddress: c.IP(),
+		}
+		if err != nil {
+			entry.Status = fiber.StatusInternalServerError
+			if e, ok := err.(*fiber.Error); ok {
+				entry.Status = e.Code
+			}
+		}
+		if principal, ok := c.Locals("admin").(*model.AdminPrincipal); ok && principal != nil {
+			entry.ActorType, entry.ActorID, entry.ActorName = principal.Type, principal.ID, principal.Name
+		} else {
+			entry.ActorType = model.AdminActorPlayer
+			entry.ActorID, _ = c.Locals("player_id").(string)
+			entry.ActorName, _ = c.Locals("username").(string)
+		}
+
+		recorder.RecordAdminAction(c.Context(), entry)
+		return err
+	}
+}
+
+// routeTarget joins the route parameters, e.g. "id=…" or "banId=42".
+func routeTarget(c *fiber.Ctx) string {
+	var parts []string
+	for _, name := range c.Route().Params {
+		parts = append(parts, name+"="+c.Params(name))
+	}
+	return truncate(strings.Join(parts, ","), 255)
+}
+
+func auditPayload(body []byte) json.RawMessage {
+	if len(body) == 0 {
+		return nil
+	}
+	if len(body) > maxAuditPayload || !json.Valid(body) {
+		data, _ := json.Marshal(fiber.Map{"omitted": true, "size": len(body)})
+		return data
+	}
+	// Copy: fasthttp reuses the request buffer after the handler returns
+	return append(json.RawMessage(nil), body...)
+}
+
+// truncate cuts s to at most n characters (not bytes, so UTF-8 stays valid).
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) > n {
+		return string([]rune(s)[:n])
+	}
+	return s
+}
@@ -1,15 +1,26 @@
 package middleware
 
 import (
-	"fmt"
+	"context"
+	"log"
 	"strings"
 
+	"spacegame-backend/internal/model"
+
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v5"
 )
 
-func Auth(jwtSecret string) fiber.Handler {
-	secret := []byte(jwtSecret)
+// AccessTokenParser verifies access tokens (implemented by service.AuthService).
+type AccessTokenParser interface {
+	ParseAccessToken(token string) (*model.AccessClaims, error)
+}
+
+// BanChecker reports players with a ban in force (implemented by service.BanService).
+type BanChecker interface {
+	IsBanned(playerID string) bool
+}
+
+func Auth(tokens AccessTokenParser, bans BanChecker) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		authHeader := c.Get("Authorization")
 		if authHeader == "" {
@@ -21,49 +32,58 @@ func Auth(jwtSecret string) fiber.Handler {
 			return c.Status(401).JSON(fiber.Map{"error": "invalid authorization format"})
 		}
 
-		token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
-			}
-			return secret, nil
-		})
-		if err != nil || !token.Valid {
+		claims, err := tokens.ParseAccessToken(tokenString)
+		if err != nil {
 			return c.Status(401).JSON(fiber.Map{"error": "invalid or expired token"})
 		}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			return c.Status(401).JSON(fiber.Map{"error": "invalid token claims"})
-		}
-
-		playerID, _ := claims["sub"].(string)
-		username, _ := claims["username"].(string)
-		if playerID == "" {
-			return c.Status(401).JSON(fiber.Map{"error": "invalid token: missing subject"})
+		// Access tokens outlive a ban by up to 15 minutes: check on every request
+		if bans.IsBanned(claims.PlayerID) {
+			return c.Status(403).JSON(fiber.Map{"error": "account is banned"})
 		}
 
-		c.Locals("player_id", playerID)
-		c.Locals("username", username)
+		c.Locals("player_id", claims.PlayerID)
+		c.Locals("username", claims.Username)
+		c.Locals("role", claims.Role)
+		c.Locals("session_id", claims.SessionID)
 		return c.Next()
 	}
 }
 
-func ServerKey(expectedKey string) fiber.Handler {
-	return func(c *fiber.Ctx) error {
-		key := c.Get("X-Server-Key")
-		if key == "" || key != expectedKey {
-			return c.Status(403).JSON(fiber.Map{"error": "invalid server key"})
-		}
-		return c.Next()
-	}
+// ServerRequestVerifier checks signed game server requests (implemented by service.GameServerService).
+type ServerRequestVerifier interface {
+	VerifyRequest(ctx context.Context, req *model.SignedServerRequest) (*model.GameServer, error)
 }
 
-func AdminKey(expectedKey string) fiber.Handler {
+// ServerAuth authenticates game servers. Registered servers sign each request
+// (X-Server-Id, X-Server-Timestamp, X-Server-Nonce, X-Server-Signature); the shared
+// X-Server-Key is still accepted while legacyKey is set, without a server identity.
+func ServerAuth(servers ServerRequestVerifier, legacyKey string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		key := c.Get("X-Admin-Key")
-		if key == "" || key != expectedKey {
-			return c.Status(403).JSON(fiber.Map{"error": "invalid admin key"})
+		if serverID := c.Get("X-Server-Id"); serverID != "" {
+			srv, err := servers.VerifyRequest(c.Context(), &model.SignedServerRequest{
+				ServerID:  serverID,
+				Timestamp: c.Get("X-Server-Timestamp"),
+				Nonce:     c.Get("X-Server-Nonce"),
+				Signature: c.Get("X-Server-Signature"),
+				Method:    c.Method(),
+				Path:      string(c.Request().RequestURI()),
+				Body:      c.Body(),
+			})
+			if err != nil {
+				log.Printf("[SERVERS] rejected request from %s to %s: %v", serverID, c.Path(), err)
+				return c.Status(403).JSON(fiber.Map{"error": "invalid server signature"})
+			}
+			c.Locals("server_id", srv.ID)
+			c.Locals("server_name", srv.Name)
+			return c.Next()
+		}
+
+		key := c.Get("X-Server-Key")
+		if legacyKey == "" || key == "" || key != legacyKey {
+			return c.Status(403).JSON(fiber.Map{"error": "invalid server key"})
 		}
+		c.Locals("server_id", "")
 		return c.Next()
 	}
 }
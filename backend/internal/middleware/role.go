@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"strconv"
+
+	"spacegame-backend/internal/model"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// PermissionChecker resolves roles and permissions (implemented by service.PermissionService).
+type PermissionChecker interface {
+	HasPermission(role, permission string) bool
+	RoleAtLeast(role, minRole string) bool
+	PlayerRole(ctx context.Context, playerID string) (string, error)
+	Outranks(ctx context.Context, actorRole, targetID string) (bool, error)
+}
+
+// RequireRole admits players whose current role ranks at or above minRole. The role
+// is looked up rather than taken from the token, so a demotion applies at once; it
+// replaces the "role" local for the handlers that follow. Must run after Auth.
+func RequireRole(perms PermissionChecker, minRole string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role, ok := currentRole(c, perms)
+		if !ok {
+			return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+		}
+		if !perms.RoleAtLeast(role, minRole) {
+			return c.Status(403).JSON(fiber.Map{"error": "insufficient role"})
+		}
+		return c.Next()
+	}
+}
+
+// RequirePermission admits players whose current role grants permission. Must run after Auth.
+func RequirePermission(perms PermissionChecker, permission string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role, ok := currentRole(c, perms)
+		if !ok {
+			return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+		}
+		if !perms.HasPermission(role, permission) {
+			return c.Status(403).JSON(fiber.Map{"error": "missing permission: " + permission})
+		}
+		return c.Next()
+	}
+}
+
+// RequireOutrank refuses staff actions on a player (route param) whose role ranks at or
+// above the caller's, and leaves the caller's role in the "role" local. API tokens and
+// the legacy admin key have no rank and pass. Must run after RequirePermission or AdminAuth.
+func RequireOutrank(perms PermissionChecker, param string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return outrank(c, perms, c.Params(param))
+	}
+}
+
+// BanLookup finds whose a ban is (implemented by service.BanService).
+type BanLookup interface {
+	BanPlayerID(ctx context.Context, banID int64) (string, error)
+}
+
+// RequireBanOutrank is RequireOutrank for routes on a ban (banId param): the target is
+// the banned player. An unknown ban is a 404.
+func RequireBanOutrank(perms PermissionChecker, bans BanLookup) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		banID, err := strconv.ParseInt(c.Params("banId"), 10, 64)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "invalid ban id"})
+		}
+		playerID, err := bans.BanPlayerID(c.Context(), banID)
+		if err != nil {
+			log.Printf("[ROLES] failed to load ban %d: %v", banID, err)
+			return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+		}
+		if playerID == "" {
+			return c.Status(404).JSON(fiber.Map{"error": "ban not found"})
+		}
+		return outrank(c, perms, playerID)
+	}
+}
+
+func outrank(c *fiber.Ctx, perms PermissionChecker, targetID string) error {
+	var role string
+	if principal, ok := c.Locals("admin").(*model.AdminPrincipal); ok && principal != nil {
+		if principal.Type != model.AdminActorPlayer {
+			return c.Next()
+		}
+		r, err := perms.PlayerRole(c.Context(), principal.ID)
+		if err != nil {
+			log.Printf("[ROLES] failed to load role of %s: %v", principal.ID, err)
+			return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+		}
+		role = r
+		c.Locals("role", role)
+	} else {
+		role, _ = c.Locals("role").(string)
+	}
+
+	ok, err := perms.Outranks(c.Context(), role, targetID)
+	if err != nil {
+		log.Printf("[ROLES] failed to load role of %s: %v", targetID, err)
+		return c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+	}
+	if !ok {
+		return c.Status(403).JSON(fiber.Map{"error": "target's role ranks at or above yours"})
+	}
+	return c.Next()
+}
+
+// currentRole loads the caller's current role into the "role" local.
+func currentRole(c *fiber.Ctx, perms PermissionChecker) (string, bool) {
+	playerID, _ := c.Locals("player_id").(string)
+	role, err := perms.PlayerRole(c.Context(), playerID)
+	if err != nil {
+		log.Printf("[ROLES] failed to load role of %s: %v", playerID, err)
+		return "", false
+	}
+	c.Locals("role", role)
+	return role, true
+}
@@ -0,0 +1,42 @@
+package model
+
+import "time"
+
+// AccountDeletionRequest confirms a deletion request (code only needed with 2FA).
+type AccountDeletionRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code,omitempty"`
+}
+
+type AccountDeletionStatus struct {
+	Scheduled   bool       `json:"scheduled"`
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty"`
+}
+
+// AccountExport is the personal data archive returned by GET /player/export.
+type AccountExport struct {
+	ExportedAt      time.Time                 `json:"exported_at"`
+	Account         *Player                   `json:"account"`
+	DiscordID       string                    `json:"discord_id,omitempty"`
+	State           *PlayerState              `json:"state"`
+	FleetShips      []FleetShipDB             `json:"fleet_ships"`
+	MarketListings  []MarketListing           `json:"market_listings"`
+	MarketPurchases []MarketListing           `json:"market_purchases"`
+	ChatMessages    []ChatMessage             `json:"chat_messages"`
+	Corporation     *CorporationMember        `json:"corporation,omitempty"`
+	Applications    []*CorporationApplication `json:"corporation_applications"`
+	Transactions    []*CorporationTransaction `json:"corporation_transactions"`
+	Activity        []*CorporationActivity    `json:"corporation_activity"`
+	Sessions        []Session                 `json:"sessions"`
+	LoginAttempts   []LoginAttempt            `json:"login_attempts"`
+	Bans            []Ban                     `json:"bans"`
+	CreditLedger    []CreditLedgerEntry       `json:"credit_ledger"`
+	PlaySessions    []PlaySession             `json:"play_sessions"`
+	Achievements    []PlayerAchievement       `json:"achievements"`
+	Friends         []Contact                 `json:"friends"`
+	FriendRequests  []Contact                 `json:"friend_requests"`
+	BlockedPlayers  []Contact                 `json:"blocked_players"`
+	OpenTrades      []Trade                   `json:"open_trades"`
+	Trades          []Trade                   `json:"trades"`
+	Mail            []Mail                    `json:"mail"`
+}
@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+// Player statistics that achievements are measured against
+const (
+	AchievementStatKills       = "kills"
+	AchievementStatMarketSales = "market_sales"       // credits earned selling on the market
+	AchievementStatCorporation = "corporation"        // 1 while in a corporation
+	AchievementStatDiscoveries = "systems_discovered" // systems with at least one discovery
+)
+
+// Achievement is unlocked once the player's Stat reaches Goal.
+type Achievement struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Stat        string `json:"stat"`
+	Goal        int64  `json:"goal"`
+}
+
+// Achievements lists every achievement. IDs are stored per player: never rename one.
+var Achievements = []Achievement{
+	{ID: "first_kill", Name: "Premier sang", Description: "Détruire un vaisseau.", Stat: AchievementStatKills, Goal: 1},
+	{ID: "kills_100", Name: "Chasseur", Description: "Détruire 100 vaisseaux.", Stat: AchievementStatKills, Goal: 100},
+	{ID: "kills_1000", Name: "Fléau des étoiles", Description: "Détruire 1 000 vaisseaux.", Stat: AchievementStatKills, Goal: 1000},
+	{ID: "first_sale", Name: "Premier client", Description: "Vendre un objet sur le marché.", Stat: AchievementStatMarketSales, Goal: 1},
+	{ID: "market_1m", Name: "Magnat", Description: "Vendre pour 1 000 000 crédits sur le marché.", Stat: AchievementStatMarketSales, Goal: 1_000_000},
+	{ID: "corporation_joined", Name: "Esprit d'équipe", Description: "Rejoindre une corporation.", Stat: AchievementStatCorporation, Goal: 1},
+	{ID: "explorer_10", Name: "Explorateur", Description: "Faire une découverte dans 10 systèmes.", Stat: AchievementStatDiscoveries, Goal: 10},
+}
+
+// PlayerAchievement is an achievement and when the player unlocked it (nil if not yet).
+type PlayerAchievement struct {
+	Achievement
+	UnlockedAt *time.Time `json:"unlocked_at,omitempty"`
+}
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Admin principal types
+const (
+	AdminActorPlayer = "player"
+	AdminActorToken  = "token"
+	AdminActorKey    = "admin_key"
+)
+
+// AdminPrincipal is whoever is calling an /admin route: a staff account, a scoped
+// API token, or the legacy shared X-Admin-Key.
+type AdminPrincipal struct {
+	Type   string   `json:"type"`
+	ID     string   `json:"id"`
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// Allows reports whether the principal holds scope ("*" grants everything).
+func (p *AdminPrincipal) Allows(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == "*" || s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// AdminToken is a scoped API token. The token itself is only returned on creation.
+type AdminToken struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+type CreateAdminTokenRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days,omitempty"` // 0 = never
+}
+
+type CreatedAdminToken struct {
+	AdminToken
+	Token string `json:"token"`
+}
+
+type AdminAuditEntry struct {
+	ID        int64           `json:"id"`
+	ActorType string          `json:"actor_type"`
+	ActorID   string          `json:"actor_id"`
+	ActorName string          `json:"actor_name"`
+	Action    string          `json:"action"`
+	Target    string          `json:"target"`
+	Path      string          `json:"path"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	Status    int             `json:"status"`
+	IPAddress string          `json:"ip_address"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// AdminAuditFilter narrows GET /admin/audit. BeforeID pages backwards (newest first).
+type AdminAuditFilter struct {
+	Actor    string
+	Action   string
+	Target   string
+	Since    *time.Time
+	Until    *time.Time
+	BeforeID int64
+	Limit    int
+}
@@ -1,28 +1,71 @@
 package model
 
+import "time"
+
 type RegisterRequest struct {
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Username    string `json:"username"`
+	Email       string `json:"email"`
+	Password    string `json:"password"`
+	DeviceLabel string `json:"device_label,omitempty"`
 }
 
 type LoginRequest struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
+	Username    string `json:"username"`
+	Password    string `json:"password"`
+	DeviceLabel string `json:"device_label,omitempty"`
 }
 
 type RefreshRequest struct {
 	RefreshToken string `json:"refresh_token"`
+	DeviceLabel  string `json:"device_label,omitempty"`
 }
 
 type LogoutRequest struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+// AuthResponse is returned by register/login. When the account has 2FA enabled, login
+// only returns Status "2fa_required" and a ChallengeToken to exchange at /auth/2fa/verify.
 type AuthResponse struct {
-	AccessToken  string  `json:"access_token"`
-	RefreshToken string  `json:"refresh_token"`
-	Player       *Player `json:"player"`
+	AccessToken    string  `json:"access_token,omitempty"`
+	RefreshToken   string  `json:"refresh_token,omitempty"`
+	Player         *Player `json:"player,omitempty"`
+	Status         string  `json:"status,omitempty"`
+	ChallengeToken string  `json:"challenge_token,omitempty"`
+}
+
+type TwoFactorVerifyRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"` // TOTP code or recovery code
+	DeviceLabel    string `json:"device_label,omitempty"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type TwoFactorDisableRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+// TwoFactorSetup is shown once during enrolment (ProvisioningURI is rendered as a QR code).
+type TwoFactorSetup struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
 }
 
 type TokenPair struct {
@@ -39,4 +82,74 @@ type ValidateTokenResponse struct {
 	PlayerID string `json:"player_id,omitempty"`
 	Username string `json:"username,omitempty"`
 	Role     string `json:"role,omitempty"`
+	Banned   bool   `json:"banned,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// SessionMeta describes the device a refresh token was issued to.
+type SessionMeta struct {
+	DeviceLabel string
+	IPAddress   string
+	UserAgent   string
+	StartedAt   time.Time // zero = new session starting now
+}
+
+// RefreshTokenRecord is a stored refresh token row (the token itself is never stored).
+// FamilyID is shared by every token rotated from the same login and identifies the session.
+type RefreshTokenRecord struct {
+	ID               string
+	FamilyID         string
+	PlayerID         string
+	DeviceLabel      string
+	SessionStartedAt time.Time
+}
+
+// Session is an active refresh token as shown to the player.
+type Session struct {
+	ID          string    `json:"id"`
+	DeviceLabel string    `json:"device_label"`
+	IPAddress   string    `json:"ip_address"`
+	UserAgent   string    `json:"user_agent"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastUsedAt  time.Time `json:"last_used_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	Current     bool      `json:"current"`
+}
+
+// LoginAttempt is a recorded login attempt, shown to admins.
+type LoginAttempt struct {
+	ID        int64     `json:"id"`
+	Username  string    `json:"username"`
+	IPAddress string    `json:"ip_address"`
+	Success   bool      `json:"success"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// LoginLockState is the brute-force protection state of an account.
+type LoginLockState struct {
+	FailedCount  int        `json:"failed_count"`
+	LastFailedAt *time.Time `json:"last_failed_at,omitempty"`
+	LockedUntil  *time.Time `json:"locked_until,omitempty"`
+}
+
+// AccessClaims are the claims of a validated access token.
+type AccessClaims struct {
+	PlayerID  string
+	Username  string
+	Role      string
+	SessionID string
+}
+
+// JWKS is the JSON Web Key Set served at /.well-known/jwks.json.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
 }
@@ -0,0 +1,42 @@
+package model
+
+import "time"
+
+type Ban struct {
+	ID          int64      `json:"id"`
+	PlayerID    string     `json:"player_id"`
+	Reason      string     `json:"reason"`
+	IssuedBy    string     `json:"issued_by"`
+	StartsAt    time.Time  `json:"starts_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"` // nil = permanent
+	LiftedAt    *time.Time `json:"lifted_at,omitempty"`
+	LiftedBy    *string    `json:"lifted_by,omitempty"`
+	AppealNotes string     `json:"appeal_notes"`
+	CreatedAt   time.Time  `json:"created_at"`
+	Active      bool       `json:"active"`
+}
+
+type IssueBanRequest struct {
+	Reason        string     `json:"reason"`
+	IssuedBy      string     `json:"issued_by"`
+	StartsAt      *time.Time `json:"starts_at,omitempty"` // default: now
+	DurationHours int        `json:"duration_hours,omitempty"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	Permanent     bool       `json:"permanent,omitempty"` // exactly one of duration_hours, expires_at and permanent
+}
+
+type LiftBanRequest struct {
+	LiftedBy string `json:"lifted_by"`
+	Note     string `json:"note,omitempty"`
+}
+
+type BanAppealNoteRequest struct {
+	Author string `json:"author"`
+	Note   string `json:"note"`
+}
+
+// WSAccountBanned is pushed to a player's websocket right before it is closed.
+type WSAccountBanned struct {
+	Reason    string     `json:"reason"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
@@ -0,0 +1,48 @@
+package model
+
+import "time"
+
+// Reasons recorded in the credit ledger
+const (
+	CreditReasonOpening        = "opening_balance" // balance when the ledger was introduced
+	CreditReasonStarting       = "starting_balance"
+	CreditReasonClientSave     = "client_save"
+	CreditReasonServerSave     = "server_save"
+	CreditReasonServerEconomy  = "server_economy" // client economy checked and relayed by the game server
+	CreditReasonAdminRestore   = "admin_restore"
+	CreditReasonMarketFee      = "market_listing_fee"
+	CreditReasonMarketPurchase = "market_purchase"
+	CreditReasonMarketSale     = "market_sale"
+	CreditReasonCorpDeposit    = "corporation_deposit"
+	CreditReasonCorpWithdraw   = "corporation_withdraw"
+	CreditReasonTrade          = "player_trade"
+	CreditReasonMailSent       = "mail_attachment_sent"
+	CreditReasonMailClaimed    = "mail_attachment_claimed"
+	CreditReasonAccountDeleted = "account_deleted"
+)
+
+// CreditLedgerEntry is one change to a player's credits.
+type CreditLedgerEntry struct {
+	ID           int64     `json:"id"`
+	PlayerID     string    `json:"player_id"`
+	Delta        int64     `json:"delta"`
+	BalanceAfter int64     `json:"balance_after"`
+	Reason       string    `json:"reason"`
+	ReferenceID  string    `json:"reference_id,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// CreditMismatch is a player whose credits don't match the sum of their ledger.
+type CreditMismatch struct {
+	PlayerID  string `json:"player_id"`
+	Username  string `json:"username"`
+	Credits   int64  `json:"credits"`
+	LedgerSum int64  `json:"ledger_sum"`
+}
+
+// CreditReconciliation is the result of checking every balance against the ledger.
+type CreditReconciliation struct {
+	CheckedAt  time.Time        `json:"checked_at"`
+	Players    int              `json:"players"`
+	Mismatches []CreditMismatch `json:"mismatches"`
+}
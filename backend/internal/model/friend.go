@@ -0,0 +1,56 @@
+package model
+
+import "time"
+
+// Status of a player_relations row (player -> other)
+const (
+	RelationPending = "pending" // friend request sent
+	RelationFriend  = "friend"
+	RelationBlocked = "blocked"
+)
+
+// Presence statuses, from least to most present
+const (
+	PresenceOffline  = "offline"
+	PresenceOnline   = "online"    // connected to the WebSocket (menus, website)
+	PresenceInSystem = "in_system" // in the game world: reported by a game server heartbeat
+)
+
+type Presence struct {
+	Status     string     `json:"status"`
+	SystemID   *int       `json:"system_id,omitempty"` // when in_system
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+}
+
+type Friend struct {
+	PlayerID       string    `json:"player_id"`
+	Username       string    `json:"username"`
+	CorporationTag string    `json:"corporation_tag,omitempty"`
+	Since          time.Time `json:"since"`
+	Presence       Presence  `json:"presence"`
+}
+
+// Contact is the other player of a friend request or block.
+type Contact struct {
+	PlayerID  string    `json:"player_id"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type FriendList struct {
+	Friends  []Friend  `json:"friends"`
+	Incoming []Contact `json:"incoming"` // requests to accept or decline
+	Outgoing []Contact `json:"outgoing"`
+	Blocked  []Contact `json:"blocked"`
+}
+
+type FriendRequestRequest struct {
+	Username string `json:"username"`
+}
+
+// FriendPresenceEvent is pushed to friends over WebSocket ("friend:online").
+type FriendPresenceEvent struct {
+	PlayerID string `json:"player_id"`
+	Username string `json:"username"`
+	Status   string `json:"status"`
+}
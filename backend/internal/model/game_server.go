@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// GameServer is a registered zone server allowed to call /api/v1/server.
+// The secret is never serialised; it is only returned once, in GameServerCredentials.
+type GameServer struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Secret     string     `json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// GameServerCredentials is shown once, when a server is registered or its secret rotated.
+type GameServerCredentials struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Secret string `json:"secret"`
+}
+
+type RegisterGameServerRequest struct {
+	Name string `json:"name"`
+}
+
+// SignedServerRequest holds what a game server signs: see service.SignServerRequest.
+type SignedServerRequest struct {
+	ServerID  string
+	Timestamp string
+	Nonce     string
+	Signature string
+	Method    string
+	Path      string
+	Body      []byte
+}
@@ -0,0 +1,56 @@
+package model
+
+import "time"
+
+// Leaderboard boards
+const (
+	LeaderboardKills        = "kills"
+	LeaderboardKD           = "kd"
+	LeaderboardNetWorth     = "net_worth"     // credits + value of active market listings
+	LeaderboardMarketVolume = "market_volume" // value of market sales and purchases
+	LeaderboardCorpMembers  = "corporation_members"
+	LeaderboardCorpTreasury = "corporation_treasury"
+)
+
+// Leaderboard periods: daily and weekly are the last 24 hours and 7 days.
+const (
+	LeaderboardDaily   = "daily"
+	LeaderboardWeekly  = "weekly"
+	LeaderboardAllTime = "all_time"
+)
+
+// LeaderboardInfo describes a board: what it ranks and over which periods.
+type LeaderboardInfo struct {
+	Board   string   `json:"board"`
+	Subject string   `json:"subject"` // player or corporation
+	Periods []string `json:"periods"`
+}
+
+// Leaderboards lists every board. Boards of current values (net worth, corporations)
+// only exist for all time.
+var Leaderboards = []LeaderboardInfo{
+	{Board: LeaderboardKills, Subject: "player", Periods: []string{LeaderboardDaily, LeaderboardWeekly, LeaderboardAllTime}},
+	{Board: LeaderboardKD, Subject: "player", Periods: []string{LeaderboardDaily, LeaderboardWeekly, LeaderboardAllTime}},
+	{Board: LeaderboardNetWorth, Subject: "player", Periods: []string{LeaderboardAllTime}},
+	{Board: LeaderboardMarketVolume, Subject: "player", Periods: []string{LeaderboardDaily, LeaderboardWeekly, LeaderboardAllTime}},
+	{Board: LeaderboardCorpMembers, Subject: "corporation", Periods: []string{LeaderboardAllTime}},
+	{Board: LeaderboardCorpTreasury, Subject: "corporation", Periods: []string{LeaderboardAllTime}},
+}
+
+// LeaderboardEntry is one ranked player or corporation.
+type LeaderboardEntry struct {
+	Rank  int     `json:"rank"`
+	ID    string  `json:"id"`
+	Name  string  `json:"name"`
+	Tag   string  `json:"tag,omitempty"` // corporation tag
+	Value float64 `json:"value"`
+}
+
+// Leaderboard is one page of a board.
+type Leaderboard struct {
+	Board      string             `json:"board"`
+	Period     string             `json:"period"`
+	ComputedAt *time.Time         `json:"computed_at"`
+	Total      int                `json:"total"`
+	Entries    []LeaderboardEntry `json:"entries"`
+}
@@ -0,0 +1,42 @@
+package model
+
+import "time"
+
+// Mail is a message in a player's inbox. Attachments use the same item description as
+// trades; they are held in escrow until claimed.
+type Mail struct {
+	ID            int64       `json:"id"`
+	RecipientID   string      `json:"recipient_id"`
+	SenderID      *string     `json:"sender_id,omitempty"` // nil for system mail
+	SenderName    string      `json:"sender_name"`
+	CorporationID *string     `json:"corporation_id,omitempty"` // sent to the whole corporation
+	Subject       string      `json:"subject"`
+	Body          string      `json:"body"`
+	Credits       int64       `json:"credits"`
+	Items         []TradeItem `json:"items"`
+	ReadAt        *time.Time  `json:"read_at,omitempty"`
+	ClaimedAt     *time.Time  `json:"claimed_at,omitempty"`
+	CreatedAt     time.Time   `json:"created_at"`
+	ExpiresAt     time.Time   `json:"expires_at"`
+}
+
+// HasAttachments is true while there are credits or items left to claim.
+func (m *Mail) HasAttachments() bool {
+	return m.ClaimedAt == nil && (m.Credits > 0 || len(m.Items) > 0)
+}
+
+type Inbox struct {
+	Mail   []Mail `json:"mail"`
+	Unread int    `json:"unread"`
+}
+
+// SendMailRequest sends to a player by username, or to every member of the sender's
+// corporation (officers only, no attachments).
+type SendMailRequest struct {
+	To          string      `json:"to,omitempty"`
+	Corporation bool        `json:"corporation,omitempty"`
+	Subject     string      `json:"subject"`
+	Body        string      `json:"body"`
+	Credits     int64       `json:"credits,omitempty"`
+	Items       []TradeItem `json:"items,omitempty"`
+}
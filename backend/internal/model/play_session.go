@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// PlaySession is a continuous stretch of play on one game server.
+type PlaySession struct {
+	ID             int64      `json:"id"`
+	PlayerID       string     `json:"player_id"`
+	ServerID       *string    `json:"server_id,omitempty"`
+	StartedAt      time.Time  `json:"started_at"`
+	LastSeenAt     time.Time  `json:"last_seen_at"`
+	EndedAt        *time.Time `json:"ended_at,omitempty"` // nil while the player is online
+	SystemsVisited []int32    `json:"systems_visited"`
+}
+
+// ConcurrencyPoint is the number of players online at a point in time.
+type ConcurrencyPoint struct {
+	At      time.Time `json:"at"`
+	Players int       `json:"players"`
+}
+
+// RetentionCohort counts the players who registered on a day and how many of them
+// played again 1, 7 and 30 days later.
+type RetentionCohort struct {
+	Day        time.Time `json:"day"`
+	Registered int       `json:"registered"`
+	Day1       int       `json:"day_1"`
+	Day7       int       `json:"day_7"`
+	Day30      int       `json:"day_30"`
+}
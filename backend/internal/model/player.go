@@ -26,6 +26,8 @@ type Player struct {
 	CorporationID   *string    `json:"corporation_id,omitempty"`
 	Role            string     `json:"role"`
 	IsBanned        bool       `json:"is_banned"`
+	EmailVerified   bool       `json:"email_verified"`
+	TwoFactorEnabled bool      `json:"two_factor_enabled"`
 	LastLoginAt     *time.Time `json:"last_login_at,omitempty"`
 	LastSaveAt      *time.Time `json:"last_save_at,omitempty"`
 	CreatedAt       time.Time  `json:"created_at"`
@@ -41,6 +43,9 @@ type PlayerProfile struct {
 	CorporationID   *string `json:"corporation_id,omitempty"`
 	CorporationName *string `json:"corporation_name,omitempty"`
 	CorporationTag  *string `json:"corporation_tag,omitempty"`
+	PlaytimeSeconds int64   `json:"playtime_seconds"`
+	// Achievements are the unlocked ones, oldest first
+	Achievements []PlayerAchievement `json:"achievements"`
 }
 
 type PlayerResource struct {
@@ -71,6 +76,8 @@ type PlayerEquipment struct {
 // PlayerState is the full save/load payload
 type PlayerState struct {
 	HasSaved      bool            `json:"has_saved"`
+	// SaveVersion is returned on load and must be sent back on save (optimistic concurrency)
+	SaveVersion   *int64          `json:"save_version,omitempty"`
 	CurrentShipID string          `json:"current_ship_id"`
 	GalaxySeed    int64           `json:"galaxy_seed"`
 	SystemID      int             `json:"system_id"`
@@ -96,4 +103,72 @@ type PlayerState struct {
 	Factions   json.RawMessage `json:"factions,omitempty"`
 	EconomySim json.RawMessage `json:"economy_sim,omitempty"`
 	Pois       json.RawMessage `json:"pois,omitempty"`
+	// SchemaVersions is the format version of each JSON section (see stateschema).
+	// Loads are always upgraded to the current versions; a section missing from it in a save is at version 0.
+	SchemaVersions map[string]int `json:"schema_versions,omitempty"`
+}
+
+// BatchSaveEntry is one player of a batch save sent by the game server.
+type BatchSaveEntry struct {
+	PlayerID string      `json:"player_id"`
+	State    PlayerState `json:"state"`
+	Force    bool        `json:"force"`
+}
+
+// BatchSaveResult is the outcome of one BatchSaveEntry. On a stale save, SaveVersion is
+// the current version.
+type BatchSaveResult struct {
+	PlayerID    string `json:"player_id"`
+	OK          bool   `json:"ok"`
+	SaveVersion int64  `json:"save_version,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// PlayerStatePatch carries only the sections of a PlayerState that changed; it uses the
+// same JSON keys. Absent or null sections are left untouched, a list (even empty)
+// replaces its section and gameplay sections replace their key of gameplay_state.
+type PlayerStatePatch struct {
+	SaveVersion     *int64           `json:"save_version"`
+	CurrentShipID   *string          `json:"current_ship_id"`
+	GalaxySeed      *int64           `json:"galaxy_seed"`
+	SystemID        *int             `json:"system_id"`
+	PosX            *float64         `json:"pos_x"`
+	PosY            *float64         `json:"pos_y"`
+	PosZ            *float64         `json:"pos_z"`
+	RotationX       *float64         `json:"rotation_x"`
+	RotationY       *float64         `json:"rotation_y"`
+	RotationZ       *float64         `json:"rotation_z"`
+	Credits         *int64           `json:"credits"`
+	Kills           *int             `json:"kills"`
+	Deaths          *int             `json:"deaths"`
+	FactionID       *string          `json:"faction_id"`
+	Resources       []PlayerResource `json:"resources"`
+	Inventory       []InventoryItem  `json:"inventory"`
+	Cargo           []CargoItem      `json:"cargo"`
+	Equipment       *PlayerEquipment `json:"equipment"`
+	Fleet           json.RawMessage  `json:"fleet"`
+	StationServices json.RawMessage  `json:"station_services"`
+	Settings        json.RawMessage  `json:"settings"`
+	Missions        json.RawMessage  `json:"missions"`
+	Factions        json.RawMessage  `json:"factions"`
+	EconomySim      json.RawMessage  `json:"economy_sim"`
+	Pois            json.RawMessage  `json:"pois"`
+	SchemaVersions  map[string]int   `json:"schema_versions"`
+}
+
+// PositionOnly reports whether the patch only moves the player (the usual autosave).
+func (p *PlayerStatePatch) PositionOnly() bool {
+	return p.Credits == nil && p.Kills == nil && p.Deaths == nil && p.FactionID == nil &&
+		p.Resources == nil && p.Inventory == nil && p.Cargo == nil && p.Equipment == nil &&
+		p.Fleet == nil && p.StationServices == nil && p.Settings == nil &&
+		p.Missions == nil && p.Factions == nil && p.EconomySim == nil && p.Pois == nil
+}
+
+// Normalize turns JSON nulls in raw sections into absent sections.
+func (p *PlayerStatePatch) Normalize() {
+	for _, raw := range []*json.RawMessage{&p.Fleet, &p.StationServices, &p.Settings, &p.Missions, &p.Factions, &p.EconomySim, &p.Pois} {
+		if string(*raw) == "null" {
+			*raw = nil
+		}
+	}
 }
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// PlayerSnapshot is a copy of a PlayerState taken when it was saved.
+// State is only filled when a single snapshot is fetched.
+type PlayerSnapshot struct {
+	ID          int64           `json:"id"`
+	PlayerID    string          `json:"player_id"`
+	SaveVersion int64           `json:"save_version"`
+	Daily       bool            `json:"daily"`
+	Reason      string          `json:"reason"`
+	CreatedBy   string          `json:"created_by,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	State       json.RawMessage `json:"state,omitempty"`
+}
+
+// SnapshotChange is one top-level PlayerState field that differs between two states.
+type SnapshotChange struct {
+	Field  string          `json:"field"`
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
+// SnapshotDiff compares a snapshot ("from") with another snapshot or the current state ("to").
+type SnapshotDiff struct {
+	From    int64            `json:"from"`
+	To      string           `json:"to"` // snapshot ID, or "current"
+	Changes []SnapshotChange `json:"changes"`
+}
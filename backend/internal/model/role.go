@@ -0,0 +1,12 @@
+package model
+
+type Role struct {
+	Name        string   `json:"name"`
+	Rank        int      `json:"rank"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type SetRoleRequest struct {
+	Role string `json:"role"`
+}
@@ -0,0 +1,87 @@
+package model
+
+import "time"
+
+// Trade statuses
+const (
+	TradeOpen      = "open"
+	TradeCompleted = "completed"
+	TradeCancelled = "cancelled"
+	TradeExpired   = "expired"
+)
+
+// Sides of a trade
+const (
+	TradeSideInitiator = "initiator"
+	TradeSidePartner   = "partner"
+)
+
+// Where a traded item comes from and goes to
+const (
+	TradeSourceInventory = "inventory" // player_inventory (category + item_name)
+	TradeSourceCargo     = "cargo"     // player_cargo (item_name)
+)
+
+type TradeItem struct {
+	Source    string  `json:"source"`
+	Category  string  `json:"category,omitempty"`
+	ItemName  string  `json:"item_name"`
+	ItemType  string  `json:"item_type,omitempty"`
+	IconColor *string `json:"icon_color,omitempty"`
+	Quantity  int     `json:"quantity"`
+}
+
+// TradeOffer is what one side gives.
+type TradeOffer struct {
+	PlayerID  string      `json:"player_id"`
+	Username  string      `json:"username"`
+	Credits   int64       `json:"credits"`
+	Items     []TradeItem `json:"items"`
+	Confirmed bool        `json:"confirmed"`
+}
+
+// Trade is a swap between two players. Revision changes with every offer change;
+// a confirmation only counts for the revision it was given for.
+type Trade struct {
+	ID        int64      `json:"id"`
+	Status    string     `json:"status"`
+	Revision  int        `json:"revision"`
+	Initiator TradeOffer `json:"initiator"`
+	Partner   TradeOffer `json:"partner"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	ClosedAt  *time.Time `json:"closed_at,omitempty"`
+}
+
+// Side returns the side playerID is on, "" if they are not part of the trade.
+func (t *Trade) Side(playerID string) string {
+	switch playerID {
+	case t.Initiator.PlayerID:
+		return TradeSideInitiator
+	case t.Partner.PlayerID:
+		return TradeSidePartner
+	}
+	return ""
+}
+
+// Other returns the ID of the player trading with playerID.
+func (t *Trade) Other(playerID string) string {
+	if playerID == t.Initiator.PlayerID {
+		return t.Partner.PlayerID
+	}
+	return t.Initiator.PlayerID
+}
+
+type CreateTradeRequest struct {
+	Username string `json:"username"`
+}
+
+type TradeOfferRequest struct {
+	Credits int64       `json:"credits"`
+	Items   []TradeItem `json:"items"`
+}
+
+type ConfirmTradeRequest struct {
+	Revision int `json:"revision"`
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Account token purposes
+const (
+	TokenPurposePasswordReset = "password_reset"
+	TokenPurposeEmailVerify   = "email_verify"
+)
+
+type AccountTokenRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewAccountTokenRepository(pool *pgxpool.Pool) *AccountTokenRepository {
+	return &AccountTokenRepository{pool: pool}
+}
+
+// Create stores a new token hash. Any earlier unused token for the same purpose is discarded
+// so only the most recently mailed link works.
+func (r *AccountTokenRepository) Create(ctx context.Context, playerID, purpose, tokenHash string, expiresAt time.Time) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `
+		DELETE FROM account_tokens WHERE player_id = $1 AND purpose = $2 AND used_at IS NULL
+	`, playerID, purpose); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO account_tokens (player_id, purpose, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+	`, playerID, purpose, tokenHash, expiresAt); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Consume marks a valid token as used and returns its player ID.
+// Returns pgx.ErrNoRows if the token is unknown, expired or already used.
+func (r *AccountTokenRepository) Consume(ctx context.Context, purpose, tokenHash string) (string, error) {
+	var playerID string
+	err := r.pool.QueryRow(ctx, `
+		UPDATE account_tokens SET used_at = NOW()
+		WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING player_id
+	`, tokenHash, purpose).Scan(&playerID)
+	return playerID, err
+}
+
+// DeleteExpired removes expired or used tokens older than a day.
+func (r *AccountTokenRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `
+		DELETE FROM account_tokens
+		WHERE expires_at < NOW() - INTERVAL '1 day' OR used_at < NOW() - INTERVAL '1 day'
+	`)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"spacegame-backend/internal/model"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// achievementStat computes a player statistic. $1 is the player ID, or the username
+// when byName is set (game events only know names).
+type achievementStat struct {
+	query  string
+	byName bool
+}
+
+var achievementStats = map[string]achievementStat{
+	model.AchievementStatKills: {byName: true, query: `
+		SELECT COUNT(*) FROM game_events WHERE event_type = 'kill' AND actor_name = $1`},
+	model.AchievementStatMarketSales: {query: `
+		SELECT COALESCE(SUM(unit_price * quantity), 0)::BIGINT FROM market_listings
+		WHERE seller_id = $1 AND status = 'sold'`},
+	model.AchievementStatCorporation: {query: `
+		SELECT COUNT(*) FROM corporation_members WHERE player_id = $1`},
+	model.AchievementStatDiscoveries: {byName: true, query: `
+		SELECT COUNT(DISTINCT system_id) FROM game_events WHERE event_type = 'discovery' AND actor_name = $1`},
+}
+
+type AchievementRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewAchievementRepository(pool *pgxpool.Pool) *AchievementRepository {
+	return &AchievementRepository{pool: pool}
+}
+
+// Stat returns the current value of a player statistic (see model.AchievementStat*).
+func (r *AchievementRepository) Stat(ctx context.Context, playerID, username, stat string) (int64, error) {
+	st, ok := achievementStats[stat]
+	if !ok {
+		return 0, fmt.Errorf("unknown achievement stat %q", stat)
+	}
+	arg := playerID
+	if st.byName {
+		arg = username
+	}
+	var value int64
+	err := r.pool.QueryRow(ctx, st.query, arg).Scan(&value)
+	return value, err
+}
+
+// ListForPlayer returns when the player unlocked each of their achievements.
+func (r *AchievementRepository) ListForPlayer(ctx context.Context, playerID string) (map[string]time.Time, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT achievement_id, unlocked_at FROM player_achievements WHERE player_id = $1
+	`, playerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	unlocked := make(map[string]time.Time)
+	for rows.Next() {
+		var id string
+		var at time.Time
+		if err := rows.Scan(&id, &at); err != nil {
+			return nil, err
+		}
+		unlocked[id] = at
+	}
+	return unlocked, rows.Err()
+}
+
+// Unlock records an achievement; false when the player already had it.
+func (r *AchievementRepository) Unlock(ctx context.Context, playerID, achievementID string) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		INSERT INTO player_achievements (player_id, achievement_id) VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`, playerID, achievementID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
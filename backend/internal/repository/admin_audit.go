@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"spacegame-backend/internal/model"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AdminAuditRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewAdminAuditRepository(pool *pgxpool.Pool) *AdminAuditRepository {
+	return &AdminAuditRepository{pool: pool}
+}
+
+// Insert appends an entry to the audit log.
+func (r *AdminAuditRepository) Insert(ctx context.Context, e *model.AdminAuditEntry) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO admin_audit_log (actor_type, actor_id, actor_name, action, target, path, payload, status, ip_address)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, e.ActorType, e.ActorID, e.ActorName, e.Action, e.Target, e.Path, e.Payload, e.Status, e.IPAddress)
+	return err
+}
+
+// Query returns entries matching the filter, newest first.
+func (r *AdminAuditRepository) Query(ctx context.Context, f model.AdminAuditFilter) ([]model.AdminAuditEntry, error) {
+	var where []string
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if f.Actor != "" {
+		add("actor_name = $%d", f.Actor)
+	}
+	if f.Action != "" {
+		add("action ILIKE '%%' || $%d || '%%'", f.Action)
+	}
+	if f.Target != "" {
+		add("target ILIKE '%%' || $%d || '%%'", f.Target)
+	}
+	if f.Since != nil {
+		add("created_at >= $%d", *f.Since)
+	}
+	if f.Until != nil {
+		add("created_at < $%d", *f.Until)
+	}
+	if f.BeforeID > 0 {
+		add("id < $%d", f.BeforeID)
+	}
+
+	query := `SELECT id, actor_type, actor_id, actor_name, action, target, path, payload, status, ip_address, created_at
+		FROM admin_audit_log`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	args = append(args, f.Limit)
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d", len(args))
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []model.AdminAuditEntry{}
+	for rows.Next() {
+		var e model.AdminAuditEntry
+		if err := rows.Scan(&e.ID, &e.ActorType, &e.ActorID, &e.ActorName, &e.Action, &e.Target, &e.Path, &e.Payload, &e.Status, &e.IPAddress, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
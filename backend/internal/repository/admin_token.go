@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"spacegame-backend/internal/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AdminTokenRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewAdminTokenRepository(pool *pgxpool.Pool) *AdminTokenRepository {
+	return &AdminTokenRepository{pool: pool}
+}
+
+const adminTokenColumns = `id, name, scopes, created_by, created_at, expires_at, last_used_at, revoked_at`
+
+func scanAdminToken(row pgx.Row) (*model.AdminToken, error) {
+	t := &model.AdminToken{}
+	err := row.Scan(&t.ID, &t.Name, &t.Scopes, &t.CreatedBy, &t.CreatedAt, &t.ExpiresAt, &t.LastUsedAt, &t.RevokedAt)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Create stores a new token by hash.
+func (r *AdminTokenRepository) Create(ctx context.Context, name, tokenHash string, scopes []string, createdBy string, expiresAt *time.Time) (*model.AdminToken, error) {
+	return scanAdminToken(r.pool.QueryRow(ctx, `
+		INSERT INTO admin_api_tokens (name, token_hash, scopes, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+adminTokenColumns,
+		name, tokenHash, scopes, createdBy, expiresAt))
+}
+
+// GetActiveByHash returns a usable (not revoked, not expired) token.
+func (r *AdminTokenRepository) GetActiveByHash(ctx context.Context, tokenHash string) (*model.AdminToken, error) {
+	return scanAdminToken(r.pool.QueryRow(ctx, `
+		SELECT `+adminTokenColumns+` FROM admin_api_tokens
+		WHERE token_hash = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())
+	`, tokenHash))
+}
+
+// List returns every token, newest first.
+func (r *AdminTokenRepository) List(ctx context.Context) ([]model.AdminToken, error) {
+	rows, err := r.pool.Query(ctx, `SELECT `+adminTokenColumns+` FROM admin_api_tokens ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []model.AdminToken{}
+	for rows.Next() {
+		t, err := scanAdminToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, *t)
+	}
+	return tokens, rows.Err()
+}
+
+// Revoke disables a token. Returns false if it doesn't exist or is already revoked.
+func (r *AdminTokenRepository) Revoke(ctx context.Context, id string) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE admin_api_tokens SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL
+	`, id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// Touch records a token use.
+func (r *AdminTokenRepository) Touch(ctx context.Context, id string) error {
+	_, err := r.pool.Exec(ctx, `UPDATE admin_api_tokens SET last_used_at = NOW() WHERE id = $1`, id)
+	return err
+}
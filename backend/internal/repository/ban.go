@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"spacegame-backend/internal/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const banColumns = `id, player_id, reason, issued_by, starts_at, expires_at, lifted_at, lifted_by, appeal_notes, created_at,
+	(lifted_at IS NULL AND starts_at <= NOW() AND (expires_at IS NULL OR expires_at > NOW()))`
+
+// activeBanCond matches bans currently in force (alias b)
+const activeBanCond = `b.lifted_at IS NULL AND b.starts_at <= NOW() AND (b.expires_at IS NULL OR b.expires_at > NOW())`
+
+type BanRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewBanRepository(pool *pgxpool.Pool) *BanRepository {
+	return &BanRepository{pool: pool}
+}
+
+func scanBan(row pgx.Row) (*model.Ban, error) {
+	b := &model.Ban{}
+	err := row.Scan(&b.ID, &b.PlayerID, &b.Reason, &b.IssuedBy, &b.StartsAt, &b.ExpiresAt,
+		&b.LiftedAt, &b.LiftedBy, &b.AppealNotes, &b.CreatedAt, &b.Active)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Create records a ban and refreshes the player's is_banned flag.
+func (r *BanRepository) Create(ctx context.Context, playerID, reason, issuedBy string, startsAt time.Time, expiresAt *time.Time) (*model.Ban, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	ban, err := scanBan(tx.QueryRow(ctx, `
+		INSERT INTO bans (player_id, reason, issued_by, starts_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+banColumns,
+		playerID, reason, issuedBy, startsAt, expiresAt))
+	if err != nil {
+		return nil, err
+	}
+	if err := syncBanFlag(ctx, tx, playerID); err != nil {
+		return nil, err
+	}
+
+	return ban, tx.Commit(ctx)
+}
+
+// Lift ends a ban early and refreshes the player's is_banned flag.
+// Returns pgx.ErrNoRows if the ban doesn't exist or was already lifted.
+func (r *BanRepository) Lift(ctx context.Context, banID int64, liftedBy, note string) (*model.Ban, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	ban, err := scanBan(tx.QueryRow(ctx, `
+		UPDATE bans SET lifted_at = NOW(), lifted_by = $2,
+		       appeal_notes = appeal_notes || $3
+		WHERE id = $1 AND lifted_at IS NULL
+		RETURNING `+banColumns,
+		banID, liftedBy, note))
+	if err != nil {
+		return nil, err
+	}
+	if err := syncBanFlag(ctx, tx, ban.PlayerID); err != nil {
+		return nil, err
+	}
+
+	return ban, tx.Commit(ctx)
+}
+
+// AppendAppealNote adds a line to a ban's appeal notes. Returns false if the ban doesn't exist.
+func (r *BanRepository) AppendAppealNote(ctx context.Context, banID int64, note string) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE bans SET appeal_notes = appeal_notes || $2 WHERE id = $1
+	`, banID, note)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// GetByID returns a single ban.
+func (r *BanRepository) GetByID(ctx context.Context, banID int64) (*model.Ban, error) {
+	return scanBan(r.pool.QueryRow(ctx, `SELECT `+banColumns+` FROM bans WHERE id = $1`, banID))
+}
+
+// GetActive returns the player's ban currently in force that ends last (permanent first).
+func (r *BanRepository) GetActive(ctx context.Context, playerID string) (*model.Ban, error) {
+	return scanBan(r.pool.QueryRow(ctx, `
+		SELECT `+banColumns+` FROM bans b
+		WHERE b.player_id = $1 AND `+activeBanCond+`
+		ORDER BY b.expires_at DESC NULLS FIRST
+		LIMIT 1
+	`, playerID))
+}
+
+// ListForPlayer returns a player's full ban history, newest first.
+func (r *BanRepository) ListForPlayer(ctx context.Context, playerID string) ([]model.Ban, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+banColumns+` FROM bans WHERE player_id = $1 ORDER BY created_at DESC
+	`, playerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bans []model.Ban
+	for rows.Next() {
+		b, err := scanBan(rows)
+		if err != nil {
+			return nil, err
+		}
+		bans = append(bans, *b)
+	}
+	return bans, rows.Err()
+}
+
+// ListActive returns every player currently banned, with the end of their
+// longest ban (nil = permanent).
+func (r *BanRepository) ListActive(ctx context.Context) (map[string]*time.Time, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT b.player_id,
+		       CASE WHEN BOOL_OR(b.expires_at IS NULL) THEN NULL ELSE MAX(b.expires_at) END
+		FROM bans b
+		WHERE `+activeBanCond+`
+		GROUP BY b.player_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	active := make(map[string]*time.Time)
+	for rows.Next() {
+		var playerID string
+		var expiresAt *time.Time
+		if err := rows.Scan(&playerID, &expiresAt); err != nil {
+			return nil, err
+		}
+		active[playerID] = expiresAt
+	}
+	return active, rows.Err()
+}
+
+// SyncFlags brings players.is_banned in line with the bans table for players that
+// have a ban history (bans expiring or scheduled bans starting).
+func (r *BanRepository) SyncFlags(ctx context.Context) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE players p SET is_banned = s.banned, updated_at = NOW()
+		FROM (
+			SELECT b.player_id, BOOL_OR(`+activeBanCond+`) AS banned
+			FROM bans b GROUP BY b.player_id
+		) s
+		WHERE p.id = s.player_id AND p.is_banned <> s.banned
+	`)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func syncBanFlag(ctx context.Context, tx pgx.Tx, playerID string) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE players SET is_banned = EXISTS (
+			SELECT 1 FROM bans b WHERE b.player_id = $1 AND `+activeBanCond+`
+		), updated_at = NOW()
+		WHERE id = $1
+	`, playerID)
+	return err
+}
@@ -8,6 +8,7 @@ import (
 
 	"spacegame-backend/internal/model"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -19,18 +20,19 @@ func NewChatRepository(pool *pgxpool.Pool) *ChatRepository {
 	return &ChatRepository{pool: pool}
 }
 
-// InsertMessage stores a single chat message.
-func (r *ChatRepository) InsertMessage(ctx context.Context, msg model.ChatPostRequest) error {
+// InsertMessage stores a single chat message relayed by a game server.
+func (r *ChatRepository) InsertMessage(ctx context.Context, msg model.ChatPostRequest, serverID *string) error {
 	_, err := r.pool.Exec(ctx, `
-		INSERT INTO chat_messages (channel, system_id, sender_name, text)
-		VALUES ($1, $2, $3, $4)
-	`, msg.Channel, msg.SystemID, msg.SenderName, msg.Text)
+		INSERT INTO chat_messages (channel, system_id, sender_name, text, server_id)
+		VALUES ($1, $2, $3, $4, $5)
+	`, msg.Channel, msg.SystemID, msg.SenderName, msg.Text, serverID)
 	return err
 }
 
 // GetHistory retrieves recent messages for the given channels, ordered chronologically.
-// For channel=1 (SYSTEM), only messages matching systemID are returned.
-func (r *ChatRepository) GetHistory(ctx context.Context, channels []int, systemID int, limit int) ([]model.ChatMessage, error) {
+// For channel=1 (SYSTEM), only messages matching systemID are returned. When viewerID is
+// set, messages between the viewer and players blocked either way are left out.
+func (r *ChatRepository) GetHistory(ctx context.Context, channels []int, systemID int, limit int, viewerID string) ([]model.ChatMessage, error) {
 	if len(channels) == 0 {
 		return nil, nil
 	}
@@ -75,6 +77,17 @@ func (r *ChatRepository) GetHistory(ctx context.Context, channels []int, systemI
 	}
 
 	where := strings.Join(conditions, " OR ")
+	if viewerID != "" {
+		where = fmt.Sprintf(`(%s) AND sender_name NOT IN (
+			SELECT p.username FROM player_relations pr JOIN players p ON p.id = pr.other_id
+			WHERE pr.player_id = $%[2]d AND pr.status = 'blocked'
+			UNION
+			SELECT p.username FROM player_relations pr JOIN players p ON p.id = pr.player_id
+			WHERE pr.other_id = $%[2]d AND pr.status = 'blocked'
+		)`, where, argIdx)
+		args = append(args, viewerID)
+		argIdx++
+	}
 	limitPlaceholder := fmt.Sprintf("$%d", argIdx)
 	args = append(args, limit)
 
@@ -122,3 +135,32 @@ func (r *ChatRepository) DeleteOlderThan(ctx context.Context, days int) (int64,
 	}
 	return tag.RowsAffected(), nil
 }
+
+// GetBySender returns every stored message sent under a name.
+func (r *ChatRepository) GetBySender(ctx context.Context, senderName string) ([]model.ChatMessage, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, channel, system_id, sender_name, text, created_at
+		FROM chat_messages WHERE sender_name = $1
+		ORDER BY created_at
+	`, senderName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	msgs := []model.ChatMessage{}
+	for rows.Next() {
+		var m model.ChatMessage
+		if err := rows.Scan(&m.ID, &m.Channel, &m.SystemID, &m.SenderName, &m.Text, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, m)
+	}
+	return msgs, rows.Err()
+}
+
+// DeleteBySenderTx removes every message sent under a name (account deletion).
+func (r *ChatRepository) DeleteBySenderTx(ctx context.Context, tx pgx.Tx, senderName string) error {
+	_, err := tx.Exec(ctx, `DELETE FROM chat_messages WHERE sender_name = $1`, senderName)
+	return err
+}
@@ -2,11 +2,13 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"spacegame-backend/internal/model"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -289,22 +291,53 @@ func (r *CorporationRepository) DeleteRank(ctx context.Context, rankID int64) er
 
 // --- Treasury ---
 
-func (r *CorporationRepository) UpdateTreasury(ctx context.Context, corporationID string, amount int64) (int64, error) {
-	var newBalance int64
-	err := r.pool.QueryRow(ctx, `
+// TransferTreasury moves credits between a player and the treasury (amount > 0 is a
+// deposit, < 0 a withdrawal) and records the corporation transaction and the player's
+// ledger entry in the same transaction. Returns the new treasury and player balances.
+// A treasury that can't cover a withdrawal yields pgx.ErrNoRows.
+func (r *CorporationRepository) TransferTreasury(ctx context.Context, corporationID, playerID, actorName string, amount int64) (int64, int64, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	reason, txType := model.CreditReasonCorpDeposit, "deposit"
+	if amount < 0 {
+		reason, txType = model.CreditReasonCorpWithdraw, "withdraw"
+	}
+
+	var treasury int64
+	err = tx.QueryRow(ctx, `
 		UPDATE corporations SET treasury = treasury + $2, updated_at = NOW()
 		WHERE id = $1 AND treasury + $2 >= 0
 		RETURNING treasury
-	`, corporationID, amount).Scan(&newBalance)
-	return newBalance, err
-}
+	`, corporationID, amount).Scan(&treasury)
+	if err != nil {
+		return 0, 0, err
+	}
 
-func (r *CorporationRepository) AddTransaction(ctx context.Context, corporationID, playerID, actorName, txType string, amount int64) error {
-	_, err := r.pool.Exec(ctx, `
+	credits, err := addCreditsTx(ctx, tx, playerID, -amount, reason, corporationID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	_, err = tx.Exec(ctx, `
 		INSERT INTO corporation_transactions (corporation_id, player_id, actor_name, tx_type, amount)
 		VALUES ($1, $2, $3, $4, $5)
-	`, corporationID, playerID, actorName, txType, amount)
-	return err
+	`, corporationID, playerID, actorName, txType, abs64(amount))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return treasury, credits, tx.Commit(ctx)
+}
+
+func abs64(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
 }
 
 func (r *CorporationRepository) GetTransactions(ctx context.Context, corporationID string, limit int) ([]*model.CorporationTransaction, error) {
@@ -474,3 +507,160 @@ func (r *CorporationRepository) GetPlayerApplications(ctx context.Context, playe
 	}
 	return apps, nil
 }
+
+// --- Personal data ---
+
+// GetPlayerApplicationHistory returns all of a player's applications, whatever their status.
+func (r *CorporationRepository) GetPlayerApplicationHistory(ctx context.Context, playerID string) ([]*model.CorporationApplication, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT a.id, a.corporation_id, a.player_id, a.player_name, a.note, a.status, a.created_at
+		FROM corporation_applications a
+		WHERE a.player_id = $1
+		ORDER BY a.created_at
+	`, playerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	apps := []*model.CorporationApplication{}
+	for rows.Next() {
+		a := &model.CorporationApplication{}
+		if err := rows.Scan(&a.ID, &a.CorporationID, &a.PlayerID, &a.PlayerName, &a.Note, &a.Status, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		apps = append(apps, a)
+	}
+	return apps, rows.Err()
+}
+
+// GetPlayerTransactions returns every treasury transaction made by a player.
+func (r *CorporationRepository) GetPlayerTransactions(ctx context.Context, playerID string) ([]*model.CorporationTransaction, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, corporation_id, player_id, actor_name, tx_type, amount, created_at
+		FROM corporation_transactions WHERE player_id = $1 ORDER BY created_at
+	`, playerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	txs := []*model.CorporationTransaction{}
+	for rows.Next() {
+		tx := &model.CorporationTransaction{}
+		if err := rows.Scan(&tx.ID, &tx.CorporationID, &tx.PlayerID, &tx.ActorName, &tx.TxType, &tx.Amount, &tx.CreatedAt); err != nil {
+			return nil, err
+		}
+		txs = append(txs, tx)
+	}
+	return txs, rows.Err()
+}
+
+// GetActivityByName returns corporation activity entries where the name appears as actor or target.
+func (r *CorporationRepository) GetActivityByName(ctx context.Context, name string) ([]*model.CorporationActivity, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, corporation_id, event_type, actor_name, target_name, details, created_at
+		FROM corporation_activity WHERE actor_name = $1 OR target_name = $1 ORDER BY created_at
+	`, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	activities := []*model.CorporationActivity{}
+	for rows.Next() {
+		a := &model.CorporationActivity{}
+		if err := rows.Scan(&a.ID, &a.CorporationID, &a.EventType, &a.ActorName, &a.TargetName, &a.Details, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		activities = append(activities, a)
+	}
+	return activities, rows.Err()
+}
+
+// AnonymisePlayerTx replaces the name copies of a deleted player in corporation history
+// and drops their applications.
+// LeaveTx removes a member inside tx without leaving the corporation leaderless: if they
+// held a rank above every remaining member, the highest-ranked one (longest-serving on
+// ties) is promoted to it. The last member leaving deletes the corporation. Returns the
+// promoted member's ID ("" if none) and whether the corporation was deleted.
+func (r *CorporationRepository) LeaveTx(ctx context.Context, tx pgx.Tx, playerID, corporationID, actorName string) (string, bool, error) {
+	// Serialises concurrent departures from the same corporation
+	if _, err := tx.Exec(ctx, `SELECT id FROM corporations WHERE id = $1 FOR UPDATE`, corporationID); err != nil {
+		return "", false, err
+	}
+	var rank int
+	err := tx.QueryRow(ctx, `
+		DELETE FROM corporation_members WHERE player_id = $1 AND corporation_id = $2 RETURNING rank_priority
+	`, playerID, corporationID).Scan(&rank)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	var nextID, nextName string
+	var nextRank int
+	err = tx.QueryRow(ctx, `
+		SELECT cm.player_id, p.username, cm.rank_priority
+		FROM corporation_members cm
+		JOIN players p ON cm.player_id = p.id
+		WHERE cm.corporation_id = $1
+		ORDER BY cm.rank_priority DESC, cm.joined_at ASC
+		LIMIT 1
+	`, corporationID).Scan(&nextID, &nextName, &nextRank)
+	if errors.Is(err, pgx.ErrNoRows) {
+		if _, err := tx.Exec(ctx, `DELETE FROM corporations WHERE id = $1`, corporationID); err != nil {
+			return "", false, err
+		}
+		return "", true, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO corporation_activity (corporation_id, event_type, actor_name, target_name, details)
+		VALUES ($1, 2, $2, $2, 'left the corporation')
+	`, corporationID, actorName); err != nil {
+		return "", false, err
+	}
+	if nextRank >= rank {
+		return "", false, nil
+	}
+	if _, err := tx.Exec(ctx, `UPDATE corporation_members SET rank_priority = $2 WHERE player_id = $1`, nextID, rank); err != nil {
+		return "", false, err
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO corporation_activity (corporation_id, event_type, actor_name, target_name, details)
+		VALUES ($1, 2, $2, $3, 'took over the leadership')
+	`, corporationID, actorName, nextName)
+	if err != nil {
+		return "", false, err
+	}
+	return nextID, false, nil
+}
+
+func (r *CorporationRepository) AnonymisePlayerTx(ctx context.Context, tx pgx.Tx, playerID, name, anonName string) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM corporation_applications WHERE player_id = $1`, playerID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM corporation_members WHERE player_id = $1`, playerID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE corporation_transactions SET actor_name = $2 WHERE player_id = $1
+	`, playerID, anonName); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE corporation_activity SET actor_name = $2 WHERE actor_name = $1
+	`, name, anonName); err != nil {
+		return err
+	}
+	_, err := tx.Exec(ctx, `
+		UPDATE corporation_activity SET target_name = $2 WHERE target_name = $1
+	`, name, anonName)
+	return err
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"spacegame-backend/internal/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrInsufficientCredits is returned when a debit would make a balance negative.
+var ErrInsufficientCredits = errors.New("insufficient credits")
+
+type CreditLedgerRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewCreditLedgerRepository(pool *pgxpool.Pool) *CreditLedgerRepository {
+	return &CreditLedgerRepository{pool: pool}
+}
+
+// addCreditsTx changes a player's credits and records it in the ledger. Debits that
+// would leave a negative balance fail with ErrInsufficientCredits.
+func addCreditsTx(ctx context.Context, tx pgx.Tx, playerID string, delta int64, reason, referenceID string) (int64, error) {
+	var balance int64
+	err := tx.QueryRow(ctx, `
+		UPDATE players SET credits = credits + $2, updated_at = NOW()
+		WHERE id = $1 AND ($2 >= 0 OR credits + $2 >= 0)
+		RETURNING credits
+	`, playerID, delta).Scan(&balance)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrInsufficientCredits
+	}
+	if err != nil {
+		return 0, err
+	}
+	return balance, recordCreditsTx(ctx, tx, playerID, delta, balance, reason, referenceID)
+}
+
+// recordCreditsTx writes a ledger entry for a change already applied to players.credits.
+func recordCreditsTx(ctx context.Context, tx pgx.Tx, playerID string, delta, balanceAfter int64, reason, referenceID string) error {
+	if delta == 0 {
+		return nil
+	}
+	_, err := tx.Exec(ctx, `
+		INSERT INTO credit_ledger (player_id, delta, balance_after, reason, reference_id)
+		VALUES ($1, $2, $3, $4, $5)
+	`, playerID, delta, balanceAfter, reason, referenceID)
+	return err
+}
+
+// ListForPlayer returns a player's ledger entries, newest first. beforeID pages back
+// through older entries (0 starts from the newest); limit 0 means all.
+func (r *CreditLedgerRepository) ListForPlayer(ctx context.Context, playerID string, beforeID int64, limit int) ([]model.CreditLedgerEntry, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, player_id, delta, balance_after, reason, reference_id, created_at
+		FROM credit_ledger
+		WHERE player_id = $1 AND ($2 = 0 OR id < $2)
+		ORDER BY id DESC
+		LIMIT NULLIF($3, 0)
+	`, playerID, beforeID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []model.CreditLedgerEntry{}
+	for rows.Next() {
+		var e model.CreditLedgerEntry
+		if err := rows.Scan(&e.ID, &e.PlayerID, &e.Delta, &e.BalanceAfter, &e.Reason, &e.ReferenceID, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// Reconcile compares every player's credits with the sum of their ledger and returns
+// the number of players checked and those that don't match.
+func (r *CreditLedgerRepository) Reconcile(ctx context.Context) (int, []model.CreditMismatch, error) {
+	var players int
+	if err := r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM players`).Scan(&players); err != nil {
+		return 0, nil, err
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT p.id, p.username, p.credits, COALESCE(l.total, 0)
+		FROM players p
+		LEFT JOIN (SELECT player_id, SUM(delta)::BIGINT AS total FROM credit_ledger GROUP BY player_id) l ON l.player_id = p.id
+		WHERE p.credits != COALESCE(l.total, 0)
+		ORDER BY p.username
+	`)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer rows.Close()
+
+	mismatches := []model.CreditMismatch{}
+	for rows.Next() {
+		var m model.CreditMismatch
+		if err := rows.Scan(&m.PlayerID, &m.Username, &m.Credits, &m.LedgerSum); err != nil {
+			return 0, nil, err
+		}
+		mismatches = append(mismatches, m)
+	}
+	return players, mismatches, rows.Err()
+}
@@ -6,6 +6,7 @@ import (
 
 	"spacegame-backend/internal/model"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -17,13 +18,14 @@ func NewEventRepository(db *pgxpool.Pool) *EventRepository {
 	return &EventRepository{db: db}
 }
 
-func (r *EventRepository) Create(ctx context.Context, eventType, actorName, targetName string, details json.RawMessage, systemID int) (*model.GameEvent, error) {
+// Create stores an event. serverID is the game server that reported it (nil for backend events).
+func (r *EventRepository) Create(ctx context.Context, eventType, actorName, targetName string, details json.RawMessage, systemID int, serverID *string) (*model.GameEvent, error) {
 	var e model.GameEvent
 	err := r.db.QueryRow(ctx,
-		`INSERT INTO game_events (event_type, actor_name, target_name, details, system_id)
-		 VALUES ($1, $2, $3, $4, $5)
+		`INSERT INTO game_events (event_type, actor_name, target_name, details, system_id, server_id)
+		 VALUES ($1, $2, $3, $4, $5, $6)
 		 RETURNING id, event_type, actor_name, target_name, details, system_id, created_at`,
-		eventType, actorName, targetName, details, systemID,
+		eventType, actorName, targetName, details, systemID, serverID,
 	).Scan(&e.ID, &e.EventType, &e.ActorName, &e.TargetName, &e.Details, &e.SystemID, &e.CreatedAt)
 	if err != nil {
 		return nil, err
@@ -55,3 +57,12 @@ func (r *EventRepository) ListByType(ctx context.Context, eventType string, limi
 	}
 	return events, nil
 }
+
+// AnonymiseNameTx replaces a deleted player's name in recorded events.
+func (r *EventRepository) AnonymiseNameTx(ctx context.Context, tx pgx.Tx, name, anonName string) error {
+	if _, err := tx.Exec(ctx, `UPDATE game_events SET actor_name = $2 WHERE actor_name = $1`, name, anonName); err != nil {
+		return err
+	}
+	_, err := tx.Exec(ctx, `UPDATE game_events SET target_name = $2 WHERE target_name = $1`, name, anonName)
+	return err
+}
@@ -3,10 +3,12 @@ package repository
 import (
 	"context"
 	"encoding/json"
-	"time"
+	"strconv"
+	"strings"
 
 	"spacegame-backend/internal/model"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -82,9 +84,42 @@ func (r *FleetRepository) GetDeployedShips(ctx context.Context) ([]model.FleetSh
 	return ships, nil
 }
 
-// BulkUpsertFleetShips inserts or updates fleet ships for a player.
-func (r *FleetRepository) BulkUpsertFleetShips(ctx context.Context, ships []model.FleetShipDB) error {
-	if len(ships) == 0 {
+// fleetStageColumns are the fleet_ships columns copied into the staging table by
+// BulkUpsertFleetShips (updated_at and updated_by_server_id are set on insert).
+var fleetStageColumns = []string{
+	"player_id", "fleet_index", "ship_id", "custom_name", "deployment_state",
+	"system_id", "station_id", "pos_x", "pos_y", "pos_z",
+	"command", "command_params", "weapons", "shield_name", "engine_name", "modules",
+	"cargo", "ship_resources", "hull_ratio", "shield_ratio",
+	"squadron_id", "squadron_role", "deployed_at", "destroyed_at",
+}
+
+// BulkUpsertFleetShips inserts or updates fleet ships (of one or many players). The rows
+// are streamed with COPY into a temporary table, then merged in a single statement.
+// When a slot appears twice, the last one wins.
+func (r *FleetRepository) BulkUpsertFleetShips(ctx context.Context, ships []model.FleetShipDB, serverID *string) error {
+	rows := make([][]any, 0, len(ships))
+	slots := make(map[string]int, len(ships))
+	for _, s := range ships {
+		if s.ShipID == "" {
+			continue // Empty slot — skip
+		}
+		row := []any{
+			s.PlayerID, s.FleetIndex, s.ShipID, s.CustomName, s.DeploymentState,
+			s.SystemID, s.StationID, s.PosX, s.PosY, s.PosZ,
+			s.Command, jsonOr(s.CommandParams, `{}`), jsonOr(s.Weapons, `[]`), s.ShieldName, s.EngineName, jsonOr(s.Modules, `[]`),
+			jsonOr(s.Cargo, `[]`), jsonOr(s.ShipResources, `{}`), s.HullRatio, s.ShieldRatio,
+			s.SquadronID, s.SquadronRole, s.DeployedAt, s.DestroyedAt,
+		}
+		key := s.PlayerID + "/" + strconv.Itoa(s.FleetIndex)
+		if i, ok := slots[key]; ok {
+			rows[i] = row
+			continue
+		}
+		slots[key] = len(rows)
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
 		return nil
 	}
 
@@ -94,116 +129,101 @@ func (r *FleetRepository) BulkUpsertFleetShips(ctx context.Context, ships []mode
 	}
 	defer tx.Rollback(ctx)
 
-	now := time.Now()
-	for _, s := range ships {
-		if s.ShipID == "" {
-			continue // Empty slot — skip
-		}
-		cmdParams := s.CommandParams
-		if cmdParams == nil {
-			cmdParams = json.RawMessage(`{}`)
-		}
-		weapons := s.Weapons
-		if weapons == nil {
-			weapons = json.RawMessage(`[]`)
-		}
-		modules := s.Modules
-		if modules == nil {
-			modules = json.RawMessage(`[]`)
-		}
-		cargo := s.Cargo
-		if cargo == nil {
-			cargo = json.RawMessage(`[]`)
-		}
-		resources := s.ShipResources
-		if resources == nil {
-			resources = json.RawMessage(`{}`)
-		}
+	_, err = tx.Exec(ctx, `
+		CREATE TEMP TABLE fleet_ships_stage ON COMMIT DROP AS
+		SELECT `+strings.Join(fleetStageColumns, ", ")+` FROM fleet_ships WITH NO DATA
+	`)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"fleet_ships_stage"}, fleetStageColumns, pgx.CopyFromRows(rows)); err != nil {
+		return err
+	}
 
-		_, err := tx.Exec(ctx, `
-			INSERT INTO fleet_ships (
-				player_id, fleet_index, ship_id, custom_name, deployment_state,
-				system_id, station_id, pos_x, pos_y, pos_z,
-				command, command_params, weapons, shield_name, engine_name, modules,
-				cargo, ship_resources, hull_ratio, shield_ratio,
-				squadron_id, squadron_role, deployed_at, destroyed_at, updated_at
-			) VALUES (
-				$1, $2, $3, $4, $5,
-				$6, $7, $8, $9, $10,
-				$11, $12, $13, $14, $15, $16,
-				$17, $18, $19, $20,
-				$21, $22, $23, $24, $25
-			)
-			ON CONFLICT (player_id, fleet_index) DO UPDATE SET
-				ship_id = EXCLUDED.ship_id,
-				custom_name = EXCLUDED.custom_name,
-				deployment_state = EXCLUDED.deployment_state,
-				system_id = EXCLUDED.system_id,
-				station_id = EXCLUDED.station_id,
-				pos_x = EXCLUDED.pos_x,
-				pos_y = EXCLUDED.pos_y,
-				pos_z = EXCLUDED.pos_z,
-				command = EXCLUDED.command,
-				command_params = EXCLUDED.command_params,
-				weapons = EXCLUDED.weapons,
-				shield_name = EXCLUDED.shield_name,
-				engine_name = EXCLUDED.engine_name,
-				modules = EXCLUDED.modules,
-				cargo = EXCLUDED.cargo,
-				ship_resources = EXCLUDED.ship_resources,
-				hull_ratio = EXCLUDED.hull_ratio,
-				shield_ratio = EXCLUDED.shield_ratio,
-				squadron_id = EXCLUDED.squadron_id,
-				squadron_role = EXCLUDED.squadron_role,
-				deployed_at = EXCLUDED.deployed_at,
-				destroyed_at = EXCLUDED.destroyed_at,
-				updated_at = EXCLUDED.updated_at
-		`, s.PlayerID, s.FleetIndex, s.ShipID, s.CustomName, s.DeploymentState,
-			s.SystemID, s.StationID, s.PosX, s.PosY, s.PosZ,
-			s.Command, cmdParams, weapons, s.ShieldName, s.EngineName, modules,
-			cargo, resources, s.HullRatio, s.ShieldRatio,
-			s.SquadronID, s.SquadronRole, s.DeployedAt, s.DestroyedAt, now)
-		if err != nil {
-			return err
-		}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO fleet_ships (`+strings.Join(fleetStageColumns, ", ")+`, updated_at, updated_by_server_id)
+		SELECT `+strings.Join(fleetStageColumns, ", ")+`, NOW(), $1 FROM fleet_ships_stage
+		ON CONFLICT (player_id, fleet_index) DO UPDATE SET
+			ship_id = EXCLUDED.ship_id,
+			custom_name = EXCLUDED.custom_name,
+			deployment_state = EXCLUDED.deployment_state,
+			system_id = EXCLUDED.system_id,
+			station_id = EXCLUDED.station_id,
+			pos_x = EXCLUDED.pos_x,
+			pos_y = EXCLUDED.pos_y,
+			pos_z = EXCLUDED.pos_z,
+			command = EXCLUDED.command,
+			command_params = EXCLUDED.command_params,
+			weapons = EXCLUDED.weapons,
+			shield_name = EXCLUDED.shield_name,
+			engine_name = EXCLUDED.engine_name,
+			modules = EXCLUDED.modules,
+			cargo = EXCLUDED.cargo,
+			ship_resources = EXCLUDED.ship_resources,
+			hull_ratio = EXCLUDED.hull_ratio,
+			shield_ratio = EXCLUDED.shield_ratio,
+			squadron_id = EXCLUDED.squadron_id,
+			squadron_role = EXCLUDED.squadron_role,
+			deployed_at = EXCLUDED.deployed_at,
+			destroyed_at = EXCLUDED.destroyed_at,
+			updated_at = EXCLUDED.updated_at,
+			updated_by_server_id = EXCLUDED.updated_by_server_id
+	`, serverID)
+	if err != nil {
+		return err
 	}
 
 	return tx.Commit(ctx)
 }
 
-// BatchUpdatePositions updates positions and health for multiple deployed ships.
-func (r *FleetRepository) BatchUpdatePositions(ctx context.Context, updates []model.FleetSyncUpdate) error {
+// BatchUpdatePositions updates positions and health for multiple deployed ships in a
+// single statement. An empty command or command_params keeps the current one.
+func (r *FleetRepository) BatchUpdatePositions(ctx context.Context, updates []model.FleetSyncUpdate, serverID *string) error {
 	if len(updates) == 0 {
 		return nil
 	}
 
-	tx, err := r.pool.Begin(ctx)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback(ctx)
-
-	now := time.Now()
+	// A row may only be updated once per statement: keep the last update of each ship
+	slots := make(map[string]int, len(updates))
+	var (
+		playerIDs           []string
+		indexes             []int32
+		xs, ys, zs          []float64
+		hulls, shields      []float32
+		commands, cmdParams []string
+	)
 	for _, u := range updates {
-		cmdParams := u.CommandParams
-		if len(cmdParams) == 0 {
-			cmdParams = json.RawMessage(`{}`)
+		params := `{}`
+		if len(u.CommandParams) > 0 {
+			params = string(u.CommandParams)
 		}
-		_, err := tx.Exec(ctx, `
-			UPDATE fleet_ships SET
-				pos_x = $3, pos_y = $4, pos_z = $5,
-				hull_ratio = $6, shield_ratio = $7,
-				command = CASE WHEN $8 != '' THEN $8 ELSE command END,
-				command_params = CASE WHEN $10::jsonb != '{}'::jsonb THEN $10 ELSE command_params END,
-				updated_at = $9
-			WHERE player_id = $1 AND fleet_index = $2 AND deployment_state = 1
-		`, u.PlayerID, u.FleetIndex, u.PosX, u.PosY, u.PosZ, u.HullRatio, u.ShieldRatio, u.Command, now, cmdParams)
-		if err != nil {
-			return err
+		key := u.PlayerID + "/" + strconv.Itoa(u.FleetIndex)
+		if i, ok := slots[key]; ok {
+			xs[i], ys[i], zs[i] = u.PosX, u.PosY, u.PosZ
+			hulls[i], shields[i] = u.HullRatio, u.ShieldRatio
+			commands[i], cmdParams[i] = u.Command, params
+			continue
 		}
+		slots[key] = len(playerIDs)
+		playerIDs = append(playerIDs, u.PlayerID)
+		indexes = append(indexes, int32(u.FleetIndex))
+		xs, ys, zs = append(xs, u.PosX), append(ys, u.PosY), append(zs, u.PosZ)
+		hulls, shields = append(hulls, u.HullRatio), append(shields, u.ShieldRatio)
+		commands, cmdParams = append(commands, u.Command), append(cmdParams, params)
 	}
 
-	return tx.Commit(ctx)
+	_, err := r.pool.Exec(ctx, `
+		UPDATE fleet_ships f SET
+			pos_x = u.pos_x, pos_y = u.pos_y, pos_z = u.pos_z,
+			hull_ratio = u.hull_ratio, shield_ratio = u.shield_ratio,
+			command = CASE WHEN u.command <> '' THEN u.command ELSE f.command END,
+			command_params = CASE WHEN u.command_params::jsonb <> '{}'::jsonb THEN u.command_params::jsonb ELSE f.command_params END,
+			updated_at = NOW(), updated_by_server_id = $10
+		FROM unnest($1::text[], $2::int[], $3::float8[], $4::float8[], $5::float8[], $6::real[], $7::real[], $8::text[], $9::text[])
+			AS u(player_id, fleet_index, pos_x, pos_y, pos_z, hull_ratio, shield_ratio, command, command_params)
+		WHERE f.player_id = u.player_id::uuid AND f.fleet_index = u.fleet_index AND f.deployment_state = 1
+	`, playerIDs, indexes, xs, ys, zs, hulls, shields, commands, cmdParams, serverID)
+	return err
 }
 
 // MarkDestroyed permanently deletes a fleet ship row (ship is irrecoverably lost).
@@ -213,3 +233,17 @@ func (r *FleetRepository) MarkDestroyed(ctx context.Context, playerID string, fl
 	`, playerID, fleetIndex)
 	return err
 }
+
+// DeletePlayerFleetTx removes all fleet ships of a player (account deletion).
+func (r *FleetRepository) DeletePlayerFleetTx(ctx context.Context, tx pgx.Tx, playerID string) error {
+	_, err := tx.Exec(ctx, `DELETE FROM fleet_ships WHERE player_id = $1`, playerID)
+	return err
+}
+
+// jsonOr returns raw, or def when raw is empty.
+func jsonOr(raw json.RawMessage, def string) json.RawMessage {
+	if len(raw) == 0 {
+		return json.RawMessage(def)
+	}
+	return raw
+}
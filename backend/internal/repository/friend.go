@@ -0,0 +1,291 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"spacegame-backend/internal/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// FriendRepository stores the social graph (player_relations): friend requests,
+// friendships (one row per direction) and blocks.
+type FriendRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewFriendRepository(pool *pgxpool.Pool) *FriendRepository {
+	return &FriendRepository{pool: pool}
+}
+
+// Relations returns the status of playerID -> otherID and of otherID -> playerID,
+// "" when there is no row.
+func (r *FriendRepository) Relations(ctx context.Context, playerID, otherID string) (string, string, error) {
+	var out, in string
+	err := r.pool.QueryRow(ctx, `
+		SELECT
+			COALESCE(MAX(status) FILTER (WHERE player_id = $1), ''),
+			COALESCE(MAX(status) FILTER (WHERE player_id = $2), '')
+		FROM player_relations
+		WHERE (player_id = $1 AND other_id = $2) OR (player_id = $2 AND other_id = $1)
+	`, playerID, otherID).Scan(&out, &in)
+	return out, in, err
+}
+
+// CreateRequest records a pending friend request.
+func (r *FriendRepository) CreateRequest(ctx context.Context, fromID, toID string) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO player_relations (player_id, other_id, status) VALUES ($1, $2, 'pending')
+	`, fromID, toID)
+	return err
+}
+
+// Accept turns the pending request fromID -> toID into a friendship. Returns false when
+// there is no such request.
+func (r *FriendRepository) Accept(ctx context.Context, fromID, toID string) (bool, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE player_relations SET status = 'friend', created_at = NOW()
+		WHERE player_id = $1 AND other_id = $2 AND status = 'pending'
+	`, fromID, toID)
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO player_relations (player_id, other_id, status) VALUES ($1, $2, 'friend')
+		ON CONFLICT (player_id, other_id) DO UPDATE SET status = 'friend', created_at = NOW()
+	`, toID, fromID)
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit(ctx)
+}
+
+// DeleteRequest removes the pending request fromID -> toID.
+func (r *FriendRepository) DeleteRequest(ctx context.Context, fromID, toID string) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		DELETE FROM player_relations WHERE player_id = $1 AND other_id = $2 AND status = 'pending'
+	`, fromID, toID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// Remove ends a friendship, or cancels a request in either direction.
+func (r *FriendRepository) Remove(ctx context.Context, playerID, otherID string) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		DELETE FROM player_relations
+		WHERE ((player_id = $1 AND other_id = $2) OR (player_id = $2 AND other_id = $1))
+		  AND status IN ('pending', 'friend')
+	`, playerID, otherID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// Block makes playerID block otherID, dropping any friendship or request between them
+// (a block by otherID is kept).
+func (r *FriendRepository) Block(ctx context.Context, playerID, otherID string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		DELETE FROM player_relations
+		WHERE ((player_id = $1 AND other_id = $2) OR (player_id = $2 AND other_id = $1))
+		  AND status <> 'blocked'
+	`, playerID, otherID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO player_relations (player_id, other_id, status) VALUES ($1, $2, 'blocked')
+		ON CONFLICT (player_id, other_id) DO NOTHING
+	`, playerID, otherID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// Unblock removes playerID's block of otherID.
+func (r *FriendRepository) Unblock(ctx context.Context, playerID, otherID string) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		DELETE FROM player_relations WHERE player_id = $1 AND other_id = $2 AND status = 'blocked'
+	`, playerID, otherID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// Blocked reports whether either player blocked the other.
+func (r *FriendRepository) Blocked(ctx context.Context, playerID, otherID string) (bool, error) {
+	var blocked bool
+	err := r.pool.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM player_relations
+			WHERE ((player_id = $1 AND other_id = $2) OR (player_id = $2 AND other_id = $1))
+			  AND status = 'blocked'
+		)
+	`, playerID, otherID).Scan(&blocked)
+	return blocked, err
+}
+
+// BlockedByMembers reports whether a member of the corporation with at least minRank
+// blocked the player.
+func (r *FriendRepository) BlockedByMembers(ctx context.Context, corporationID, playerID string, minRank int) (bool, error) {
+	var blocked bool
+	err := r.pool.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM player_relations pr
+			JOIN corporation_members cm ON cm.player_id = pr.player_id
+			WHERE cm.corporation_id = $1 AND cm.rank_priority >= $3
+			  AND pr.other_id = $2 AND pr.status = 'blocked'
+		)
+	`, corporationID, playerID, minRank).Scan(&blocked)
+	return blocked, err
+}
+
+// BlocksOf returns the players each of playerIDs blocked. Players without blocks are
+// absent from the map.
+func (r *FriendRepository) BlocksOf(ctx context.Context, playerIDs []string) (map[string][]model.Contact, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT pr.player_id, p.id, p.username, pr.created_at
+		FROM player_relations pr
+		JOIN players p ON p.id = pr.other_id
+		WHERE pr.player_id = ANY($1::uuid[]) AND pr.status = 'blocked'
+		ORDER BY pr.player_id, pr.created_at
+	`, playerIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	blocks := map[string][]model.Contact{}
+	for rows.Next() {
+		var playerID string
+		var c model.Contact
+		if err := rows.Scan(&playerID, &c.PlayerID, &c.Username, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		blocks[playerID] = append(blocks[playerID], c)
+	}
+	return blocks, rows.Err()
+}
+
+// CountFriends returns how many friends the player has.
+func (r *FriendRepository) CountFriends(ctx context.Context, playerID string) (int, error) {
+	var n int
+	err := r.pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM player_relations WHERE player_id = $1 AND status = 'friend'
+	`, playerID).Scan(&n)
+	return n, err
+}
+
+// FriendIDs returns the IDs of the player's friends.
+func (r *FriendRepository) FriendIDs(ctx context.Context, playerID string) ([]string, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT other_id FROM player_relations WHERE player_id = $1 AND status = 'friend'
+	`, playerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// DeleteForPlayerTx removes every relation of the player (account deletion).
+func (r *FriendRepository) DeleteForPlayerTx(ctx context.Context, tx pgx.Tx, playerID string) error {
+	_, err := tx.Exec(ctx, `DELETE FROM player_relations WHERE player_id = $1 OR other_id = $1`, playerID)
+	return err
+}
+
+// ListFriends returns the player's friends by name. Their presence is in_system when a
+// game server reported them after seenSince, offline otherwise.
+func (r *FriendRepository) ListFriends(ctx context.Context, playerID string, seenSince time.Time) ([]model.Friend, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT p.id, p.username, COALESCE(c.corporation_tag, ''), pr.created_at,
+		       COALESCE(p.last_seen_at, p.last_login_at),
+		       p.last_seen_at IS NOT NULL AND p.last_seen_at > $2, p.system_id
+		FROM player_relations pr
+		JOIN players p ON p.id = pr.other_id
+		LEFT JOIN corporations c ON c.id = p.corporation_id
+		WHERE pr.player_id = $1 AND pr.status = 'friend'
+		ORDER BY LOWER(p.username)
+	`, playerID, seenSince)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	friends := []model.Friend{}
+	for rows.Next() {
+		var f model.Friend
+		var inGame bool
+		var systemID int
+		if err := rows.Scan(&f.PlayerID, &f.Username, &f.CorporationTag, &f.Since, &f.Presence.LastSeenAt, &inGame, &systemID); err != nil {
+			return nil, err
+		}
+		f.Presence.Status = model.PresenceOffline
+		if inGame {
+			f.Presence.Status = model.PresenceInSystem
+			f.Presence.SystemID = &systemID
+		}
+		friends = append(friends, f)
+	}
+	return friends, rows.Err()
+}
+
+// ListContacts returns the other players of the player's relations with status:
+// outgoing (player -> other) or incoming (other -> player), newest first.
+func (r *FriendRepository) ListContacts(ctx context.Context, playerID, status string, incoming bool) ([]model.Contact, error) {
+	self, other := "player_id", "other_id"
+	if incoming {
+		self, other = other, self
+	}
+	rows, err := r.pool.Query(ctx, `
+		SELECT p.id, p.username, pr.created_at
+		FROM player_relations pr
+		JOIN players p ON p.id = pr.`+other+`
+		WHERE pr.`+self+` = $1 AND pr.status = $2
+		ORDER BY pr.created_at DESC
+	`, playerID, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	contacts := []model.Contact{}
+	for rows.Next() {
+		var c model.Contact
+		if err := rows.Scan(&c.PlayerID, &c.Username, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		contacts = append(contacts, c)
+	}
+	return contacts, rows.Err()
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"spacegame-backend/internal/model"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type GameServerRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewGameServerRepository(pool *pgxpool.Pool) *GameServerRepository {
+	return &GameServerRepository{pool: pool}
+}
+
+const gameServerColumns = `id, name, secret, created_at, last_seen_at, revoked_at`
+
+// Create registers a game server.
+func (r *GameServerRepository) Create(ctx context.Context, name, secret string) (*model.GameServer, error) {
+	var s model.GameServer
+	err := r.pool.QueryRow(ctx, `
+		INSERT INTO game_servers (name, secret) VALUES ($1, $2)
+		RETURNING `+gameServerColumns, name, secret,
+	).Scan(&s.ID, &s.Name, &s.Secret, &s.CreatedAt, &s.LastSeenAt, &s.RevokedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// List returns every registered server, revoked ones included.
+func (r *GameServerRepository) List(ctx context.Context) ([]model.GameServer, error) {
+	rows, err := r.pool.Query(ctx, `SELECT `+gameServerColumns+` FROM game_servers ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	servers := []model.GameServer{}
+	for rows.Next() {
+		var s model.GameServer
+		if err := rows.Scan(&s.ID, &s.Name, &s.Secret, &s.CreatedAt, &s.LastSeenAt, &s.RevokedAt); err != nil {
+			return nil, err
+		}
+		servers = append(servers, s)
+	}
+	return servers, rows.Err()
+}
+
+// Revoke disables a server. Returns false if it doesn't exist or is already revoked.
+func (r *GameServerRepository) Revoke(ctx context.Context, id string) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE game_servers SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL
+	`, id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// SetSecret replaces the secret of an active server. Returns nil if none matched.
+func (r *GameServerRepository) SetSecret(ctx context.Context, id, secret string) (*model.GameServer, error) {
+	var s model.GameServer
+	err := r.pool.QueryRow(ctx, `
+		UPDATE game_servers SET secret = $2 WHERE id = $1 AND revoked_at IS NULL
+		RETURNING `+gameServerColumns, id, secret,
+	).Scan(&s.ID, &s.Name, &s.Secret, &s.CreatedAt, &s.LastSeenAt, &s.RevokedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Touch records that a server made a request.
+func (r *GameServerRepository) Touch(ctx context.Context, id string) error {
+	_, err := r.pool.Exec(ctx, `UPDATE game_servers SET last_seen_at = NOW() WHERE id = $1`, id)
+	return err
+}
+
+// UseNonce stores a request nonce. Returns false if the server already used it.
+func (r *GameServerRepository) UseNonce(ctx context.Context, serverID, nonce string) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		INSERT INTO game_server_nonces (server_id, nonce) VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`, serverID, nonce)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// DeleteNoncesOlderThan purges nonces that fell out of the timestamp window.
+func (r *GameServerRepository) DeleteNoncesOlderThan(ctx context.Context, age time.Duration) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `
+		DELETE FROM game_server_nonces WHERE created_at < $1
+	`, time.Now().Add(-age))
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
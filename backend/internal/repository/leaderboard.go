@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"spacegame-backend/internal/model"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// kdMinFights is how many kills + deaths a player needs to appear on the K/D board.
+const kdMinFights = 10
+
+// Ranked players must not be banned or deleted; c is their corporation (for the tag).
+const leaderboardPlayerJoin = `
+	LEFT JOIN corporations c ON c.id = p.corporation_id
+	WHERE p.deleted_at IS NULL AND NOT p.is_banned`
+
+// leaderboardSource computes a board as (id, name, tag, value) rows. allTime takes no
+// parameter; window takes the start of the period as $4 and is empty when the board
+// only exists for all time.
+type leaderboardSource struct {
+	allTime string
+	window  string
+}
+
+var leaderboardSources = map[string]leaderboardSource{
+	model.LeaderboardKills: {
+		allTime: `
+			SELECT p.id, p.username, COALESCE(c.corporation_tag, ''), p.kills::float8
+			FROM players p` + leaderboardPlayerJoin + ` AND p.kills > 0`,
+		window: `
+			SELECT p.id, p.username, COALESCE(c.corporation_tag, ''), COUNT(*)::float8
+			FROM game_events e
+			JOIN players p ON p.username = e.actor_name` + leaderboardPlayerJoin + `
+			  AND e.event_type = 'kill' AND e.created_at >= $4
+			GROUP BY p.id, p.username, c.corporation_tag`,
+	},
+	model.LeaderboardKD: {
+		allTime: `
+			SELECT p.id, p.username, COALESCE(c.corporation_tag, ''),
+			       ROUND(p.kills::numeric / GREATEST(p.deaths, 1), 2)::float8
+			FROM players p` + leaderboardPlayerJoin + `
+			  AND p.kills > 0 AND p.kills + p.deaths >= ` + strconv.Itoa(kdMinFights),
+		window: `
+			SELECT p.id, p.username, COALESCE(c.corporation_tag, ''),
+			       ROUND(f.kills::numeric / GREATEST(f.deaths, 1), 2)::float8
+			FROM (
+				SELECT name, COUNT(*) FILTER (WHERE is_kill) AS kills, COUNT(*) FILTER (WHERE NOT is_kill) AS deaths
+				FROM (
+					SELECT actor_name AS name, TRUE AS is_kill FROM game_events WHERE event_type = 'kill' AND created_at >= $4
+					UNION ALL
+					SELECT target_name, FALSE FROM game_events WHERE event_type = 'kill' AND created_at >= $4
+				) k
+				GROUP BY name
+			) f
+			JOIN players p ON p.username = f.name` + leaderboardPlayerJoin + `
+			  AND f.kills > 0 AND f.kills + f.deaths >= ` + strconv.Itoa(kdMinFights),
+	},
+	model.LeaderboardNetWorth: {
+		allTime: `
+			SELECT p.id, p.username, COALESCE(c.corporation_tag, ''), (p.credits + COALESCE(l.listed, 0))::float8
+			FROM players p
+			LEFT JOIN (
+				SELECT seller_id, SUM(unit_price * quantity) AS listed
+				FROM market_listings WHERE status = 'active' GROUP BY seller_id
+			) l ON l.seller_id = p.id` + leaderboardPlayerJoin + `
+			  AND p.credits + COALESCE(l.listed, 0) > 0`,
+	},
+	model.LeaderboardMarketVolume: {
+		allTime: marketVolumeSource(""),
+		window:  marketVolumeSource(" AND sold_at >= $4"),
+	},
+	model.LeaderboardCorpMembers: {
+		allTime: `
+			SELECT co.id, co.corporation_name, co.corporation_tag, COUNT(*)::float8
+			FROM corporations co
+			JOIN corporation_members cm ON cm.corporation_id = co.id
+			GROUP BY co.id, co.corporation_name, co.corporation_tag`,
+	},
+	model.LeaderboardCorpTreasury: {
+		allTime: `
+			SELECT co.id, co.corporation_name, co.corporation_tag, co.treasury::float8
+			FROM corporations co WHERE co.treasury > 0`,
+	},
+}
+
+// marketVolumeSource sums the value of sold listings for both the seller and the buyer.
+func marketVolumeSource(filter string) string {
+	return `
+		SELECT p.id, p.username, COALESCE(c.corporation_tag, ''), SUM(v.amount)::float8
+		FROM (
+			SELECT seller_id AS player_id, unit_price * quantity AS amount
+			FROM market_listings WHERE status = 'sold'` + filter + `
+			UNION ALL
+			SELECT sold_to_id, unit_price * quantity
+			FROM market_listings WHERE status = 'sold'` + filter + `
+		) v
+		JOIN players p ON p.id = v.player_id` + leaderboardPlayerJoin + `
+		GROUP BY p.id, p.username, c.corporation_tag`
+}
+
+type LeaderboardRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewLeaderboardRepository(pool *pgxpool.Pool) *LeaderboardRepository {
+	return &LeaderboardRepository{pool: pool}
+}
+
+// Recompute replaces a board's ranking for a period with its top size entries.
+// since is the start of the period, nil for all time.
+func (r *LeaderboardRepository) Recompute(ctx context.Context, board, period string, since *time.Time, size int) (int64, error) {
+	src, ok := leaderboardSources[board]
+	if !ok {
+		return 0, fmt.Errorf("unknown leaderboard %q", board)
+	}
+	query, args := src.allTime, []any{board, period, size}
+	if since != nil {
+		if src.window == "" {
+			return 0, fmt.Errorf("leaderboard %q has no period %q", board, period)
+		}
+		query, args = src.window, append(args, *since)
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM leaderboard_entries WHERE board = $1 AND period = $2`, board, period); err != nil {
+		return 0, err
+	}
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO leaderboard_entries (board, period, rank, subject_id, subject_name, subject_tag, value, computed_at)
+		SELECT $1, $2, ROW_NUMBER() OVER (ORDER BY s.value DESC, s.name), s.id, s.name, s.tag, s.value, NOW()
+		FROM (`+query+`) AS s(id, name, tag, value)
+		ORDER BY s.value DESC, s.name
+		LIMIT $3
+	`, args...)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), tx.Commit(ctx)
+}
+
+// Get returns a page of a board, best first, with its size and when it was computed.
+func (r *LeaderboardRepository) Get(ctx context.Context, board, period string, limit, offset int) (*model.Leaderboard, error) {
+	lb := &model.Leaderboard{Board: board, Period: period, Entries: []model.LeaderboardEntry{}}
+	err := r.pool.QueryRow(ctx, `
+		SELECT COUNT(*), MAX(computed_at) FROM leaderboard_entries WHERE board = $1 AND period = $2
+	`, board, period).Scan(&lb.Total, &lb.ComputedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT rank, subject_id, subject_name, subject_tag, value
+		FROM leaderboard_entries
+		WHERE board = $1 AND period = $2
+		ORDER BY rank
+		LIMIT $3 OFFSET $4
+	`, board, period, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e model.LeaderboardEntry
+		if err := rows.Scan(&e.Rank, &e.ID, &e.Name, &e.Tag, &e.Value); err != nil {
+			return nil, err
+		}
+		lb.Entries = append(lb.Entries, e)
+	}
+	return lb, rows.Err()
+}
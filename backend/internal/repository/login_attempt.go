@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"spacegame-backend/internal/model"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type LoginAttemptRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewLoginAttemptRepository(pool *pgxpool.Pool) *LoginAttemptRepository {
+	return &LoginAttemptRepository{pool: pool}
+}
+
+// Record logs a login attempt. playerID is nil when the username doesn't exist.
+func (r *LoginAttemptRepository) Record(ctx context.Context, playerID *string, username, ipAddress string, success bool) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO login_attempts (player_id, username, ip_address, success)
+		VALUES ($1, $2, $3, $4)
+	`, playerID, username, ipAddress, success)
+	return err
+}
+
+// GetLockState returns the account's consecutive failures and lockout.
+func (r *LoginAttemptRepository) GetLockState(ctx context.Context, playerID string) (*model.LoginLockState, error) {
+	st := &model.LoginLockState{}
+	err := r.pool.QueryRow(ctx, `
+		SELECT failed_login_count, last_failed_login_at, locked_until FROM players WHERE id = $1
+	`, playerID).Scan(&st.FailedCount, &st.LastFailedAt, &st.LockedUntil)
+	if err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// Claim reserves the account for one login attempt if it isn't locked, its failures are
+// still failedCount, the delay since the last one has elapsed and no other attempt holds
+// it (claims older than ttl are abandoned). Returns false if it can't be claimed now.
+func (r *LoginAttemptRepository) Claim(ctx context.Context, playerID string, failedCount int, delay, ttl time.Duration) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE players SET login_claimed_at = NOW()
+		WHERE id = $1 AND failed_login_count = $2
+		  AND (locked_until IS NULL OR locked_until <= NOW())
+		  AND (last_failed_login_at IS NULL OR last_failed_login_at + make_interval(secs => $3) <= NOW())
+		  AND (login_claimed_at IS NULL OR login_claimed_at <= NOW() - make_interval(secs => $4))
+	`, playerID, failedCount, delay.Seconds(), ttl.Seconds())
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// Release ends a claimed attempt that neither failed nor logged in (password right,
+// second factor pending).
+func (r *LoginAttemptRepository) Release(ctx context.Context, playerID string) error {
+	_, err := r.pool.Exec(ctx, `UPDATE players SET login_claimed_at = NULL WHERE id = $1`, playerID)
+	return err
+}
+
+// AddFailure increments the account's consecutive failures, ends the claimed attempt and
+// returns the new count.
+func (r *LoginAttemptRepository) AddFailure(ctx context.Context, playerID string) (int, error) {
+	var count int
+	err := r.pool.QueryRow(ctx, `
+		UPDATE players SET failed_login_count = failed_login_count + 1, last_failed_login_at = NOW(),
+			login_claimed_at = NULL
+		WHERE id = $1
+		RETURNING failed_login_count
+	`, playerID).Scan(&count)
+	return count, err
+}
+
+// Lock locks the account until the given time.
+func (r *LoginAttemptRepository) Lock(ctx context.Context, playerID string, until time.Time) error {
+	_, err := r.pool.Exec(ctx, `UPDATE players SET locked_until = $2 WHERE id = $1`, playerID, until)
+	return err
+}
+
+// Reset clears failures and any lockout (successful login or admin unlock).
+// Returns false if the player doesn't exist.
+func (r *LoginAttemptRepository) Reset(ctx context.Context, playerID string) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE players SET failed_login_count = 0, last_failed_login_at = NULL, locked_until = NULL,
+			login_claimed_at = NULL
+		WHERE id = $1
+	`, playerID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// CountRecentIPFailures returns the failed attempts from an IP since the given time,
+// and when the oldest of them happened.
+func (r *LoginAttemptRepository) CountRecentIPFailures(ctx context.Context, ipAddress string, since time.Time) (int, *time.Time, error) {
+	var count int
+	var oldest *time.Time
+	err := r.pool.QueryRow(ctx, `
+		SELECT COUNT(*), MIN(created_at) FROM login_attempts
+		WHERE ip_address = $1 AND success = FALSE AND created_at > $2
+	`, ipAddress, since).Scan(&count, &oldest)
+	return count, oldest, err
+}
+
+// ListForPlayer returns the most recent attempts on an account (all of them when limit is 0).
+func (r *LoginAttemptRepository) ListForPlayer(ctx context.Context, playerID string, limit int) ([]model.LoginAttempt, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, username, ip_address, success, created_at
+		FROM login_attempts WHERE player_id = $1
+		ORDER BY created_at DESC
+		LIMIT NULLIF($2, 0)
+	`, playerID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attempts []model.LoginAttempt
+	for rows.Next() {
+		var a model.LoginAttempt
+		if err := rows.Scan(&a.ID, &a.Username, &a.IPAddress, &a.Success, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, a)
+	}
+	return attempts, rows.Err()
+}
+
+// DeleteOlderThan removes attempts older than N days.
+func (r *LoginAttemptRepository) DeleteOlderThan(ctx context.Context, days int) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `
+		DELETE FROM login_attempts WHERE created_at < NOW() - make_interval(days => $1)
+	`, days)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,387 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"spacegame-backend/internal/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrNothingToClaim is returned when a mail has no attachments left.
+var ErrNothingToClaim = errors.New("nothing to claim")
+
+const mailColumns = `
+	id, recipient_id, sender_id, sender_name, corporation_id, subject, body, credits,
+	read_at, claimed_at, created_at, expires_at`
+
+// mailHasAttachments matches mail m with credits or items left to claim.
+const mailHasAttachments = `m.claimed_at IS NULL AND (m.credits > 0 OR EXISTS (SELECT 1 FROM mail_items i WHERE i.mail_id = m.id))`
+
+// MailRepository stores in-game mail and the attachments held in escrow.
+type MailRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewMailRepository(pool *pgxpool.Pool) *MailRepository {
+	return &MailRepository{pool: pool}
+}
+
+func scanMail(row pgx.Row) (*model.Mail, error) {
+	m := &model.Mail{Items: []model.TradeItem{}}
+	err := row.Scan(
+		&m.ID, &m.RecipientID, &m.SenderID, &m.SenderName, &m.CorporationID, &m.Subject, &m.Body, &m.Credits,
+		&m.ReadAt, &m.ClaimedAt, &m.CreatedAt, &m.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// loadMailItems fills in the attached items of the mails.
+func loadMailItems(ctx context.Context, db dbtx, mails ...*model.Mail) error {
+	if len(mails) == 0 {
+		return nil
+	}
+	byID := make(map[int64]*model.Mail, len(mails))
+	ids := make([]int64, len(mails))
+	for i, m := range mails {
+		byID[m.ID] = m
+		ids[i] = m.ID
+	}
+
+	rows, err := db.Query(ctx, `
+		SELECT mail_id, source, category, item_name, item_type, icon_color, quantity
+		FROM mail_items
+		WHERE mail_id = ANY($1)
+		ORDER BY id
+	`, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var mailID int64
+		var item model.TradeItem
+		if err := rows.Scan(&mailID, &item.Source, &item.Category, &item.ItemName, &item.ItemType, &item.IconColor, &item.Quantity); err != nil {
+			return err
+		}
+		m := byID[mailID]
+		m.Items = append(m.Items, item)
+	}
+	return rows.Err()
+}
+
+// insertMailTx stores a mail and its items, and sets its ID and creation time. The
+// attachments must already have been taken from whoever pays for them.
+func insertMailTx(ctx context.Context, tx pgx.Tx, m *model.Mail) error {
+	err := tx.QueryRow(ctx, `
+		INSERT INTO mail (recipient_id, sender_id, sender_name, corporation_id, subject, body, credits, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`, m.RecipientID, m.SenderID, m.SenderName, m.CorporationID, m.Subject, m.Body, m.Credits, m.ExpiresAt).Scan(&m.ID, &m.CreatedAt)
+	if err != nil {
+		return err
+	}
+	if m.Items == nil {
+		m.Items = []model.TradeItem{}
+	}
+	for _, item := range m.Items {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO mail_items (mail_id, source, category, item_name, item_type, icon_color, quantity)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`, m.ID, item.Source, item.Category, item.ItemName, item.ItemType, item.IconColor, item.Quantity)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Send stores a player's mail and takes its attachments from them in the same
+// transaction (ErrInsufficientCredits / ErrInsufficientItems when they can't pay).
+// Their save_version is bumped when anything was taken.
+func (r *MailRepository) Send(ctx context.Context, m *model.Mail) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for i := range m.Items {
+		if err := takeItemTx(ctx, tx, *m.SenderID, &m.Items[i]); err != nil {
+			return err
+		}
+	}
+	if err := insertMailTx(ctx, tx, m); err != nil {
+		return err
+	}
+	if m.Credits > 0 {
+		if _, err := addCreditsTx(ctx, tx, *m.SenderID, -m.Credits, model.CreditReasonMailSent, strconv.FormatInt(m.ID, 10)); err != nil {
+			return err
+		}
+	}
+	if m.HasAttachments() {
+		if _, err := tx.Exec(ctx, `UPDATE players SET save_version = save_version + 1 WHERE id = $1`, *m.SenderID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// SendMany stores mail without attachments in one transaction (corporation mail).
+func (r *MailRepository) SendMany(ctx context.Context, mails []*model.Mail) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for _, m := range mails {
+		if err := insertMailTx(ctx, tx, m); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *MailRepository) GetByID(ctx context.Context, id int64) (*model.Mail, error) {
+	m, err := scanMail(r.pool.QueryRow(ctx, `SELECT `+mailColumns+` FROM mail WHERE id = $1`, id))
+	if err != nil {
+		return nil, err
+	}
+	return m, loadMailItems(ctx, r.pool, m)
+}
+
+// ListInbox returns the player's mail, newest first. limit 0 returns all of it.
+func (r *MailRepository) ListInbox(ctx context.Context, recipientID string, unreadOnly bool, limit int) ([]model.Mail, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+mailColumns+`
+		FROM mail
+		WHERE recipient_id = $1 AND expires_at > NOW() AND (NOT $2 OR read_at IS NULL)
+		ORDER BY created_at DESC, id DESC
+		LIMIT NULLIF($3, 0)
+	`, recipientID, unreadOnly, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mails := []model.Mail{}
+	for rows.Next() {
+		m, err := scanMail(rows)
+		if err != nil {
+			return nil, err
+		}
+		mails = append(mails, *m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	ptrs := make([]*model.Mail, len(mails))
+	for i := range mails {
+		ptrs[i] = &mails[i]
+	}
+	return mails, loadMailItems(ctx, r.pool, ptrs...)
+}
+
+func (r *MailRepository) CountUnread(ctx context.Context, recipientID string) (int, error) {
+	var n int
+	err := r.pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM mail WHERE recipient_id = $1 AND read_at IS NULL AND expires_at > NOW()
+	`, recipientID).Scan(&n)
+	return n, err
+}
+
+func (r *MailRepository) MarkRead(ctx context.Context, id int64, recipientID string) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE mail SET read_at = NOW() WHERE id = $1 AND recipient_id = $2 AND read_at IS NULL
+	`, id, recipientID)
+	return err
+}
+
+// Claim gives the attachments of a mail to its recipient (ErrNothingToClaim if there
+// are none left) and returns the claimed mail.
+func (r *MailRepository) Claim(ctx context.Context, id int64, recipientID string) (*model.Mail, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	m, err := scanMail(tx.QueryRow(ctx, `
+		SELECT `+mailColumns+` FROM mail
+		WHERE id = $1 AND recipient_id = $2 AND expires_at > NOW()
+		FOR UPDATE
+	`, id, recipientID))
+	if err != nil {
+		return nil, err
+	}
+	if err := loadMailItems(ctx, tx, m); err != nil {
+		return nil, err
+	}
+	if !m.HasAttachments() {
+		return nil, ErrNothingToClaim
+	}
+	if err := deliverMailTx(ctx, tx, m); err != nil {
+		return nil, err
+	}
+	return m, tx.Commit(ctx)
+}
+
+// deliverMailTx gives the attachments to the recipient and marks the mail claimed (and
+// read). The recipient's save_version is bumped, like after a trade.
+func deliverMailTx(ctx context.Context, tx pgx.Tx, m *model.Mail) error {
+	if m.Credits > 0 {
+		if _, err := addCreditsTx(ctx, tx, m.RecipientID, m.Credits, model.CreditReasonMailClaimed, strconv.FormatInt(m.ID, 10)); err != nil {
+			return err
+		}
+	}
+	for _, item := range m.Items {
+		if err := giveItemTx(ctx, tx, m.RecipientID, item); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(ctx, `UPDATE players SET save_version = save_version + 1 WHERE id = $1`, m.RecipientID); err != nil {
+		return err
+	}
+	return tx.QueryRow(ctx, `
+		UPDATE mail SET claimed_at = NOW(), read_at = COALESCE(read_at, NOW()) WHERE id = $1
+		RETURNING claimed_at, read_at
+	`, m.ID).Scan(&m.ClaimedAt, &m.ReadAt)
+}
+
+// Delete removes a mail of the recipient unless it still has attachments to claim.
+func (r *MailRepository) Delete(ctx context.Context, id int64, recipientID string) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		DELETE FROM mail m WHERE m.id = $1 AND m.recipient_id = $2 AND NOT (`+mailHasAttachments+`)
+	`, id, recipientID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// ListExpiredWithAttachments returns up to limit expired mails whose attachments were
+// never claimed, oldest first.
+func (r *MailRepository) ListExpiredWithAttachments(ctx context.Context, limit int) ([]model.Mail, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+mailColumns+`
+		FROM mail m
+		WHERE m.expires_at <= NOW() AND `+mailHasAttachments+`
+		ORDER BY m.id
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mails := []model.Mail{}
+	for rows.Next() {
+		m, err := scanMail(rows)
+		if err != nil {
+			return nil, err
+		}
+		mails = append(mails, *m)
+	}
+	return mails, rows.Err()
+}
+
+// lockUnclaimedTx locks a mail with its items, or returns nil when it was claimed or
+// deleted in the meantime.
+func lockUnclaimedTx(ctx context.Context, tx pgx.Tx, id int64) (*model.Mail, error) {
+	m, err := scanMail(tx.QueryRow(ctx, `SELECT `+mailColumns+` FROM mail WHERE id = $1 AND claimed_at IS NULL FOR UPDATE`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return m, loadMailItems(ctx, tx, m)
+}
+
+// ReturnExpired moves the unclaimed attachments of an expired mail to ret (a mail to
+// its sender) and deletes it. Returns false when there was nothing left to return.
+func (r *MailRepository) ReturnExpired(ctx context.Context, id int64, ret *model.Mail) (bool, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	m, err := lockUnclaimedTx(ctx, tx, id)
+	if err != nil || m == nil {
+		return false, err
+	}
+	ret.Credits, ret.Items = m.Credits, nil
+	if err := insertMailTx(ctx, tx, ret); err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE mail_items SET mail_id = $2 WHERE mail_id = $1`, id, ret.ID); err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM mail WHERE id = $1`, id); err != nil {
+		return false, err
+	}
+	ret.Items = m.Items
+	return true, tx.Commit(ctx)
+}
+
+// DeliverExpired gives the unclaimed attachments of an expired mail with no sender to
+// return them to (system mail, deleted sender) to its recipient, then deletes it.
+func (r *MailRepository) DeliverExpired(ctx context.Context, id int64) (bool, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	m, err := lockUnclaimedTx(ctx, tx, id)
+	if err != nil || m == nil {
+		return false, err
+	}
+	if err := deliverMailTx(ctx, tx, m); err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM mail WHERE id = $1`, id); err != nil {
+		return false, err
+	}
+	return true, tx.Commit(ctx)
+}
+
+// DeleteExpired deletes the expired mail that has nothing left to claim.
+func (r *MailRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `
+		DELETE FROM mail m WHERE m.expires_at <= NOW() AND NOT (`+mailHasAttachments+`)
+	`)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// AnonymisePlayerTx deletes a deleted player's mail, except the mail from a player still
+// holding attachments: it is expired (and emptied) so the next expiry run returns them to
+// their senders. System mail goes with its attachments, which would otherwise be
+// delivered to the deleted account. Mail they sent becomes anonymous system mail.
+func (r *MailRepository) AnonymisePlayerTx(ctx context.Context, tx pgx.Tx, playerID, anonName string) error {
+	if _, err := tx.Exec(ctx, `
+		DELETE FROM mail m WHERE m.recipient_id = $1 AND (m.sender_id IS NULL OR NOT (`+mailHasAttachments+`))
+	`, playerID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE mail SET expires_at = NOW(), body = '' WHERE recipient_id = $1
+	`, playerID); err != nil {
+		return err
+	}
+	_, err := tx.Exec(ctx, `UPDATE mail SET sender_id = NULL, sender_name = $2 WHERE sender_id = $1`, playerID, anonName)
+	return err
+}
@@ -3,10 +3,12 @@ package repository
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"spacegame-backend/internal/model"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -18,8 +20,15 @@ func NewMarketRepository(pool *pgxpool.Pool) *MarketRepository {
 	return &MarketRepository{pool: pool}
 }
 
+// Create inserts a listing and debits its fee from the seller in the same transaction.
 func (r *MarketRepository) Create(ctx context.Context, listing *model.MarketListing) (*model.MarketListing, error) {
-	err := r.pool.QueryRow(ctx, `
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
 		INSERT INTO market_listings (
 			seller_id, seller_name, system_id, station_id, station_name,
 			item_category, item_id, item_name, quantity, unit_price,
@@ -34,6 +43,15 @@ func (r *MarketRepository) Create(ctx context.Context, listing *model.MarketList
 	if err != nil {
 		return nil, err
 	}
+
+	ref := strconv.FormatInt(listing.ID, 10)
+	if _, err := addCreditsTx(ctx, tx, listing.SellerID, -listing.ListingFee, model.CreditReasonMarketFee, ref); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
 	listing.Status = "active"
 	return listing, nil
 }
@@ -218,7 +236,10 @@ func (r *MarketRepository) GetBySellerID(ctx context.Context, sellerID string, s
 	return listings, nil
 }
 
-func (r *MarketRepository) Buy(ctx context.Context, listingID int64, buyerID string, buyerName string) (*model.MarketListing, error) {
+// Buy debits the buyer, credits the seller and marks the listing sold. The seller's
+// save_version is bumped, like after a trade, and they are told with the mail built by
+// notice (stored in the same transaction, without attachments).
+func (r *MarketRepository) Buy(ctx context.Context, listingID int64, buyerID string, buyerName string, notice func(*model.MarketListing) *model.Mail) (*model.MarketListing, error) {
 	tx, err := r.pool.Begin(ctx)
 	if err != nil {
 		return nil, err
@@ -245,23 +266,18 @@ func (r *MarketRepository) Buy(ctx context.Context, listingID int64, buyerID str
 
 	totalPrice := l.UnitPrice * int64(l.Quantity)
 
-	// Debit buyer
-	var buyerCredits int64
-	err = tx.QueryRow(ctx, `
-		UPDATE players SET credits = credits - $2, updated_at = NOW()
-		WHERE id = $1 AND credits >= $2
-		RETURNING credits
-	`, buyerID, totalPrice).Scan(&buyerCredits)
-	if err != nil {
+	// Debit buyer, credit seller
+	ref := strconv.FormatInt(listingID, 10)
+	if _, err := addCreditsTx(ctx, tx, buyerID, -totalPrice, model.CreditReasonMarketPurchase, ref); err != nil {
 		return nil, err
 	}
-
-	// Credit seller
-	_, err = tx.Exec(ctx, `
-		UPDATE players SET credits = credits + $2, updated_at = NOW()
-		WHERE id = $1
-	`, l.SellerID, totalPrice)
-	if err != nil {
+	if _, err := addCreditsTx(ctx, tx, l.SellerID, totalPrice, model.CreditReasonMarketSale, ref); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE players SET save_version = save_version + 1 WHERE id = $1`, l.SellerID); err != nil {
+		return nil, err
+	}
+	if err := insertMailTx(ctx, tx, notice(l)); err != nil {
 		return nil, err
 	}
 
@@ -323,14 +339,99 @@ func (r *MarketRepository) GetAvgPrices(ctx context.Context) (map[string]int64,
 	return result, nil
 }
 
+// ExpireOld marks the listings past their expiry as expired and tells each seller with
+// the mail built by notice. The stored mails are returned.
+func (r *MarketRepository) ExpireOld(ctx context.Context, notice func(*model.MarketListing) *model.Mail) ([]*model.Mail, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
 
-func (r *MarketRepository) ExpireOld(ctx context.Context) (int64, error) {
-	tag, err := r.pool.Exec(ctx, `
+	rows, err := tx.Query(ctx, `
 		UPDATE market_listings SET status = 'expired'
 		WHERE status = 'active' AND expires_at < NOW()
+		RETURNING id, seller_id, seller_name, system_id, station_id, station_name,
+		          item_category, item_id, item_name, quantity, unit_price, listing_fee,
+		          status, created_at, expires_at
 	`)
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+	var expired []model.MarketListing
+	for rows.Next() {
+		var l model.MarketListing
+		if err := rows.Scan(
+			&l.ID, &l.SellerID, &l.SellerName, &l.SystemID, &l.StationID, &l.StationName,
+			&l.ItemCategory, &l.ItemID, &l.ItemName, &l.Quantity, &l.UnitPrice, &l.ListingFee,
+			&l.Status, &l.CreatedAt, &l.ExpiresAt,
+		); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		expired = append(expired, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	mails := make([]*model.Mail, 0, len(expired))
+	for i := range expired {
+		mail := notice(&expired[i])
+		if err := insertMailTx(ctx, tx, mail); err != nil {
+			return nil, err
+		}
+		mails = append(mails, mail)
+	}
+	return mails, tx.Commit(ctx)
+}
+
+// GetByBuyerID returns the listings a player bought.
+func (r *MarketRepository) GetByBuyerID(ctx context.Context, buyerID string) ([]model.MarketListing, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, seller_id, seller_name, system_id, station_id, station_name,
+		       item_category, item_id, item_name, quantity, unit_price, listing_fee,
+		       status, created_at, expires_at, sold_to_id, sold_to_name, sold_at
+		FROM market_listings
+		WHERE sold_to_id = $1
+		ORDER BY sold_at DESC
+	`, buyerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	listings := []model.MarketListing{}
+	for rows.Next() {
+		var l model.MarketListing
+		if err := rows.Scan(
+			&l.ID, &l.SellerID, &l.SellerName, &l.SystemID, &l.StationID, &l.StationName,
+			&l.ItemCategory, &l.ItemID, &l.ItemName, &l.Quantity, &l.UnitPrice, &l.ListingFee,
+			&l.Status, &l.CreatedAt, &l.ExpiresAt, &l.SoldToID, &l.SoldToName, &l.SoldAt,
+		); err != nil {
+			return nil, err
+		}
+		listings = append(listings, l)
+	}
+	return listings, rows.Err()
+}
+
+// AnonymisePlayerTx replaces the seller_name/sold_to_name copies of a deleted player
+// and withdraws their active listings.
+func (r *MarketRepository) AnonymisePlayerTx(ctx context.Context, tx pgx.Tx, playerID, anonName string) error {
+	if _, err := tx.Exec(ctx, `
+		UPDATE market_listings SET status = 'cancelled' WHERE seller_id = $1 AND status = 'active'
+	`, playerID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE market_listings SET seller_name = $2 WHERE seller_id = $1
+	`, playerID, anonName); err != nil {
+		return err
 	}
-	return tag.RowsAffected(), nil
+	_, err := tx.Exec(ctx, `
+		UPDATE market_listings SET sold_to_name = $2 WHERE sold_to_id = $1
+	`, playerID, anonName)
+	return err
 }
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"spacegame-backend/internal/model"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PlaySessionRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPlaySessionRepository(pool *pgxpool.Pool) *PlaySessionRepository {
+	return &PlaySessionRepository{pool: pool}
+}
+
+// Heartbeat records that the players are online on the server: their open session is
+// extended (or opened) and the reported system added to it. A session the server no
+// longer reports, or one left open on another server, is ended. systems maps player ID
+// to current system and may be incomplete.
+func (r *PlaySessionRepository) Heartbeat(ctx context.Context, serverID *string, playerIDs []string, systems map[string]int) error {
+	ids := make([]string, 0, len(playerIDs))
+	sys := make([]*int32, 0, len(playerIDs))
+	seen := make(map[string]bool, len(playerIDs))
+	for _, id := range playerIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+		if s, ok := systems[id]; ok {
+			v := int32(s)
+			sys = append(sys, &v)
+		} else {
+			sys = append(sys, nil)
+		}
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		UPDATE play_sessions SET ended_at = last_seen_at
+		WHERE ended_at IS NULL AND player_id = ANY($1::uuid[]) AND server_id IS DISTINCT FROM $2::uuid
+	`, ids, serverID)
+	if err != nil {
+		return err
+	}
+	// Servers on the legacy shared key can't be told apart: their sessions only end by timeout
+	if serverID != nil {
+		_, err = tx.Exec(ctx, `
+			UPDATE play_sessions SET ended_at = last_seen_at
+			WHERE ended_at IS NULL AND server_id = $1 AND player_id <> ALL($2::uuid[])
+		`, *serverID, ids)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO play_sessions (player_id, server_id, systems_visited)
+		SELECT p.id, $2, CASE WHEN h.system_id IS NULL THEN '{}'::int[] ELSE ARRAY[h.system_id] END
+		FROM unnest($1::uuid[], $3::int[]) AS h(player_id, system_id)
+		JOIN players p ON p.id = h.player_id
+		ON CONFLICT (player_id) WHERE ended_at IS NULL DO UPDATE SET
+			last_seen_at = NOW(),
+			systems_visited = CASE WHEN EXCLUDED.systems_visited <@ play_sessions.systems_visited
+				THEN play_sessions.systems_visited
+				ELSE play_sessions.systems_visited || EXCLUDED.systems_visited END
+	`, ids, serverID, sys)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// CloseIdle ends the open sessions not seen in a heartbeat for longer than idle
+// (server crashed or stopped sending heartbeats).
+func (r *PlaySessionRepository) CloseIdle(ctx context.Context, idle time.Duration) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE play_sessions SET ended_at = last_seen_at
+		WHERE ended_at IS NULL AND last_seen_at < $1
+	`, time.Now().Add(-idle))
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// ListForPlayer returns the player's sessions, newest first. limit 0 returns all of them.
+func (r *PlaySessionRepository) ListForPlayer(ctx context.Context, playerID string, limit int) ([]model.PlaySession, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, player_id, server_id, started_at, last_seen_at, ended_at, systems_visited
+		FROM play_sessions
+		WHERE player_id = $1
+		ORDER BY started_at DESC
+		LIMIT NULLIF($2, 0)
+	`, playerID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []model.PlaySession{}
+	for rows.Next() {
+		var s model.PlaySession
+		if err := rows.Scan(&s.ID, &s.PlayerID, &s.ServerID, &s.StartedAt, &s.LastSeenAt, &s.EndedAt, &s.SystemsVisited); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// Concurrency counts the players online every step between from and to.
+func (r *PlaySessionRepository) Concurrency(ctx context.Context, from, to time.Time, step time.Duration) ([]model.ConcurrencyPoint, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT t, (
+			SELECT COUNT(DISTINCT ps.player_id) FROM play_sessions ps
+			WHERE ps.started_at <= t AND COALESCE(ps.ended_at, NOW()) >= t
+		)
+		FROM generate_series($1::timestamptz, $2::timestamptz, make_interval(secs => $3)) AS t
+		ORDER BY t
+	`, from, to, step.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := []model.ConcurrencyPoint{}
+	for rows.Next() {
+		var p model.ConcurrencyPoint
+		if err := rows.Scan(&p.At, &p.Players); err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
+
+// playedOnDay is true when player p was playing at some point of the day n days after
+// registering (a session overlapping that day, even one started the day before).
+func playedOnDay(n string) string {
+	return `EXISTS (
+		SELECT 1 FROM play_sessions ps WHERE ps.player_id = p.id
+		AND ps.started_at < date_trunc('day', p.created_at) + INTERVAL '` + n + ` days' + INTERVAL '1 day'
+		AND COALESCE(ps.ended_at, NOW()) >= date_trunc('day', p.created_at) + INTERVAL '` + n + ` days'
+	)`
+}
+
+// Retention returns the registration cohorts of the last days days, oldest first.
+func (r *PlaySessionRepository) Retention(ctx context.Context, days int) ([]model.RetentionCohort, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT date_trunc('day', p.created_at) AS day, COUNT(*),
+		       COUNT(*) FILTER (WHERE `+playedOnDay("1")+`),
+		       COUNT(*) FILTER (WHERE `+playedOnDay("7")+`),
+		       COUNT(*) FILTER (WHERE `+playedOnDay("30")+`)
+		FROM players p
+		WHERE p.created_at >= date_trunc('day', NOW()) - make_interval(days => $1)
+		GROUP BY day
+		ORDER BY day
+	`, days)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cohorts := []model.RetentionCohort{}
+	for rows.Next() {
+		var c model.RetentionCohort
+		if err := rows.Scan(&c.Day, &c.Registered, &c.Day1, &c.Day7, &c.Day30); err != nil {
+			return nil, err
+		}
+		cohorts = append(cohorts, c)
+	}
+	return cohorts, rows.Err()
+}
@@ -3,15 +3,22 @@ package repository
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"spacegame-backend/internal/model"
+	"spacegame-backend/internal/stateschema"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// ErrStaleSave is returned by SaveFullState when the state was loaded from an older
+// save_version than the one stored. The current version is returned alongside.
+var ErrStaleSave = errors.New("stale save")
+
 type PlayerRepository struct {
 	pool *pgxpool.Pool
 }
@@ -22,17 +29,25 @@ func NewPlayerRepository(pool *pgxpool.Pool) *PlayerRepository {
 
 func (r *PlayerRepository) Create(ctx context.Context, username, email, passwordHash string) (*model.Player, error) {
 	p := &model.Player{}
+	// The starting credits are recorded in the ledger by the same statement
 	err := r.pool.QueryRow(ctx, `
-		INSERT INTO players (username, email, password_hash)
-		VALUES ($1, $2, $3)
-		ON CONFLICT DO NOTHING
-		RETURNING id, username, email, password_hash, current_ship_id, galaxy_seed, system_id,
-		          pos_x, pos_y, pos_z, rotation_x, rotation_y, rotation_z,
-		          credits, kills, deaths, faction_id, corporation_id, role, is_banned, last_login_at, last_save_at, created_at, updated_at
+		WITH p AS (
+			INSERT INTO players (username, email, password_hash)
+			VALUES ($1, $2, $3)
+			ON CONFLICT DO NOTHING
+			RETURNING *
+		), l AS (
+			INSERT INTO credit_ledger (player_id, delta, balance_after, reason)
+			SELECT id, credits, credits, '`+model.CreditReasonStarting+`' FROM p WHERE credits != 0
+		)
+		SELECT id, username, email, password_hash, current_ship_id, galaxy_seed, system_id,
+		       pos_x, pos_y, pos_z, rotation_x, rotation_y, rotation_z,
+		       credits, kills, deaths, faction_id, corporation_id, role, is_banned, email_verified_at IS NOT NULL, totp_enabled_at IS NOT NULL, last_login_at, last_save_at, created_at, updated_at
+		FROM p
 	`, username, email, passwordHash).Scan(
 		&p.ID, &p.Username, &p.Email, &p.PasswordHash, &p.CurrentShipID, &p.GalaxySeed, &p.SystemID,
 		&p.PosX, &p.PosY, &p.PosZ, &p.RotationX, &p.RotationY, &p.RotationZ,
-		&p.Credits, &p.Kills, &p.Deaths, &p.FactionID, &p.CorporationID, &p.Role, &p.IsBanned, &p.LastLoginAt, &p.LastSaveAt, &p.CreatedAt, &p.UpdatedAt,
+		&p.Credits, &p.Kills, &p.Deaths, &p.FactionID, &p.CorporationID, &p.Role, &p.IsBanned, &p.EmailVerified, &p.TwoFactorEnabled, &p.LastLoginAt, &p.LastSaveAt, &p.CreatedAt, &p.UpdatedAt,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -44,16 +59,20 @@ func (r *PlayerRepository) Create(ctx context.Context, username, email, password
 }
 
 func (r *PlayerRepository) GetByID(ctx context.Context, id string) (*model.Player, error) {
+	return getPlayerByID(ctx, r.pool, id)
+}
+
+func getPlayerByID(ctx context.Context, db dbtx, id string) (*model.Player, error) {
 	p := &model.Player{}
-	err := r.pool.QueryRow(ctx, `
+	err := db.QueryRow(ctx, `
 		SELECT id, username, email, password_hash, current_ship_id, galaxy_seed, system_id,
 		       pos_x, pos_y, pos_z, rotation_x, rotation_y, rotation_z,
-		       credits, kills, deaths, faction_id, corporation_id, role, is_banned, last_login_at, last_save_at, created_at, updated_at
+		       credits, kills, deaths, faction_id, corporation_id, role, is_banned, email_verified_at IS NOT NULL, totp_enabled_at IS NOT NULL, last_login_at, last_save_at, created_at, updated_at
 		FROM players WHERE id = $1
 	`, id).Scan(
 		&p.ID, &p.Username, &p.Email, &p.PasswordHash, &p.CurrentShipID, &p.GalaxySeed, &p.SystemID,
 		&p.PosX, &p.PosY, &p.PosZ, &p.RotationX, &p.RotationY, &p.RotationZ,
-		&p.Credits, &p.Kills, &p.Deaths, &p.FactionID, &p.CorporationID, &p.Role, &p.IsBanned, &p.LastLoginAt, &p.LastSaveAt, &p.CreatedAt, &p.UpdatedAt,
+		&p.Credits, &p.Kills, &p.Deaths, &p.FactionID, &p.CorporationID, &p.Role, &p.IsBanned, &p.EmailVerified, &p.TwoFactorEnabled, &p.LastLoginAt, &p.LastSaveAt, &p.CreatedAt, &p.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
@@ -66,12 +85,30 @@ func (r *PlayerRepository) GetByUsername(ctx context.Context, username string) (
 	err := r.pool.QueryRow(ctx, `
 		SELECT id, username, email, password_hash, current_ship_id, galaxy_seed, system_id,
 		       pos_x, pos_y, pos_z, rotation_x, rotation_y, rotation_z,
-		       credits, kills, deaths, faction_id, corporation_id, role, is_banned, last_login_at, last_save_at, created_at, updated_at
+		       credits, kills, deaths, faction_id, corporation_id, role, is_banned, email_verified_at IS NOT NULL, totp_enabled_at IS NOT NULL, last_login_at, last_save_at, created_at, updated_at
 		FROM players WHERE username = $1
 	`, username).Scan(
 		&p.ID, &p.Username, &p.Email, &p.PasswordHash, &p.CurrentShipID, &p.GalaxySeed, &p.SystemID,
 		&p.PosX, &p.PosY, &p.PosZ, &p.RotationX, &p.RotationY, &p.RotationZ,
-		&p.Credits, &p.Kills, &p.Deaths, &p.FactionID, &p.CorporationID, &p.Role, &p.IsBanned, &p.LastLoginAt, &p.LastSaveAt, &p.CreatedAt, &p.UpdatedAt,
+		&p.Credits, &p.Kills, &p.Deaths, &p.FactionID, &p.CorporationID, &p.Role, &p.IsBanned, &p.EmailVerified, &p.TwoFactorEnabled, &p.LastLoginAt, &p.LastSaveAt, &p.CreatedAt, &p.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (r *PlayerRepository) GetByEmail(ctx context.Context, email string) (*model.Player, error) {
+	p := &model.Player{}
+	err := r.pool.QueryRow(ctx, `
+		SELECT id, username, email, password_hash, current_ship_id, galaxy_seed, system_id,
+		       pos_x, pos_y, pos_z, rotation_x, rotation_y, rotation_z,
+		       credits, kills, deaths, faction_id, corporation_id, role, is_banned, email_verified_at IS NOT NULL, totp_enabled_at IS NOT NULL, last_login_at, last_save_at, created_at, updated_at
+		FROM players WHERE email = LOWER($1)
+	`, email).Scan(
+		&p.ID, &p.Username, &p.Email, &p.PasswordHash, &p.CurrentShipID, &p.GalaxySeed, &p.SystemID,
+		&p.PosX, &p.PosY, &p.PosZ, &p.RotationX, &p.RotationY, &p.RotationZ,
+		&p.Credits, &p.Kills, &p.Deaths, &p.FactionID, &p.CorporationID, &p.Role, &p.IsBanned, &p.EmailVerified, &p.TwoFactorEnabled, &p.LastLoginAt, &p.LastSaveAt, &p.CreatedAt, &p.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
@@ -82,11 +119,13 @@ func (r *PlayerRepository) GetByUsername(ctx context.Context, username string) (
 func (r *PlayerRepository) GetProfile(ctx context.Context, id string) (*model.PlayerProfile, error) {
 	p := &model.PlayerProfile{}
 	err := r.pool.QueryRow(ctx, `
-		SELECT p.id, p.username, p.current_ship_id, p.kills, p.deaths, p.corporation_id, c.corporation_name, c.corporation_tag
+		SELECT p.id, p.username, p.current_ship_id, p.kills, p.deaths, p.corporation_id, c.corporation_name, c.corporation_tag,
+		       (SELECT COALESCE(EXTRACT(EPOCH FROM SUM(COALESCE(ps.ended_at, ps.last_seen_at) - ps.started_at)), 0)::BIGINT
+		        FROM play_sessions ps WHERE ps.player_id = p.id)
 		FROM players p
 		LEFT JOIN corporations c ON p.corporation_id = c.id
 		WHERE p.id = $1
-	`, id).Scan(&p.ID, &p.Username, &p.CurrentShipID, &p.Kills, &p.Deaths, &p.CorporationID, &p.CorporationName, &p.CorporationTag)
+	`, id).Scan(&p.ID, &p.Username, &p.CurrentShipID, &p.Kills, &p.Deaths, &p.CorporationID, &p.CorporationName, &p.CorporationTag, &p.PlaytimeSeconds)
 	if err != nil {
 		return nil, err
 	}
@@ -98,23 +137,60 @@ func (r *PlayerRepository) UpdateLoginTime(ctx context.Context, id string) error
 	return err
 }
 
+func (r *PlayerRepository) UpdatePassword(ctx context.Context, id, passwordHash string) error {
+	_, err := r.pool.Exec(ctx, `UPDATE players SET password_hash = $2, updated_at = NOW() WHERE id = $1`, id, passwordHash)
+	return err
+}
+
+func (r *PlayerRepository) MarkEmailVerified(ctx context.Context, id string) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE players SET email_verified_at = COALESCE(email_verified_at, NOW()), updated_at = NOW() WHERE id = $1
+	`, id)
+	return err
+}
+
 func (r *PlayerRepository) CountTotal(ctx context.Context) (int, error) {
 	var count int
-	err := r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM players`).Scan(&count)
+	err := r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM players WHERE deleted_at IS NULL`).Scan(&count)
 	return count, err
 }
 
 // --- Full state save/load ---
 
 func (r *PlayerRepository) GetFullState(ctx context.Context, playerID string) (*model.PlayerState, error) {
-	p, err := r.GetByID(ctx, playerID)
+	return loadFullState(ctx, r.pool, playerID)
+}
+
+// loadFullState reads the player's state through db, so a save transaction can read
+// back what it wrote.
+func loadFullState(ctx context.Context, db dbtx, playerID string) (*model.PlayerState, error) {
+	p, err := getPlayerByID(ctx, db, playerID)
 	if err != nil {
 		return nil, err
 	}
 
 	// Fleet + StationServices + Settings + GameplayState (JSONB columns on players table)
 	var fleetRaw, stationServicesRaw, settingsRaw, gameplayStateRaw []byte
-	_ = r.pool.QueryRow(ctx, `SELECT fleet, station_services, settings, gameplay_state FROM players WHERE id = $1`, playerID).Scan(&fleetRaw, &stationServicesRaw, &settingsRaw, &gameplayStateRaw)
+	var saveVersion int64
+	var schemaVersions map[string]int
+	_ = db.QueryRow(ctx, `SELECT fleet, station_services, settings, gameplay_state, save_version, schema_versions FROM players WHERE id = $1`, playerID).Scan(&fleetRaw, &stationServicesRaw, &settingsRaw, &gameplayStateRaw, &saveVersion, &schemaVersions)
+
+	// Upgrade the sections saved by an older game version before defaulting them
+	sections := map[string]json.RawMessage{
+		stateschema.Fleet: fleetRaw, stateschema.StationServices: stationServicesRaw, stateschema.Settings: settingsRaw,
+	}
+	if len(gameplayStateRaw) > 2 { // more than "{}"
+		var gp map[string]json.RawMessage
+		if err := json.Unmarshal(gameplayStateRaw, &gp); err == nil {
+			for _, key := range []string{stateschema.Missions, stateschema.Factions, stateschema.EconomySim, stateschema.Pois} {
+				sections[key] = gp[key]
+			}
+		}
+	}
+	if _, err := stateschema.UpgradeSections(sections, schemaVersions); err != nil {
+		return nil, err
+	}
+	fleetRaw, stationServicesRaw, settingsRaw = sections[stateschema.Fleet], sections[stateschema.StationServices], sections[stateschema.Settings]
 
 	// Default nil JSONB columns to empty JSON to avoid null in API response
 	if fleetRaw == nil {
@@ -129,6 +205,7 @@ func (r *PlayerRepository) GetFullState(ctx context.Context, playerID string) (*
 
 	state := &model.PlayerState{
 		HasSaved:      p.LastSaveAt != nil,
+		SaveVersion:   &saveVersion,
 		CurrentShipID: p.CurrentShipID,
 		GalaxySeed:    p.GalaxySeed,
 		SystemID:      p.SystemID,
@@ -145,87 +222,191 @@ func (r *PlayerRepository) GetFullState(ctx context.Context, playerID string) (*
 		Fleet:           json.RawMessage(fleetRaw),
 		StationServices: json.RawMessage(stationServicesRaw),
 		Settings:        json.RawMessage(settingsRaw),
+		// Unbundled from the gameplay_state JSONB
+		Missions:        sections[stateschema.Missions],
+		Factions:        sections[stateschema.Factions],
+		EconomySim:      sections[stateschema.EconomySim],
+		Pois:            sections[stateschema.Pois],
+		SchemaVersions:  stateschema.CurrentVersions(),
 		Resources:       []model.PlayerResource{},
 		Inventory:       []model.InventoryItem{},
 		Cargo:           []model.CargoItem{},
 	}
 
-	// Unbundle gameplay_state JSONB into individual fields
-	if len(gameplayStateRaw) > 2 { // more than "{}"
-		var gp map[string]json.RawMessage
-		if err := json.Unmarshal(gameplayStateRaw, &gp); err == nil {
-			state.Missions = gp["missions"]
-			state.Factions = gp["factions"]
-			state.EconomySim = gp["economy_sim"]
-			state.Pois = gp["pois"]
-		}
+	// Resources, inventory & cargo
+	if err := loadProtectedItems(ctx, db, playerID, state); err != nil {
+		return nil, err
+	}
+
+	// Equipment
+	var eq model.PlayerEquipment
+	var hardpoints, modules []byte
+	err = db.QueryRow(ctx, `
+		SELECT hardpoints, shield_name, engine_name, modules FROM player_equipment WHERE player_id = $1
+	`, playerID).Scan(&hardpoints, &eq.ShieldName, &eq.EngineName, &modules)
+	if err == nil {
+		eq.Hardpoints = json.RawMessage(hardpoints)
+		eq.Modules = json.RawMessage(modules)
+		state.Equipment = &eq
 	}
+	// pgx.ErrNoRows is fine — no equipment row yet
 
-	// Resources
-	rows, err := r.pool.Query(ctx, `SELECT resource_id, quantity FROM player_resources WHERE player_id = $1`, playerID)
+	return state, nil
+}
+
+// loadProtectedTx returns only the server-authoritative fields of the player's state
+// (credits, kills, deaths, resources, inventory, cargo). The caller holds the players
+// row FOR UPDATE, so they can't change before the save commits.
+func loadProtectedTx(ctx context.Context, tx pgx.Tx, playerID string) (*model.PlayerState, error) {
+	state := &model.PlayerState{Resources: []model.PlayerResource{}, Inventory: []model.InventoryItem{}, Cargo: []model.CargoItem{}}
+	err := tx.QueryRow(ctx, `SELECT credits, kills, deaths FROM players WHERE id = $1`, playerID).
+		Scan(&state.Credits, &state.Kills, &state.Deaths)
 	if err != nil {
 		return nil, err
 	}
+	if err := loadProtectedItems(ctx, tx, playerID, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// loadProtectedItems appends the player's resources, inventory and cargo to state.
+func loadProtectedItems(ctx context.Context, db dbtx, playerID string, state *model.PlayerState) error {
+	rows, err := db.Query(ctx, `SELECT resource_id, quantity FROM player_resources WHERE player_id = $1`, playerID)
+	if err != nil {
+		return err
+	}
 	defer rows.Close()
 	for rows.Next() {
 		var res model.PlayerResource
 		if err := rows.Scan(&res.ResourceID, &res.Quantity); err != nil {
-			return nil, err
+			return err
 		}
 		state.Resources = append(state.Resources, res)
 	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
 
-	// Inventory
-	rows, err = r.pool.Query(ctx, `SELECT category, item_name, quantity FROM player_inventory WHERE player_id = $1`, playerID)
+	rows, err = db.Query(ctx, `SELECT category, item_name, quantity FROM player_inventory WHERE player_id = $1`, playerID)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
 	for rows.Next() {
 		var item model.InventoryItem
 		if err := rows.Scan(&item.Category, &item.ItemName, &item.Quantity); err != nil {
-			return nil, err
+			return err
 		}
 		state.Inventory = append(state.Inventory, item)
 	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
 
-	// Cargo
-	rows, err = r.pool.Query(ctx, `SELECT item_name, item_type, quantity, icon_color FROM player_cargo WHERE player_id = $1`, playerID)
+	rows, err = db.Query(ctx, `SELECT item_name, item_type, quantity, icon_color FROM player_cargo WHERE player_id = $1`, playerID)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
 	for rows.Next() {
 		var item model.CargoItem
 		if err := rows.Scan(&item.ItemName, &item.ItemType, &item.Quantity, &item.IconColor); err != nil {
-			return nil, err
+			return err
 		}
 		state.Cargo = append(state.Cargo, item)
 	}
+	return rows.Err()
+}
 
-	// Equipment
-	var eq model.PlayerEquipment
-	var hardpoints, modules []byte
-	err = r.pool.QueryRow(ctx, `
-		SELECT hardpoints, shield_name, engine_name, modules FROM player_equipment WHERE player_id = $1
-	`, playerID).Scan(&hardpoints, &eq.ShieldName, &eq.EngineName, &modules)
-	if err == nil {
-		eq.Hardpoints = json.RawMessage(hardpoints)
-		eq.Modules = json.RawMessage(modules)
-		state.Equipment = &eq
+// SaveOptions controls how SaveFullState writes a state.
+type SaveOptions struct {
+	ServerID      *string // game server that sent it (nil for the client, the legacy server key or an admin)
+	Force         bool    // skip the save_version check
+	KeepProtected bool    // leave credits, kills, deaths, resources, inventory and cargo untouched
+	CreditReason  string  // credit_ledger reason if credits change
+	CreditRef     string  // credit_ledger reference (defaults to ServerID)
+
+	// CheckProtected, if set, is called inside the save transaction with the stored
+	// protected fields, locked until the save commits. Returning true keeps them, as
+	// KeepProtected does.
+	CheckProtected func(stored *model.PlayerState) bool
+	// CheckEconomy, if set, is called the same way with when the player's economy was last
+	// saved through it (nil if never); an error aborts the save. PatchState then records
+	// this save as the last economy save.
+	CheckEconomy func(stored *model.PlayerState, lastSaved *time.Time) error
+
+	// SnapshotReason, if set, snapshots the state as stored by this save, read back
+	// inside the save transaction. SnapshotKeep regular snapshots are kept.
+	SnapshotReason string
+	SnapshotBy     string
+	SnapshotKeep   int
+}
+
+// checkProtectedTx applies opts.CheckEconomy and opts.CheckProtected once the players
+// row is locked.
+func checkProtectedTx(ctx context.Context, tx pgx.Tx, playerID string, opts *SaveOptions) error {
+	if (opts.CheckProtected == nil && opts.CheckEconomy == nil) || opts.KeepProtected {
+		return nil
 	}
-	// pgx.ErrNoRows is fine — no equipment row yet
+	stored, err := loadProtectedTx(ctx, tx, playerID)
+	if err != nil {
+		return err
+	}
+	if opts.CheckEconomy != nil {
+		var lastSaved *time.Time
+		if err := tx.QueryRow(ctx, `SELECT economy_saved_at FROM players WHERE id = $1`, playerID).Scan(&lastSaved); err != nil {
+			return err
+		}
+		if err := opts.CheckEconomy(stored, lastSaved); err != nil {
+			return err
+		}
+	}
+	if opts.CheckProtected != nil {
+		opts.KeepProtected = opts.CheckProtected(stored)
+	}
+	return nil
+}
 
-	return state, nil
+// snapshotTx records the state just written by the transaction if opts asks for it.
+func snapshotTx(ctx context.Context, tx pgx.Tx, playerID string, opts *SaveOptions) error {
+	if opts.SnapshotReason == "" {
+		return nil
+	}
+	state, err := loadFullState(ctx, tx, playerID)
+	if err != nil {
+		return err
+	}
+	state.HasSaved = true
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return insertSnapshotTx(ctx, tx, playerID, *state.SaveVersion, opts.SnapshotReason, opts.SnapshotBy, data, opts.SnapshotKeep)
 }
 
-func (r *PlayerRepository) SaveFullState(ctx context.Context, playerID string, state *model.PlayerState) error {
+// SaveFullState overwrites the player's state if state.SaveVersion still matches the
+// stored version (or opts.Force is set), and returns the new version. A stale write
+// returns ErrStaleSave with the current version. A change of credits is recorded in
+// the credit ledger.
+func (r *PlayerRepository) SaveFullState(ctx context.Context, playerID string, state *model.PlayerState, opts SaveOptions) (int64, error) {
 	tx, err := r.pool.Begin(ctx)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	defer tx.Rollback(ctx)
 
+	var current, credits int64
+	if err := tx.QueryRow(ctx, `SELECT save_version, credits FROM players WHERE id = $1 FOR UPDATE`, playerID).Scan(&current, &credits); err != nil {
+		return 0, err
+	}
+	if !opts.Force && (state.SaveVersion == nil || *state.SaveVersion != current) {
+		return current, ErrStaleSave
+	}
+	if err := checkProtectedTx(ctx, tx, playerID, &opts); err != nil {
+		return 0, err
+	}
+
 	now := time.Now()
 
 	// Default fleet/station_services/settings to empty JSON if nil
@@ -257,6 +438,8 @@ func (r *PlayerRepository) SaveFullState(ctx context.Context, playerID string, s
 		gameplayState["pois"] = state.Pois
 	}
 	gameplayJSON, _ := json.Marshal(gameplayState)
+	// Sections must already be upgraded (stateschema.UpgradeState)
+	schemaJSON, _ := json.Marshal(stateschema.CurrentVersions())
 
 	// Update player core fields
 	_, err = tx.Exec(ctx, `
@@ -264,79 +447,392 @@ func (r *PlayerRepository) SaveFullState(ctx context.Context, playerID string, s
 			current_ship_id = $2, galaxy_seed = $3, system_id = $4,
 			pos_x = $5, pos_y = $6, pos_z = $7,
 			rotation_x = $8, rotation_y = $9, rotation_z = $10,
-			credits = $11, kills = $12, deaths = $13,
+			credits = CASE WHEN $21 THEN credits ELSE $11 END,
+			kills = CASE WHEN $21 THEN kills ELSE $12 END,
+			deaths = CASE WHEN $21 THEN deaths ELSE $13 END,
 			faction_id = $14,
 			fleet = $15, station_services = $16, settings = $17,
-			gameplay_state = $19,
+			gameplay_state = $19, schema_versions = $22,
+			last_server_id = COALESCE($20, last_server_id),
+			save_version = save_version + 1,
 			last_save_at = $18, updated_at = $18
 		WHERE id = $1
 	`, playerID, state.CurrentShipID, state.GalaxySeed, state.SystemID,
 		state.PosX, state.PosY, state.PosZ,
 		state.RotationX, state.RotationY, state.RotationZ,
-		state.Credits, state.Kills, state.Deaths, state.FactionID, fleetJSON, stationServicesJSON, settingsJSON, now, gameplayJSON)
+		state.Credits, state.Kills, state.Deaths, state.FactionID, fleetJSON, stationServicesJSON, settingsJSON, now, gameplayJSON, opts.ServerID, opts.KeepProtected, schemaJSON)
+	if err != nil {
+		return 0, err
+	}
+
+	if !opts.KeepProtected {
+		ref := opts.CreditRef
+		if ref == "" && opts.ServerID != nil {
+			ref = *opts.ServerID
+		}
+		if err := recordCreditsTx(ctx, tx, playerID, state.Credits-credits, state.Credits, opts.CreditReason, ref); err != nil {
+			return 0, err
+		}
+	}
+
+	// Resources, inventory & cargo are left as they are when protected; cargo also
+	// when the state doesn't carry it
+	if !opts.KeepProtected {
+		if err := replaceResourcesTx(ctx, tx, playerID, state.Resources); err != nil {
+			return 0, err
+		}
+		if err := replaceInventoryTx(ctx, tx, playerID, state.Inventory); err != nil {
+			return 0, err
+		}
+		if state.Cargo != nil {
+			if err := replaceCargoTx(ctx, tx, playerID, state.Cargo); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if state.Equipment != nil {
+		if err := upsertEquipmentTx(ctx, tx, playerID, state.Equipment); err != nil {
+			return 0, err
+		}
+	}
+	if err := snapshotTx(ctx, tx, playerID, &opts); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return current + 1, nil
+}
+
+// PatchState applies only the sections present in the patch, with the same version
+// check, protection and credit ledger rules as SaveFullState. Returns the new version.
+func (r *PlayerRepository) PatchState(ctx context.Context, playerID string, patch *model.PlayerStatePatch, opts SaveOptions) (int64, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var current, credits int64
+	if err := tx.QueryRow(ctx, `SELECT save_version, credits FROM players WHERE id = $1 FOR UPDATE`, playerID).Scan(&current, &credits); err != nil {
+		return 0, err
+	}
+	if !opts.Force && (patch.SaveVersion == nil || *patch.SaveVersion != current) {
+		return current, ErrStaleSave
+	}
+	if err := checkProtectedTx(ctx, tx, playerID, &opts); err != nil {
+		return 0, err
+	}
+
+	sets := []string{"save_version = save_version + 1", "last_save_at = NOW()", "updated_at = NOW()"}
+	args := []any{playerID}
+	set := func(column string, value any) {
+		args = append(args, value)
+		sets = append(sets, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+	if opts.ServerID != nil {
+		set("last_server_id", *opts.ServerID)
+	}
+	if opts.CheckEconomy != nil {
+		sets = append(sets, "economy_saved_at = NOW()")
+	}
+	if patch.CurrentShipID != nil {
+		set("current_ship_id", *patch.CurrentShipID)
+	}
+	if patch.GalaxySeed != nil {
+		set("galaxy_seed", *patch.GalaxySeed)
+	}
+	if patch.SystemID != nil {
+		set("system_id", *patch.SystemID)
+	}
+	// Fixed column order: the same patch shape must give the same SQL text, so pgx's
+	// statement cache hits
+	for _, f := range []struct {
+		column string
+		value  *float64
+	}{
+		{"pos_x", patch.PosX}, {"pos_y", patch.PosY}, {"pos_z", patch.PosZ},
+		{"rotation_x", patch.RotationX}, {"rotation_y", patch.RotationY}, {"rotation_z", patch.RotationZ},
+	} {
+		if f.value != nil {
+			set(f.column, *f.value)
+		}
+	}
+	if patch.FactionID != nil {
+		set("faction_id", *patch.FactionID)
+	}
+	// Sections must already be upgraded (stateschema.UpgradePatch); only the ones sent
+	// are stamped with the current version
+	versions := map[string]int{}
+	if patch.Fleet != nil {
+		set("fleet", patch.Fleet)
+		versions[stateschema.Fleet] = stateschema.Current(stateschema.Fleet)
+	}
+	if patch.StationServices != nil {
+		set("station_services", patch.StationServices)
+		versions[stateschema.StationServices] = stateschema.Current(stateschema.StationServices)
+	}
+	if patch.Settings != nil {
+		set("settings", patch.Settings)
+		versions[stateschema.Settings] = stateschema.Current(stateschema.Settings)
+	}
+	gameplay := map[string]json.RawMessage{}
+	for _, s := range []struct {
+		key   string
+		value json.RawMessage
+	}{
+		{stateschema.Missions, patch.Missions}, {stateschema.Factions, patch.Factions},
+		{stateschema.EconomySim, patch.EconomySim}, {stateschema.Pois, patch.Pois},
+	} {
+		if s.value != nil {
+			gameplay[s.key] = s.value
+			versions[s.key] = stateschema.Current(s.key)
+		}
+	}
+	if len(gameplay) > 0 {
+		gameplayJSON, _ := json.Marshal(gameplay)
+		args = append(args, gameplayJSON)
+		sets = append(sets, fmt.Sprintf("gameplay_state = COALESCE(gameplay_state, '{}') || $%d::jsonb", len(args)))
+	}
+	if len(versions) > 0 {
+		versionsJSON, _ := json.Marshal(versions)
+		args = append(args, versionsJSON)
+		sets = append(sets, fmt.Sprintf("schema_versions = schema_versions || $%d::jsonb", len(args)))
+	}
+	if !opts.KeepProtected {
+		if patch.Credits != nil {
+			set("credits", *patch.Credits)
+		}
+		if patch.Kills != nil {
+			set("kills", *patch.Kills)
+		}
+		if patch.Deaths != nil {
+			set("deaths", *patch.Deaths)
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE players SET `+strings.Join(sets, ", ")+` WHERE id = $1`, args...); err != nil {
+		return 0, err
+	}
+
+	if !opts.KeepProtected {
+		if patch.Credits != nil {
+			ref := opts.CreditRef
+			if ref == "" && opts.ServerID != nil {
+				ref = *opts.ServerID
+			}
+			if err := recordCreditsTx(ctx, tx, playerID, *patch.Credits-credits, *patch.Credits, opts.CreditReason, ref); err != nil {
+				return 0, err
+			}
+		}
+		if patch.Resources != nil {
+			if err := replaceResourcesTx(ctx, tx, playerID, patch.Resources); err != nil {
+				return 0, err
+			}
+		}
+		if patch.Inventory != nil {
+			if err := replaceInventoryTx(ctx, tx, playerID, patch.Inventory); err != nil {
+				return 0, err
+			}
+		}
+		if patch.Cargo != nil {
+			if err := replaceCargoTx(ctx, tx, playerID, patch.Cargo); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if patch.Equipment != nil {
+		if err := upsertEquipmentTx(ctx, tx, playerID, patch.Equipment); err != nil {
+			return 0, err
+		}
+	}
+	if err := snapshotTx(ctx, tx, playerID, &opts); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return current + 1, nil
+}
+
+// replaceResourcesTx makes the player's resources match the list: changed rows are
+// upserted, missing ones deleted, unchanged ones left alone.
+func replaceResourcesTx(ctx context.Context, tx pgx.Tx, playerID string, resources []model.PlayerResource) error {
+	ids := make([]string, len(resources))
+	quantities := make([]int32, len(resources))
+	for i, res := range resources {
+		ids[i], quantities[i] = res.ResourceID, int32(res.Quantity)
+	}
+
+	_, err := tx.Exec(ctx, `DELETE FROM player_resources WHERE player_id = $1 AND resource_id <> ALL($2::text[])`, playerID, ids)
 	if err != nil {
 		return err
 	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO player_resources (player_id, resource_id, quantity)
+		SELECT $1, r.id, r.quantity FROM unnest($2::text[], $3::int[]) AS r(id, quantity)
+		ON CONFLICT (player_id, resource_id) DO UPDATE SET quantity = EXCLUDED.quantity
+		WHERE player_resources.quantity <> EXCLUDED.quantity
+	`, playerID, ids, quantities)
+	return err
+}
 
-	// Resources — delete and re-insert
-	_, err = tx.Exec(ctx, `DELETE FROM player_resources WHERE player_id = $1`, playerID)
+// replaceInventoryTx makes the player's inventory match the list (see replaceResourcesTx).
+func replaceInventoryTx(ctx context.Context, tx pgx.Tx, playerID string, items []model.InventoryItem) error {
+	categories := make([]string, len(items))
+	names := make([]string, len(items))
+	quantities := make([]int32, len(items))
+	for i, item := range items {
+		categories[i], names[i], quantities[i] = item.Category, item.ItemName, int32(item.Quantity)
+	}
+
+	_, err := tx.Exec(ctx, `
+		DELETE FROM player_inventory i
+		WHERE i.player_id = $1 AND NOT EXISTS (
+			SELECT 1 FROM unnest($2::text[], $3::text[]) AS k(category, item_name)
+			WHERE k.category = i.category AND k.item_name = i.item_name
+		)
+	`, playerID, categories, names)
 	if err != nil {
 		return err
 	}
-	for _, res := range state.Resources {
-		_, err = tx.Exec(ctx, `
-			INSERT INTO player_resources (player_id, resource_id, quantity) VALUES ($1, $2, $3)
-		`, playerID, res.ResourceID, res.Quantity)
-		if err != nil {
-			return err
-		}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO player_inventory (player_id, category, item_name, quantity)
+		SELECT $1, k.category, k.item_name, k.quantity FROM unnest($2::text[], $3::text[], $4::int[]) AS k(category, item_name, quantity)
+		ON CONFLICT (player_id, category, item_name) DO UPDATE SET quantity = EXCLUDED.quantity
+		WHERE player_inventory.quantity <> EXCLUDED.quantity
+	`, playerID, categories, names, quantities)
+	return err
+}
+
+// replaceCargoTx makes the player's cargo match the list (see replaceResourcesTx).
+func replaceCargoTx(ctx context.Context, tx pgx.Tx, playerID string, cargo []model.CargoItem) error {
+	names := make([]string, len(cargo))
+	types := make([]string, len(cargo))
+	quantities := make([]int32, len(cargo))
+	colors := make([]*string, len(cargo))
+	for i, item := range cargo {
+		names[i], types[i], quantities[i], colors[i] = item.ItemName, item.ItemType, int32(item.Quantity), item.IconColor
 	}
 
-	// Inventory
-	_, err = tx.Exec(ctx, `DELETE FROM player_inventory WHERE player_id = $1`, playerID)
+	_, err := tx.Exec(ctx, `DELETE FROM player_cargo WHERE player_id = $1 AND item_name <> ALL($2::text[])`, playerID, names)
 	if err != nil {
 		return err
 	}
-	for _, item := range state.Inventory {
-		_, err = tx.Exec(ctx, `
-			INSERT INTO player_inventory (player_id, category, item_name, quantity) VALUES ($1, $2, $3, $4)
-		`, playerID, item.Category, item.ItemName, item.Quantity)
-		if err != nil {
-			return err
+	_, err = tx.Exec(ctx, `
+		INSERT INTO player_cargo (player_id, item_name, item_type, quantity, icon_color)
+		SELECT $1, c.item_name, c.item_type, c.quantity, c.icon_color
+		FROM unnest($2::text[], $3::text[], $4::int[], $5::text[]) AS c(item_name, item_type, quantity, icon_color)
+		ON CONFLICT (player_id, item_name) DO UPDATE SET
+			item_type = EXCLUDED.item_type, quantity = EXCLUDED.quantity, icon_color = EXCLUDED.icon_color
+		WHERE (player_cargo.item_type, player_cargo.quantity, player_cargo.icon_color)
+			IS DISTINCT FROM (EXCLUDED.item_type, EXCLUDED.quantity, EXCLUDED.icon_color)
+	`, playerID, names, types, quantities, colors)
+	return err
+}
+
+func upsertEquipmentTx(ctx context.Context, tx pgx.Tx, playerID string, eq *model.PlayerEquipment) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO player_equipment (player_id, hardpoints, shield_name, engine_name, modules)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (player_id) DO UPDATE SET
+			hardpoints = EXCLUDED.hardpoints,
+			shield_name = EXCLUDED.shield_name,
+			engine_name = EXCLUDED.engine_name,
+			modules = EXCLUDED.modules
+	`, playerID, eq.Hardpoints, eq.ShieldName, eq.EngineName, eq.Modules)
+	return err
+}
+
+// --- Save schema upgrades ---
+
+// ListOutdatedSchemaIDs returns up to limit players (ordered by ID, after afterID) with
+// a section stored below its current version.
+func (r *PlayerRepository) ListOutdatedSchemaIDs(ctx context.Context, afterID string, limit int) ([]string, error) {
+	// A missing version is 0, so sections that never had an upgrade need not be stamped
+	required := map[string]int{}
+	for section, v := range stateschema.CurrentVersions() {
+		if v > 0 {
+			required[section] = v
 		}
 	}
+	requiredJSON, _ := json.Marshal(required)
 
-	// Cargo
-	_, err = tx.Exec(ctx, `DELETE FROM player_cargo WHERE player_id = $1`, playerID)
+	rows, err := r.pool.Query(ctx, `
+		SELECT id FROM players
+		WHERE NOT schema_versions @> $1::jsonb AND id::text > $2
+		ORDER BY id::text
+		LIMIT $3
+	`, requiredJSON, afterID, limit)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	for _, item := range state.Cargo {
-		_, err = tx.Exec(ctx, `
-			INSERT INTO player_cargo (player_id, item_name, item_type, quantity, icon_color) VALUES ($1, $2, $3, $4, $5)
-		`, playerID, item.ItemName, item.ItemType, item.Quantity, item.IconColor)
-		if err != nil {
-			return err
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
 		}
+		ids = append(ids, id)
 	}
+	return ids, rows.Err()
+}
 
-	// Equipment
-	if state.Equipment != nil {
-		_, err = tx.Exec(ctx, `
-			INSERT INTO player_equipment (player_id, hardpoints, shield_name, engine_name, modules)
-			VALUES ($1, $2, $3, $4, $5)
-			ON CONFLICT (player_id) DO UPDATE SET
-				hardpoints = EXCLUDED.hardpoints,
-				shield_name = EXCLUDED.shield_name,
-				engine_name = EXCLUDED.engine_name,
-				modules = EXCLUDED.modules
-		`, playerID, state.Equipment.Hardpoints, state.Equipment.ShieldName, state.Equipment.EngineName, state.Equipment.Modules)
-		if err != nil {
-			return err
+// UpgradeStoredSchemas rewrites the player's JSON sections at their current versions.
+// save_version is not bumped: the loaded state was already upgraded, so a client
+// holding it can still save. Reports whether any section changed.
+func (r *PlayerRepository) UpgradeStoredSchemas(ctx context.Context, playerID string) (bool, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	var fleetRaw, stationServicesRaw, settingsRaw, gameplayStateRaw []byte
+	var versions map[string]int
+	err = tx.QueryRow(ctx, `
+		SELECT fleet, station_services, settings, gameplay_state, schema_versions FROM players WHERE id = $1 FOR UPDATE
+	`, playerID).Scan(&fleetRaw, &stationServicesRaw, &settingsRaw, &gameplayStateRaw, &versions)
+	if err != nil {
+		return false, err
+	}
+
+	sections := map[string]json.RawMessage{
+		stateschema.Fleet: fleetRaw, stateschema.StationServices: stationServicesRaw, stateschema.Settings: settingsRaw,
+	}
+	gameplay := map[string]json.RawMessage{}
+	if len(gameplayStateRaw) > 0 {
+		if err := json.Unmarshal(gameplayStateRaw, &gameplay); err != nil {
+			return false, fmt.Errorf("decode gameplay_state: %w", err)
 		}
 	}
+	for _, key := range []string{stateschema.Missions, stateschema.Factions, stateschema.EconomySim, stateschema.Pois} {
+		sections[key] = gameplay[key]
+	}
+	changed, err := stateschema.UpgradeSections(sections, versions)
+	if err != nil {
+		return false, err
+	}
+	for _, key := range []string{stateschema.Missions, stateschema.Factions, stateschema.EconomySim, stateschema.Pois} {
+		if sections[key] != nil {
+			gameplay[key] = sections[key]
+		}
+	}
+	gameplayJSON, _ := json.Marshal(gameplay)
+	schemaJSON, _ := json.Marshal(stateschema.CurrentVersions())
 
-	return tx.Commit(ctx)
+	_, err = tx.Exec(ctx, `
+		UPDATE players SET fleet = $2, station_services = $3, settings = $4, gameplay_state = $5, schema_versions = $6
+		WHERE id = $1
+	`, playerID, sections[stateschema.Fleet], sections[stateschema.StationServices], sections[stateschema.Settings], gameplayJSON, schemaJSON)
+	if err != nil {
+		return false, err
+	}
+	return changed, tx.Commit(ctx)
 }
 
 func (r *PlayerRepository) SetCorporationID(ctx context.Context, playerID string, corporationID *string) error {
@@ -353,21 +849,152 @@ func (r *PlayerRepository) GetCorporationID(ctx context.Context, playerID string
 	return corporationID, err
 }
 
-func (r *PlayerRepository) UpdateLastSeen(ctx context.Context, playerIDs []string) error {
+// UpdateLastSeen records a game server heartbeat for the players. Returns the ones
+// that had not been seen since absentSince (they just joined the game).
+func (r *PlayerRepository) UpdateLastSeen(ctx context.Context, playerIDs []string, serverID *string, absentSince time.Time) ([]string, error) {
 	if len(playerIDs) == 0 {
-		return nil
+		return nil, nil
+	}
+	rows, err := r.pool.Query(ctx, `
+		UPDATE players p SET last_seen_at = NOW(), last_server_id = COALESCE($2, p.last_server_id)
+		FROM (SELECT id, last_seen_at FROM players WHERE id = ANY($1)) prev
+		WHERE p.id = prev.id
+		RETURNING p.id, prev.last_seen_at IS NULL OR prev.last_seen_at <= $3
+	`, playerIDs, serverID, absentSince)
+	if err != nil {
+		return nil, err
 	}
-	_, err := r.pool.Exec(ctx, `UPDATE players SET last_seen_at = NOW() WHERE id = ANY($1)`, playerIDs)
-	return err
+	defer rows.Close()
+
+	joined := []string{}
+	for rows.Next() {
+		var id string
+		var absent bool
+		if err := rows.Scan(&id, &absent); err != nil {
+			return nil, err
+		}
+		if absent {
+			joined = append(joined, id)
+		}
+	}
+	return joined, rows.Err()
 }
 
-func (r *PlayerRepository) AddCredits(ctx context.Context, playerID string, amount int64) error {
-	_, err := r.pool.Exec(ctx, `
-		UPDATE players SET credits = credits + $2, updated_at = NOW()
-		WHERE id = $1
-	`, playerID, amount)
-	return err
+// SeenSince reports whether a game server reported the player after since.
+func (r *PlayerRepository) SeenSince(ctx context.Context, id string, since time.Time) (bool, error) {
+	var seen bool
+	err := r.pool.QueryRow(ctx, `
+		SELECT COALESCE(last_seen_at > $2, FALSE) FROM players WHERE id = $1
+	`, id, since).Scan(&seen)
+	return seen, err
+}
+
+// AddCredits changes the player's credits and records it in the credit ledger.
+// A debit larger than the balance fails with ErrInsufficientCredits.
+func (r *PlayerRepository) AddCredits(ctx context.Context, playerID string, amount int64, reason, referenceID string) (int64, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	balance, err := addCreditsTx(ctx, tx, playerID, amount, reason, referenceID)
+	if err != nil {
+		return 0, err
+	}
+	return balance, tx.Commit(ctx)
 }
 
 // Ensure rows interface is consumed even on error
 var _ pgx.Rows = (pgx.Rows)(nil)
+
+// --- Account deletion ---
+
+// BeginTx starts a transaction for multi-repository operations (account deletion).
+func (r *PlayerRepository) BeginTx(ctx context.Context) (pgx.Tx, error) {
+	return r.pool.Begin(ctx)
+}
+
+// ScheduleDeletion marks the account for deletion at the given time.
+func (r *PlayerRepository) ScheduleDeletion(ctx context.Context, id string, at time.Time) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE players SET deletion_scheduled_at = $2, updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL
+	`, id, at)
+	return err
+}
+
+// CancelDeletion clears a pending deletion. Returns false if none was scheduled.
+func (r *PlayerRepository) CancelDeletion(ctx context.Context, id string) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE players SET deletion_scheduled_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND deletion_scheduled_at IS NOT NULL AND deleted_at IS NULL
+	`, id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// GetDeletionSchedule returns when the account is due for deletion (nil if not scheduled).
+func (r *PlayerRepository) GetDeletionSchedule(ctx context.Context, id string) (*time.Time, error) {
+	var at *time.Time
+	err := r.pool.QueryRow(ctx, `SELECT deletion_scheduled_at FROM players WHERE id = $1`, id).Scan(&at)
+	return at, err
+}
+
+// ListDueDeletions returns accounts whose grace period has ended.
+func (r *PlayerRepository) ListDueDeletions(ctx context.Context) ([]string, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id FROM players
+		WHERE deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= NOW() AND deleted_at IS NULL
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// AnonymiseTx turns the player into a tombstone: personal data is wiped, the row is
+// kept (renamed to anonName) so foreign keys from other players' history stay valid.
+func (r *PlayerRepository) AnonymiseTx(ctx context.Context, tx pgx.Tx, id, anonName string) error {
+	for _, table := range []string{
+		"player_resources", "player_inventory", "player_cargo", "player_equipment",
+		"refresh_tokens", "account_tokens", "totp_recovery_codes", "login_attempts",
+		"player_snapshots",
+	} {
+		if _, err := tx.Exec(ctx, `DELETE FROM `+table+` WHERE player_id = $1`, id); err != nil {
+			return fmt.Errorf("clear %s: %w", table, err)
+		}
+	}
+
+	// The ledger is append-only: zeroing the balance is recorded like any other change
+	var credits int64
+	if err := tx.QueryRow(ctx, `SELECT credits FROM players WHERE id = $1 FOR UPDATE`, id).Scan(&credits); err != nil {
+		return err
+	}
+	if err := recordCreditsTx(ctx, tx, id, -credits, 0, model.CreditReasonAccountDeleted, ""); err != nil {
+		return err
+	}
+
+	_, err := tx.Exec(ctx, `
+		UPDATE players SET
+			username = $2, email = $2 || '@deleted.invalid', password_hash = '!',
+			email_verified_at = NULL, totp_secret = NULL, totp_enabled_at = NULL,
+			discord_id = NULL, discord_link_code = NULL, discord_link_expires = NULL,
+			corporation_id = NULL, credits = 0, kills = 0, deaths = 0,
+			fleet = '[]', station_services = '[]', settings = '{}', gameplay_state = '{}',
+			deletion_scheduled_at = NULL, deleted_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`, id, anonName)
+	return err
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+
+	"spacegame-backend/internal/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PlayerSnapshotRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPlayerSnapshotRepository(pool *pgxpool.Pool) *PlayerSnapshotRepository {
+	return &PlayerSnapshotRepository{pool: pool}
+}
+
+// insertSnapshotTx stores a snapshot and prunes the player's regular snapshots down to
+// keep. The first snapshot of each day is flagged daily and is not pruned here.
+func insertSnapshotTx(ctx context.Context, tx pgx.Tx, playerID string, saveVersion int64, reason, createdBy string, state json.RawMessage, keep int) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO player_snapshots (player_id, save_version, reason, created_by, state, daily)
+		VALUES ($1, $2, $3, $4, $5, NOT EXISTS (
+			SELECT 1 FROM player_snapshots
+			WHERE player_id = $1 AND daily AND created_at >= date_trunc('day', NOW())
+		))
+	`, playerID, saveVersion, reason, createdBy, state)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		DELETE FROM player_snapshots
+		WHERE player_id = $1 AND NOT daily AND id NOT IN (
+			SELECT id FROM player_snapshots WHERE player_id = $1 AND NOT daily ORDER BY id DESC LIMIT $2
+		)
+	`, playerID, keep)
+	return err
+}
+
+// ListForPlayer returns a player's snapshots, newest first, without their state.
+func (r *PlayerSnapshotRepository) ListForPlayer(ctx context.Context, playerID string) ([]model.PlayerSnapshot, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, player_id, save_version, daily, reason, created_by, created_at
+		FROM player_snapshots WHERE player_id = $1
+		ORDER BY id DESC
+	`, playerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snapshots := []model.PlayerSnapshot{}
+	for rows.Next() {
+		var s model.PlayerSnapshot
+		if err := rows.Scan(&s.ID, &s.PlayerID, &s.SaveVersion, &s.Daily, &s.Reason, &s.CreatedBy, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, s)
+	}
+	return snapshots, rows.Err()
+}
+
+// Get returns a snapshot with its state. Returns pgx.ErrNoRows if it doesn't belong to the player.
+func (r *PlayerSnapshotRepository) Get(ctx context.Context, playerID string, id int64) (*model.PlayerSnapshot, error) {
+	s := &model.PlayerSnapshot{}
+	err := r.pool.QueryRow(ctx, `
+		SELECT id, player_id, save_version, daily, reason, created_by, created_at, state
+		FROM player_snapshots WHERE id = $1 AND player_id = $2
+	`, id, playerID).Scan(&s.ID, &s.PlayerID, &s.SaveVersion, &s.Daily, &s.Reason, &s.CreatedBy, &s.CreatedAt, &s.State)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// DeleteDailyOlderThan removes daily snapshots older than the given number of days.
+func (r *PlayerSnapshotRepository) DeleteDailyOlderThan(ctx context.Context, days int) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM player_snapshots WHERE daily AND created_at < NOW() - make_interval(days => $1)`, days)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
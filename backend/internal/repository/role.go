@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+
+	"spacegame-backend/internal/model"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RoleRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewRoleRepository(pool *pgxpool.Pool) *RoleRepository {
+	return &RoleRepository{pool: pool}
+}
+
+// ListRoles returns every role with its permissions, lowest rank first.
+func (r *RoleRepository) ListRoles(ctx context.Context) ([]model.Role, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT r.name, r.rank, r.description,
+		       COALESCE(ARRAY_AGG(rp.permission ORDER BY rp.permission) FILTER (WHERE rp.permission IS NOT NULL), '{}')
+		FROM roles r
+		LEFT JOIN role_permissions rp ON rp.role = r.name
+		GROUP BY r.name, r.rank, r.description
+		ORDER BY r.rank
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []model.Role
+	for rows.Next() {
+		var role model.Role
+		if err := rows.Scan(&role.Name, &role.Rank, &role.Description, &role.Permissions); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, rows.Err()
+}
+
+// GetPlayerRole returns a player's current role.
+func (r *RoleRepository) GetPlayerRole(ctx context.Context, playerID string) (string, error) {
+	var role string
+	err := r.pool.QueryRow(ctx, `SELECT role FROM players WHERE id = $1`, playerID).Scan(&role)
+	return role, err
+}
+
+// SetPlayerRole changes a player's role. Returns false if the player doesn't exist.
+func (r *RoleRepository) SetPlayerRole(ctx context.Context, playerID, role string) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE players SET role = $2, updated_at = NOW() WHERE id = $1
+	`, playerID, role)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
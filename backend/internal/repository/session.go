@@ -2,11 +2,19 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"time"
 
+	"spacegame-backend/internal/model"
+
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// ErrRefreshTokenReused is returned when a refresh token that was already
+// rotated is presented again. The returned record identifies the family to revoke.
+var ErrRefreshTokenReused = errors.New("refresh token reused")
+
 type SessionRepository struct {
 	pool *pgxpool.Pool
 }
@@ -15,21 +23,71 @@ func NewSessionRepository(pool *pgxpool.Pool) *SessionRepository {
 	return &SessionRepository{pool: pool}
 }
 
-func (r *SessionRepository) StoreRefreshToken(ctx context.Context, playerID, tokenHash string, expiresAt time.Time) error {
-	_, err := r.pool.Exec(ctx, `
-		INSERT INTO refresh_tokens (player_id, token_hash, expires_at)
-		VALUES ($1, $2, $3)
-	`, playerID, tokenHash, expiresAt)
-	return err
+// StoreRefreshToken persists the first refresh token of a new session (its own family).
+func (r *SessionRepository) StoreRefreshToken(ctx context.Context, playerID, tokenHash string, expiresAt time.Time, meta model.SessionMeta) (*model.RefreshTokenRecord, error) {
+	return insertRefreshToken(ctx, r.pool, playerID, nil, tokenHash, expiresAt, meta)
 }
 
-func (r *SessionRepository) ValidateRefreshToken(ctx context.Context, tokenHash string) (string, error) {
-	var playerID string
+// ValidateRefreshToken looks up a refresh token by hash. Revoked or expired tokens
+// yield pgx.ErrNoRows; tokens that were rotated yield ErrRefreshTokenReused along
+// with the record so the caller can revoke the family.
+func (r *SessionRepository) ValidateRefreshToken(ctx context.Context, tokenHash string) (*model.RefreshTokenRecord, error) {
+	rec := &model.RefreshTokenRecord{}
+	var revoked, rotated bool
+	var expiresAt time.Time
 	err := r.pool.QueryRow(ctx, `
-		SELECT player_id FROM refresh_tokens
-		WHERE token_hash = $1 AND revoked = FALSE AND expires_at > NOW()
-	`, tokenHash).Scan(&playerID)
-	return playerID, err
+		SELECT id, family_id, player_id, device_label, session_started_at,
+		       revoked, rotated_at IS NOT NULL, expires_at
+		FROM refresh_tokens
+		WHERE token_hash = $1
+	`, tokenHash).Scan(&rec.ID, &rec.FamilyID, &rec.PlayerID, &rec.DeviceLabel, &rec.SessionStartedAt,
+		&revoked, &rotated, &expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if rotated {
+		return rec, ErrRefreshTokenReused
+	}
+	if revoked || time.Now().After(expiresAt) {
+		return nil, pgx.ErrNoRows
+	}
+	return rec, nil
+}
+
+// RotateRefreshToken atomically retires the old token and stores its successor in
+// the same family. If the old token was rotated or revoked concurrently,
+// ErrRefreshTokenReused is returned and nothing is written.
+func (r *SessionRepository) RotateRefreshToken(ctx context.Context, old *model.RefreshTokenRecord, newTokenHash string, expiresAt time.Time, meta model.SessionMeta) (*model.RefreshTokenRecord, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE refresh_tokens SET revoked = TRUE, rotated_at = NOW()
+		WHERE id = $1 AND revoked = FALSE
+	`, old.ID)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, ErrRefreshTokenReused
+	}
+
+	rec, err := insertRefreshToken(ctx, tx, old.PlayerID, &old.FamilyID, newTokenHash, expiresAt, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE refresh_tokens SET replaced_by = $2 WHERE id = $1`, old.ID, rec.ID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return rec, nil
 }
 
 func (r *SessionRepository) RevokeRefreshToken(ctx context.Context, tokenHash string) error {
@@ -39,6 +97,14 @@ func (r *SessionRepository) RevokeRefreshToken(ctx context.Context, tokenHash st
 	return err
 }
 
+// RevokeFamily revokes every token descended from the same login.
+func (r *SessionRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE refresh_tokens SET revoked = TRUE WHERE family_id = $1 AND revoked = FALSE
+	`, familyID)
+	return err
+}
+
 func (r *SessionRepository) RevokeAllForPlayer(ctx context.Context, playerID string) error {
 	_, err := r.pool.Exec(ctx, `
 		UPDATE refresh_tokens SET revoked = TRUE WHERE player_id = $1
@@ -46,7 +112,76 @@ func (r *SessionRepository) RevokeAllForPlayer(ctx context.Context, playerID str
 	return err
 }
 
+// ListActiveForPlayer returns the player's active sessions, most recently used first.
+// Each family has at most one live token, whose family ID is used as the session ID.
+func (r *SessionRepository) ListActiveForPlayer(ctx context.Context, playerID string) ([]model.Session, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT family_id, device_label, ip_address, user_agent, session_started_at, last_used_at, expires_at
+		FROM refresh_tokens
+		WHERE player_id = $1 AND revoked = FALSE AND expires_at > NOW()
+		ORDER BY last_used_at DESC
+	`, playerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []model.Session
+	for rows.Next() {
+		var s model.Session
+		if err := rows.Scan(&s.ID, &s.DeviceLabel, &s.IPAddress, &s.UserAgent, &s.CreatedAt, &s.LastUsedAt, &s.ExpiresAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, nil
+}
+
+// RevokeByID revokes one of the player's sessions (a whole token family).
+// Returns false if no active token matched.
+func (r *SessionRepository) RevokeByID(ctx context.Context, playerID, sessionID string) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE refresh_tokens SET revoked = TRUE
+		WHERE player_id = $2 AND family_id::text = $1 AND revoked = FALSE
+	`, sessionID, playerID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// CleanupExpired deletes expired tokens. Revoked tokens are kept until they
+// expire so that reuse of a rotated token can still be detected.
 func (r *SessionRepository) CleanupExpired(ctx context.Context) error {
-	_, err := r.pool.Exec(ctx, `DELETE FROM refresh_tokens WHERE expires_at < NOW() OR revoked = TRUE`)
+	_, err := r.pool.Exec(ctx, `DELETE FROM refresh_tokens WHERE expires_at < NOW()`)
 	return err
 }
+
+// dbtx is satisfied by both *pgxpool.Pool and pgx.Tx.
+type dbtx interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+func insertRefreshToken(ctx context.Context, db dbtx, playerID string, familyID *string, tokenHash string, expiresAt time.Time, meta model.SessionMeta) (*model.RefreshTokenRecord, error) {
+	startedAt := meta.StartedAt
+	if startedAt.IsZero() {
+		startedAt = time.Now()
+	}
+	rec := &model.RefreshTokenRecord{
+		PlayerID:         playerID,
+		DeviceLabel:      meta.DeviceLabel,
+		SessionStartedAt: startedAt,
+	}
+	err := db.QueryRow(ctx, `
+		INSERT INTO refresh_tokens (id, family_id, player_id, token_hash, expires_at,
+		                            device_label, ip_address, user_agent, session_started_at)
+		SELECT v.id, COALESCE($2::uuid, v.id), $1, $3, $4, $5, $6, $7, $8
+		FROM (SELECT uuid_generate_v4() AS id) v
+		RETURNING id, family_id
+	`, playerID, familyID, tokenHash, expiresAt, meta.DeviceLabel, meta.IPAddress, meta.UserAgent, startedAt).Scan(&rec.ID, &rec.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
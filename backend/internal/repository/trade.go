@@ -0,0 +1,459 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"spacegame-backend/internal/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	// ErrTradeClosed is returned when a trade is no longer open (completed, cancelled or expired).
+	ErrTradeClosed = errors.New("trade is not open")
+	// ErrTradeRevision is returned when a confirmation was given for an older revision.
+	ErrTradeRevision = errors.New("trade offers changed")
+	// ErrInsufficientItems is returned when a player doesn't hold the items they offered.
+	ErrInsufficientItems = errors.New("insufficient items")
+)
+
+const tradeColumns = `
+	id, status, revision,
+	initiator_id, initiator_name, initiator_credits, initiator_confirmed,
+	partner_id, partner_name, partner_credits, partner_confirmed,
+	created_at, updated_at, expires_at, closed_at`
+
+// TradeRepository stores player-to-player trades and performs the swap.
+type TradeRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewTradeRepository(pool *pgxpool.Pool) *TradeRepository {
+	return &TradeRepository{pool: pool}
+}
+
+// scanTrade reads a row of tradeColumns. An open trade past its expiry is reported as
+// expired even before ExpireOld closed it.
+func scanTrade(row pgx.Row) (*model.Trade, error) {
+	t := &model.Trade{}
+	err := row.Scan(
+		&t.ID, &t.Status, &t.Revision,
+		&t.Initiator.PlayerID, &t.Initiator.Username, &t.Initiator.Credits, &t.Initiator.Confirmed,
+		&t.Partner.PlayerID, &t.Partner.Username, &t.Partner.Credits, &t.Partner.Confirmed,
+		&t.CreatedAt, &t.UpdatedAt, &t.ExpiresAt, &t.ClosedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if t.Status == model.TradeOpen && t.ExpiresAt.Before(time.Now()) {
+		t.Status = model.TradeExpired
+	}
+	t.Initiator.Items = []model.TradeItem{}
+	t.Partner.Items = []model.TradeItem{}
+	return t, nil
+}
+
+// loadTradeItems fills in the offered items of the trades.
+func loadTradeItems(ctx context.Context, db dbtx, trades ...*model.Trade) error {
+	if len(trades) == 0 {
+		return nil
+	}
+	byID := make(map[int64]*model.Trade, len(trades))
+	ids := make([]int64, len(trades))
+	for i, t := range trades {
+		byID[t.ID] = t
+		ids[i] = t.ID
+	}
+
+	rows, err := db.Query(ctx, `
+		SELECT trade_id, side, source, category, item_name, item_type, icon_color, quantity
+		FROM trade_items
+		WHERE trade_id = ANY($1)
+		ORDER BY id
+	`, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tradeID int64
+		var side string
+		var item model.TradeItem
+		if err := rows.Scan(&tradeID, &side, &item.Source, &item.Category, &item.ItemName, &item.ItemType, &item.IconColor, &item.Quantity); err != nil {
+			return err
+		}
+		t := byID[tradeID]
+		if side == model.TradeSideInitiator {
+			t.Initiator.Items = append(t.Initiator.Items, item)
+		} else {
+			t.Partner.Items = append(t.Partner.Items, item)
+		}
+	}
+	return rows.Err()
+}
+
+// Create opens an empty trade. A second open trade between the same players fails
+// with a unique violation.
+func (r *TradeRepository) Create(ctx context.Context, initiatorID, initiatorName, partnerID, partnerName string, expiresAt time.Time) (*model.Trade, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// A trade between the two past its expiry but not yet closed by ExpireOld would
+	// still hold idx_trades_open_pair
+	_, err = tx.Exec(ctx, `
+		UPDATE trades SET status = 'expired', closed_at = expires_at, updated_at = NOW()
+		WHERE status = 'open' AND expires_at <= NOW()
+		  AND LEAST(initiator_id, partner_id) = LEAST($1::uuid, $2::uuid)
+		  AND GREATEST(initiator_id, partner_id) = GREATEST($1::uuid, $2::uuid)
+	`, initiatorID, partnerID)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := scanTrade(tx.QueryRow(ctx, `
+		INSERT INTO trades (initiator_id, initiator_name, partner_id, partner_name, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+tradeColumns,
+		initiatorID, initiatorName, partnerID, partnerName, expiresAt))
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (r *TradeRepository) GetByID(ctx context.Context, id int64) (*model.Trade, error) {
+	t, err := scanTrade(r.pool.QueryRow(ctx, `SELECT `+tradeColumns+` FROM trades WHERE id = $1`, id))
+	if err != nil {
+		return nil, err
+	}
+	return t, loadTradeItems(ctx, r.pool, t)
+}
+
+// ListForPlayer returns the player's open trades, or their closed ones (the trade log),
+// newest first. limit 0 returns all of them.
+func (r *TradeRepository) ListForPlayer(ctx context.Context, playerID string, open bool, limit int) ([]model.Trade, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+tradeColumns+`
+		FROM trades
+		WHERE (initiator_id = $1 OR partner_id = $1) AND (status = 'open') = $2
+		ORDER BY created_at DESC
+		LIMIT NULLIF($3, 0)
+	`, playerID, open, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	trades := []model.Trade{}
+	for rows.Next() {
+		t, err := scanTrade(rows)
+		if err != nil {
+			return nil, err
+		}
+		trades = append(trades, *t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	ptrs := make([]*model.Trade, len(trades))
+	for i := range trades {
+		ptrs[i] = &trades[i]
+	}
+	return trades, loadTradeItems(ctx, r.pool, ptrs...)
+}
+
+// CountOpen counts the open trades the player is part of.
+func (r *TradeRepository) CountOpen(ctx context.Context, playerID string) (int, error) {
+	var n int
+	err := r.pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM trades
+		WHERE (initiator_id = $1 OR partner_id = $1) AND status = 'open' AND expires_at > NOW()
+	`, playerID).Scan(&n)
+	return n, err
+}
+
+// lockOpenTx locks a trade and fails with ErrTradeClosed when it is no longer open.
+func lockOpenTx(ctx context.Context, tx pgx.Tx, id int64) (*model.Trade, error) {
+	t, err := scanTrade(tx.QueryRow(ctx, `SELECT `+tradeColumns+` FROM trades WHERE id = $1 FOR UPDATE`, id))
+	if err != nil {
+		return nil, err
+	}
+	if t.Status != model.TradeOpen {
+		return nil, ErrTradeClosed
+	}
+	return t, nil
+}
+
+// SetOffer replaces one side's offer, clears both confirmations, bumps the revision and
+// pushes the expiry back. The player must hold what they offer; the item_type and
+// icon_color of cargo items are copied from their cargo.
+func (r *TradeRepository) SetOffer(ctx context.Context, id int64, side string, credits int64, items []model.TradeItem, expiresAt time.Time) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	t, err := lockOpenTx(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	ownerID := t.Initiator.PlayerID
+	if side == model.TradeSidePartner {
+		ownerID = t.Partner.PlayerID
+	}
+	if err := holdsTx(ctx, tx, ownerID, credits, items); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE trades SET
+			`+side+`_credits = $2,
+			initiator_confirmed = FALSE, partner_confirmed = FALSE,
+			revision = revision + 1, updated_at = NOW(), expires_at = $3
+		WHERE id = $1
+	`, id, credits, expiresAt)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM trade_items WHERE trade_id = $1 AND side = $2`, id, side); err != nil {
+		return err
+	}
+	for _, item := range items {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO trade_items (trade_id, side, source, category, item_name, item_type, icon_color, quantity)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`, id, side, item.Source, item.Category, item.ItemName, item.ItemType, item.IconColor, item.Quantity)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// holdsTx checks that the player has the credits and items. Cargo items get the
+// item_type and icon_color of the player's cargo.
+func holdsTx(ctx context.Context, tx pgx.Tx, playerID string, credits int64, items []model.TradeItem) error {
+	var balance int64
+	if err := tx.QueryRow(ctx, `SELECT credits FROM players WHERE id = $1`, playerID).Scan(&balance); err != nil {
+		return err
+	}
+	if balance < credits {
+		return ErrInsufficientCredits
+	}
+
+	for i := range items {
+		item := &items[i]
+		var held int
+		var err error
+		if item.Source == model.TradeSourceCargo {
+			err = tx.QueryRow(ctx, `
+				SELECT item_type, icon_color, quantity FROM player_cargo WHERE player_id = $1 AND item_name = $2
+			`, playerID, item.ItemName).Scan(&item.ItemType, &item.IconColor, &held)
+		} else {
+			err = tx.QueryRow(ctx, `
+				SELECT quantity FROM player_inventory WHERE player_id = $1 AND category = $2 AND item_name = $3
+			`, playerID, item.Category, item.ItemName).Scan(&held)
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrInsufficientItems
+		}
+		if err != nil {
+			return err
+		}
+		if held < item.Quantity {
+			return ErrInsufficientItems
+		}
+	}
+	return nil
+}
+
+// Confirm records that side accepts the given revision of the offers. When the other
+// side already confirmed it, the swap is executed in the same transaction; completed
+// tells whether it was. Failures leave the trade as it was (including this confirmation).
+func (r *TradeRepository) Confirm(ctx context.Context, id int64, side string, revision int) (completed bool, err error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	t, err := lockOpenTx(ctx, tx, id)
+	if err != nil {
+		return false, err
+	}
+	if t.Revision != revision {
+		return false, ErrTradeRevision
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE trades SET `+side+`_confirmed = TRUE, updated_at = NOW() WHERE id = $1`, id)
+	if err != nil {
+		return false, err
+	}
+	otherConfirmed := t.Partner.Confirmed
+	if side == model.TradeSidePartner {
+		otherConfirmed = t.Initiator.Confirmed
+	}
+	if otherConfirmed {
+		if err := loadTradeItems(ctx, tx, t); err != nil {
+			return false, err
+		}
+		if err := executeTradeTx(ctx, tx, t); err != nil {
+			return false, err
+		}
+	}
+	return otherConfirmed, tx.Commit(ctx)
+}
+
+// executeTradeTx swaps the offers and closes the trade. Both players are locked (in a
+// fixed order, like any other writer of their state) and their save_version is bumped,
+// so a client still holding the pre-trade state can't overwrite it.
+func executeTradeTx(ctx context.Context, tx pgx.Tx, t *model.Trade) error {
+	ids := []string{t.Initiator.PlayerID, t.Partner.PlayerID}
+	if _, err := tx.Exec(ctx, `SELECT id FROM players WHERE id = ANY($1::uuid[]) ORDER BY id FOR UPDATE`, ids); err != nil {
+		return err
+	}
+
+	ref := strconv.FormatInt(t.ID, 10)
+	for _, give := range []struct{ from, to *model.TradeOffer }{
+		{&t.Initiator, &t.Partner},
+		{&t.Partner, &t.Initiator},
+	} {
+		for _, item := range give.from.Items {
+			if err := moveItemTx(ctx, tx, give.from.PlayerID, give.to.PlayerID, item); err != nil {
+				return err
+			}
+		}
+		if give.from.Credits > 0 {
+			if _, err := addCreditsTx(ctx, tx, give.from.PlayerID, -give.from.Credits, model.CreditReasonTrade, ref); err != nil {
+				return err
+			}
+			if _, err := addCreditsTx(ctx, tx, give.to.PlayerID, give.from.Credits, model.CreditReasonTrade, ref); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err := tx.Exec(ctx, `UPDATE players SET save_version = save_version + 1, updated_at = NOW() WHERE id = ANY($1::uuid[])`, ids)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `
+		UPDATE trades SET status = 'completed', closed_at = NOW(), updated_at = NOW() WHERE id = $1
+	`, t.ID)
+	return err
+}
+
+// moveItemTx takes quantity of an item from one player and gives it to the other.
+func moveItemTx(ctx context.Context, tx pgx.Tx, fromID, toID string, item model.TradeItem) error {
+	if err := takeItemTx(ctx, tx, fromID, &item); err != nil {
+		return err
+	}
+	return giveItemTx(ctx, tx, toID, item)
+}
+
+// takeItemTx removes quantity of an item from the player, failing with
+// ErrInsufficientItems if they hold less. Cargo items get the item_type and icon_color
+// of the player's cargo.
+func takeItemTx(ctx context.Context, tx pgx.Tx, playerID string, item *model.TradeItem) error {
+	if item.Source == model.TradeSourceCargo {
+		err := tx.QueryRow(ctx, `
+			UPDATE player_cargo SET quantity = quantity - $3
+			WHERE player_id = $1 AND item_name = $2 AND quantity >= $3
+			RETURNING item_type, icon_color
+		`, playerID, item.ItemName, item.Quantity).Scan(&item.ItemType, &item.IconColor)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrInsufficientItems
+		}
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `DELETE FROM player_cargo WHERE player_id = $1 AND item_name = $2 AND quantity <= 0`, playerID, item.ItemName)
+		return err
+	}
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE player_inventory SET quantity = quantity - $4
+		WHERE player_id = $1 AND category = $2 AND item_name = $3 AND quantity >= $4
+	`, playerID, item.Category, item.ItemName, item.Quantity)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrInsufficientItems
+	}
+	_, err = tx.Exec(ctx, `
+		DELETE FROM player_inventory WHERE player_id = $1 AND category = $2 AND item_name = $3 AND quantity <= 0
+	`, playerID, item.Category, item.ItemName)
+	return err
+}
+
+// giveItemTx adds quantity of an item to the player.
+func giveItemTx(ctx context.Context, tx pgx.Tx, playerID string, item model.TradeItem) error {
+	if item.Source == model.TradeSourceCargo {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO player_cargo (player_id, item_name, item_type, quantity, icon_color)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (player_id, item_name) DO UPDATE SET quantity = player_cargo.quantity + EXCLUDED.quantity
+		`, playerID, item.ItemName, item.ItemType, item.Quantity, item.IconColor)
+		return err
+	}
+	_, err := tx.Exec(ctx, `
+		INSERT INTO player_inventory (player_id, category, item_name, quantity)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (player_id, category, item_name) DO UPDATE SET quantity = player_inventory.quantity + EXCLUDED.quantity
+	`, playerID, item.Category, item.ItemName, item.Quantity)
+	return err
+}
+
+// Cancel closes an open trade the player is part of. Returns false when there is none.
+func (r *TradeRepository) Cancel(ctx context.Context, id int64, playerID string) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE trades SET status = 'cancelled', closed_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = 'open' AND $2 IN (initiator_id, partner_id)
+	`, id, playerID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// ExpireOld closes the open trades past their expiry.
+func (r *TradeRepository) ExpireOld(ctx context.Context) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE trades SET status = 'expired', closed_at = expires_at, updated_at = NOW()
+		WHERE status = 'open' AND expires_at <= NOW()
+	`)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// AnonymisePlayerTx cancels the open trades of a deleted player and replaces the
+// copies of their name in the trade log.
+func (r *TradeRepository) AnonymisePlayerTx(ctx context.Context, tx pgx.Tx, playerID, anonName string) error {
+	if _, err := tx.Exec(ctx, `
+		UPDATE trades SET status = 'cancelled', closed_at = NOW(), updated_at = NOW()
+		WHERE (initiator_id = $1 OR partner_id = $1) AND status = 'open'
+	`, playerID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE trades SET initiator_name = $2 WHERE initiator_id = $1`, playerID, anonName); err != nil {
+		return err
+	}
+	_, err := tx.Exec(ctx, `UPDATE trades SET partner_name = $2 WHERE partner_id = $1`, playerID, anonName)
+	return err
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type TwoFactorRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewTwoFactorRepository(pool *pgxpool.Pool) *TwoFactorRepository {
+	return &TwoFactorRepository{pool: pool}
+}
+
+// GetSecret returns the player's TOTP secret ("" if none) and whether 2FA is enabled.
+func (r *TwoFactorRepository) GetSecret(ctx context.Context, playerID string) (string, bool, error) {
+	var secret *string
+	var enabled bool
+	err := r.pool.QueryRow(ctx, `
+		SELECT totp_secret, totp_enabled_at IS NOT NULL FROM players WHERE id = $1
+	`, playerID).Scan(&secret, &enabled)
+	if err != nil {
+		return "", false, err
+	}
+	if secret == nil {
+		return "", enabled, nil
+	}
+	return *secret, enabled, nil
+}
+
+// SetPendingSecret stores a secret awaiting confirmation. Does nothing once 2FA is enabled.
+func (r *TwoFactorRepository) SetPendingSecret(ctx context.Context, playerID, secret string) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE players SET totp_secret = $2, totp_last_step = 0, updated_at = NOW()
+		WHERE id = $1 AND totp_enabled_at IS NULL
+	`, playerID, secret)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// Enable turns on 2FA and replaces the player's recovery codes.
+func (r *TwoFactorRepository) Enable(ctx context.Context, playerID string, codeHashes []string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE players SET totp_enabled_at = NOW(), updated_at = NOW() WHERE id = $1
+	`, playerID); err != nil {
+		return err
+	}
+	if err := replaceRecoveryCodes(ctx, tx, playerID, codeHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Disable turns off 2FA, forgetting the secret and recovery codes.
+func (r *TwoFactorRepository) Disable(ctx context.Context, playerID string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE players SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = 0, updated_at = NOW()
+		WHERE id = $1
+	`, playerID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM totp_recovery_codes WHERE player_id = $1`, playerID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// UseStep records step as the last accepted time step. Returns false if that step
+// (or a later one) was already used, i.e. the code is being replayed.
+func (r *TwoFactorRepository) UseStep(ctx context.Context, playerID string, step int64) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE players SET totp_last_step = $2 WHERE id = $1 AND totp_last_step < $2
+	`, playerID, step)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// ConsumeRecoveryCode marks an unused recovery code as used. Returns false if it doesn't match.
+func (r *TwoFactorRepository) ConsumeRecoveryCode(ctx context.Context, playerID, codeHash string) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE totp_recovery_codes SET used_at = NOW()
+		WHERE id = (
+			SELECT id FROM totp_recovery_codes
+			WHERE player_id = $1 AND code_hash = $2 AND used_at IS NULL
+			LIMIT 1
+		)
+	`, playerID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// ReplaceRecoveryCodes discards all existing recovery codes and stores new ones.
+func (r *TwoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, playerID string, codeHashes []string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := replaceRecoveryCodes(ctx, tx, playerID, codeHashes); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// CountRecoveryCodes returns how many unused recovery codes the player has left.
+func (r *TwoFactorRepository) CountRecoveryCodes(ctx context.Context, playerID string) (int, error) {
+	var n int
+	err := r.pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM totp_recovery_codes WHERE player_id = $1 AND used_at IS NULL
+	`, playerID).Scan(&n)
+	return n, err
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, playerID string, codeHashes []string) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM totp_recovery_codes WHERE player_id = $1`, playerID); err != nil {
+		return err
+	}
+	_, err := tx.Exec(ctx, `
+		INSERT INTO totp_recovery_codes (player_id, code_hash)
+		SELECT $1, UNNEST($2::text[])
+	`, playerID, codeHashes)
+	return err
+}
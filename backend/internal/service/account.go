@@ -0,0 +1,331 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"spacegame-backend/internal/model"
+	"spacegame-backend/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const accountDeletionGrace = 14 * 24 * time.Hour
+
+var ErrDeletionNotScheduled = errors.New("no account deletion is scheduled")
+
+// AccountService handles the player's personal data: export and self-service deletion.
+// Deletion is delayed by a grace period, then the player row is anonymised in place
+// (other players' market and corporation history keep pointing at it).
+type AccountService struct {
+	playerRepo       *repository.PlayerRepository
+	sessionRepo      *repository.SessionRepository
+	loginAttemptRepo *repository.LoginAttemptRepository
+	banRepo          *repository.BanRepository
+	fleetRepo        *repository.FleetRepository
+	marketRepo       *repository.MarketRepository
+	chatRepo         *repository.ChatRepository
+	corpRepo         *repository.CorporationRepository
+	discordRepo      *repository.DiscordRepository
+	eventRepo        *repository.EventRepository
+	ledgerRepo       *repository.CreditLedgerRepository
+	playSessionRepo  *repository.PlaySessionRepository
+	friendRepo       *repository.FriendRepository
+	tradeRepo        *repository.TradeRepository
+	mailRepo         *repository.MailRepository
+	authSvc          *AuthService
+	eventSvc         *EventService
+	achievementSvc   *AchievementService
+	wsHub            *WSHub
+	mailer           Mailer
+}
+
+func NewAccountService(
+	playerRepo *repository.PlayerRepository,
+	sessionRepo *repository.SessionRepository,
+	loginAttemptRepo *repository.LoginAttemptRepository,
+	banRepo *repository.BanRepository,
+	fleetRepo *repository.FleetRepository,
+	marketRepo *repository.MarketRepository,
+	chatRepo *repository.ChatRepository,
+	corpRepo *repository.CorporationRepository,
+	discordRepo *repository.DiscordRepository,
+	eventRepo *repository.EventRepository,
+	ledgerRepo *repository.CreditLedgerRepository,
+	playSessionRepo *repository.PlaySessionRepository,
+	friendRepo *repository.FriendRepository,
+	tradeRepo *repository.TradeRepository,
+	mailRepo *repository.MailRepository,
+	authSvc *AuthService,
+	eventSvc *EventService,
+	achievementSvc *AchievementService,
+	wsHub *WSHub,
+	mailer Mailer,
+) *AccountService {
+	return &AccountService{
+		playerRepo:       playerRepo,
+		sessionRepo:      sessionRepo,
+		loginAttemptRepo: loginAttemptRepo,
+		banRepo:          banRepo,
+		fleetRepo:        fleetRepo,
+		marketRepo:       marketRepo,
+		chatRepo:         chatRepo,
+		corpRepo:         corpRepo,
+		discordRepo:      discordRepo,
+		eventRepo:        eventRepo,
+		ledgerRepo:       ledgerRepo,
+		playSessionRepo:  playSessionRepo,
+		friendRepo:       friendRepo,
+		tradeRepo:        tradeRepo,
+		mailRepo:         mailRepo,
+		authSvc:          authSvc,
+		eventSvc:         eventSvc,
+		achievementSvc:   achievementSvc,
+		wsHub:            wsHub,
+		mailer:           mailer,
+	}
+}
+
+// Export gathers everything stored about the player into one archive.
+func (s *AccountService) Export(ctx context.Context, playerID string) (*model.AccountExport, error) {
+	player, err := s.playerRepo.GetByID(ctx, playerID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &model.AccountExport{ExportedAt: time.Now().UTC(), Account: player}
+
+	if out.DiscordID, err = s.discordRepo.GetDiscordID(ctx, playerID); err != nil {
+		return nil, fmt.Errorf("discord: %w", err)
+	}
+	if out.State, err = s.playerRepo.GetFullState(ctx, playerID); err != nil {
+		return nil, fmt.Errorf("state: %w", err)
+	}
+	if out.FleetShips, err = s.fleetRepo.GetPlayerFleet(ctx, playerID); err != nil {
+		return nil, fmt.Errorf("fleet: %w", err)
+	}
+	if out.MarketListings, err = s.marketRepo.GetBySellerID(ctx, playerID, "all"); err != nil {
+		return nil, fmt.Errorf("market listings: %w", err)
+	}
+	if out.MarketPurchases, err = s.marketRepo.GetByBuyerID(ctx, playerID); err != nil {
+		return nil, fmt.Errorf("market purchases: %w", err)
+	}
+	if out.ChatMessages, err = s.chatRepo.GetBySender(ctx, player.Username); err != nil {
+		return nil, fmt.Errorf("chat: %w", err)
+	}
+	member, err := s.corpRepo.GetMember(ctx, playerID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("corporation: %w", err)
+	}
+	out.Corporation = member
+	if out.Applications, err = s.corpRepo.GetPlayerApplicationHistory(ctx, playerID); err != nil {
+		return nil, fmt.Errorf("corporation applications: %w", err)
+	}
+	if out.Transactions, err = s.corpRepo.GetPlayerTransactions(ctx, playerID); err != nil {
+		return nil, fmt.Errorf("corporation transactions: %w", err)
+	}
+	if out.Activity, err = s.corpRepo.GetActivityByName(ctx, player.Username); err != nil {
+		return nil, fmt.Errorf("corporation activity: %w", err)
+	}
+	if out.Sessions, err = s.sessionRepo.ListActiveForPlayer(ctx, playerID); err != nil {
+		return nil, fmt.Errorf("sessions: %w", err)
+	}
+	if out.LoginAttempts, err = s.loginAttemptRepo.ListForPlayer(ctx, playerID, 0); err != nil {
+		return nil, fmt.Errorf("login attempts: %w", err)
+	}
+	if out.Bans, err = s.banRepo.ListForPlayer(ctx, playerID); err != nil {
+		return nil, fmt.Errorf("bans: %w", err)
+	}
+	if out.CreditLedger, err = s.ledgerRepo.ListForPlayer(ctx, playerID, 0, 0); err != nil {
+		return nil, fmt.Errorf("credit ledger: %w", err)
+	}
+	if out.PlaySessions, err = s.playSessionRepo.ListForPlayer(ctx, playerID, 0); err != nil {
+		return nil, fmt.Errorf("play sessions: %w", err)
+	}
+	if out.Achievements, err = s.achievementSvc.Unlocked(ctx, playerID); err != nil {
+		return nil, fmt.Errorf("achievements: %w", err)
+	}
+	if out.Friends, err = s.friendRepo.ListContacts(ctx, playerID, model.RelationFriend, false); err != nil {
+		return nil, fmt.Errorf("friends: %w", err)
+	}
+	if out.FriendRequests, err = s.friendRepo.ListContacts(ctx, playerID, model.RelationPending, false); err != nil {
+		return nil, fmt.Errorf("friend requests: %w", err)
+	}
+	if out.BlockedPlayers, err = s.friendRepo.ListContacts(ctx, playerID, model.RelationBlocked, false); err != nil {
+		return nil, fmt.Errorf("blocked players: %w", err)
+	}
+	if out.OpenTrades, err = s.tradeRepo.ListForPlayer(ctx, playerID, true, 0); err != nil {
+		return nil, fmt.Errorf("open trades: %w", err)
+	}
+	if out.Trades, err = s.tradeRepo.ListForPlayer(ctx, playerID, false, 0); err != nil {
+		return nil, fmt.Errorf("trades: %w", err)
+	}
+	if out.Mail, err = s.mailRepo.ListInbox(ctx, playerID, false, 0); err != nil {
+		return nil, fmt.Errorf("mail: %w", err)
+	}
+
+	return out, nil
+}
+
+// DeletionStatus reports whether a deletion is pending.
+func (s *AccountService) DeletionStatus(ctx context.Context, playerID string) (*model.AccountDeletionStatus, error) {
+	at, err := s.playerRepo.GetDeletionSchedule(ctx, playerID)
+	if err != nil {
+		return nil, err
+	}
+	return &model.AccountDeletionStatus{Scheduled: at != nil, ScheduledAt: at}, nil
+}
+
+// RequestDeletion schedules the account for deletion after the grace period.
+// Requires the password, and a 2FA code when enabled.
+func (s *AccountService) RequestDeletion(ctx context.Context, playerID, password, code string) (*model.AccountDeletionStatus, error) {
+	if err := s.authSvc.Reauthenticate(ctx, playerID, password, code); err != nil {
+		return nil, err
+	}
+	player, err := s.playerRepo.GetByID(ctx, playerID)
+	if err != nil {
+		return nil, err
+	}
+
+	at := time.Now().Add(accountDeletionGrace).UTC()
+	if err := s.playerRepo.ScheduleDeletion(ctx, playerID, at); err != nil {
+		return nil, err
+	}
+
+	s.eventSvc.RecordSecurityEvent(ctx, "account_deletion_requested", player.Username, map[string]string{
+		"scheduled_at": at.Format(time.RFC3339),
+	})
+	s.sendMail(player.Email, "Imperion Online — Suppression du compte programmée", fmt.Sprintf(`Bonjour %s,
+
+La suppression de ton compte Imperion Online a été demandée. Elle aura lieu le %s (UTC).
+
+Tu peux l'annuler d'ici là en te connectant au jeu ou au site.
+Si tu n'es pas à l'origine de cette demande, annule-la et change ton mot de passe.
+`, player.Username, at.Format("02/01/2006 15:04")))
+
+	return &model.AccountDeletionStatus{Scheduled: true, ScheduledAt: &at}, nil
+}
+
+// CancelDeletion aborts a pending deletion.
+func (s *AccountService) CancelDeletion(ctx context.Context, playerID string) error {
+	ok, err := s.playerRepo.CancelDeletion(ctx, playerID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrDeletionNotScheduled
+	}
+
+	if player, err := s.playerRepo.GetByID(ctx, playerID); err == nil {
+		s.eventSvc.RecordSecurityEvent(ctx, "account_deletion_cancelled", player.Username, nil)
+	}
+	return nil
+}
+
+// ProcessDueDeletions anonymises every account whose grace period is over.
+func (s *AccountService) ProcessDueDeletions(ctx context.Context) (int, error) {
+	ids, err := s.playerRepo.ListDueDeletions(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, id := range ids {
+		if err := s.deleteAccount(ctx, id); err != nil {
+			log.Printf("[ACCOUNT] failed to delete account %s: %v", id, err)
+			continue
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+func (s *AccountService) deleteAccount(ctx context.Context, playerID string) error {
+	player, err := s.playerRepo.GetByID(ctx, playerID)
+	if err != nil {
+		return err
+	}
+	anonName := "deleted-" + playerID[:8]
+
+	tx, err := s.playerRepo.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// A leader hands the corporation over to its next-ranked member; the last one dissolves it
+	var promoted string
+	var disbanded bool
+	if player.CorporationID != nil {
+		if promoted, disbanded, err = s.corpRepo.LeaveTx(ctx, tx, playerID, *player.CorporationID, anonName); err != nil {
+			return fmt.Errorf("leave corporation: %w", err)
+		}
+	}
+
+	if err := s.fleetRepo.DeletePlayerFleetTx(ctx, tx, playerID); err != nil {
+		return fmt.Errorf("fleet: %w", err)
+	}
+	if err := s.marketRepo.AnonymisePlayerTx(ctx, tx, playerID, anonName); err != nil {
+		return fmt.Errorf("market: %w", err)
+	}
+	if err := s.chatRepo.DeleteBySenderTx(ctx, tx, player.Username); err != nil {
+		return fmt.Errorf("chat: %w", err)
+	}
+	if err := s.corpRepo.AnonymisePlayerTx(ctx, tx, playerID, player.Username, anonName); err != nil {
+		return fmt.Errorf("corporation: %w", err)
+	}
+	if err := s.eventRepo.AnonymiseNameTx(ctx, tx, player.Username, anonName); err != nil {
+		return fmt.Errorf("events: %w", err)
+	}
+	if err := s.friendRepo.DeleteForPlayerTx(ctx, tx, playerID); err != nil {
+		return fmt.Errorf("friends: %w", err)
+	}
+	if err := s.tradeRepo.AnonymisePlayerTx(ctx, tx, playerID, anonName); err != nil {
+		return fmt.Errorf("trades: %w", err)
+	}
+	if err := s.mailRepo.AnonymisePlayerTx(ctx, tx, playerID, anonName); err != nil {
+		return fmt.Errorf("mail: %w", err)
+	}
+	if err := s.playerRepo.AnonymiseTx(ctx, tx, playerID, anonName); err != nil {
+		return fmt.Errorf("player: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	if disbanded {
+		log.Printf("[ACCOUNT] dissolved corporation %s (its last member %s was deleted)", *player.CorporationID, playerID)
+	} else if promoted != "" {
+		log.Printf("[ACCOUNT] %s now leads corporation %s (leader %s was deleted)", promoted, *player.CorporationID, playerID)
+	}
+
+	data, _ := json.Marshal(map[string]string{"reason": "account deleted"})
+	s.wsHub.DisconnectPlayer(playerID, &model.WSEvent{Type: "account:deleted", Data: data})
+
+	s.eventSvc.RecordSecurityEvent(ctx, "account_deleted", anonName, nil)
+	s.sendMail(player.Email, "Imperion Online — Compte supprimé", fmt.Sprintf(`Bonjour %s,
+
+Ton compte Imperion Online a été supprimé, ainsi que tes données personnelles.
+
+Merci d'avoir joué !
+`, player.Username))
+
+	log.Printf("[ACCOUNT] deleted account %s (now %s)", playerID, anonName)
+	return nil
+}
+
+func (s *AccountService) sendMail(to, subject, body string) {
+	if s.mailer == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), mailSendTimeout)
+		defer cancel()
+		if err := s.mailer.Send(ctx, to, subject, body); err != nil {
+			log.Printf("[ACCOUNT] mail to %s failed: %v", to, err)
+		}
+	}()
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"sort"
+	"time"
+
+	"spacegame-backend/internal/model"
+	"spacegame-backend/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// achievementEvalTimeout bounds a background evaluation (see EvaluateByNameLater).
+const achievementEvalTimeout = 10 * time.Second
+
+// AchievementService unlocks achievements (model.Achievements) when a player statistic
+// may have changed: the services recording kills, discoveries, market sales and
+// corporation joins call Evaluate with that statistic. Unlocks are pushed over WebSocket.
+type AchievementService struct {
+	repo       *repository.AchievementRepository
+	playerRepo *repository.PlayerRepository
+	wsHub      *WSHub
+}
+
+func NewAchievementService(repo *repository.AchievementRepository, playerRepo *repository.PlayerRepository, wsHub *WSHub) *AchievementService {
+	return &AchievementService{repo: repo, playerRepo: playerRepo, wsHub: wsHub}
+}
+
+// Evaluate unlocks the player's achievements on stat that are now reached. Failures are
+// logged, never returned: the action that triggered them already happened.
+func (s *AchievementService) Evaluate(ctx context.Context, playerID, stat string) {
+	player, err := s.playerRepo.GetByID(ctx, playerID)
+	if err != nil {
+		log.Printf("[ACHIEVEMENT] failed to load player %s: %v", playerID, err)
+		return
+	}
+	s.evaluate(ctx, player, stat)
+}
+
+// EvaluateByName is Evaluate for game events, which name players. Unknown names (NPCs)
+// are ignored.
+func (s *AchievementService) EvaluateByName(ctx context.Context, username, stat string) {
+	if username == "" {
+		return
+	}
+	player, err := s.playerRepo.GetByUsername(ctx, username)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return
+	}
+	if err != nil {
+		log.Printf("[ACHIEVEMENT] failed to load player %s: %v", username, err)
+		return
+	}
+	s.evaluate(ctx, player, stat)
+}
+
+// EvaluateByNameLater runs EvaluateByName in the background, keeping the statistic
+// queries off the game server's event request.
+func (s *AchievementService) EvaluateByNameLater(username, stat string) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), achievementEvalTimeout)
+		defer cancel()
+		s.EvaluateByName(ctx, username, stat)
+	}()
+}
+
+func (s *AchievementService) evaluate(ctx context.Context, player *model.Player, stat string) {
+	unlocked, err := s.repo.ListForPlayer(ctx, player.ID)
+	if err != nil {
+		log.Printf("[ACHIEVEMENT] failed to list achievements of %s: %v", player.Username, err)
+		return
+	}
+	var pending []model.Achievement
+	for _, a := range model.Achievements {
+		if _, ok := unlocked[a.ID]; !ok && a.Stat == stat {
+			pending = append(pending, a)
+		}
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	value, err := s.repo.Stat(ctx, player.ID, player.Username, stat)
+	if err != nil {
+		log.Printf("[ACHIEVEMENT] failed to compute %s of %s: %v", stat, player.Username, err)
+		return
+	}
+	for _, a := range pending {
+		if value < a.Goal {
+			continue
+		}
+		ok, err := s.repo.Unlock(ctx, player.ID, a.ID)
+		if err != nil {
+			log.Printf("[ACHIEVEMENT] failed to unlock %s for %s: %v", a.ID, player.Username, err)
+			continue
+		}
+		if !ok {
+			continue // unlocked concurrently
+		}
+		log.Printf("[ACHIEVEMENT] %s unlocked %s", player.Username, a.ID)
+		data, _ := json.Marshal(a)
+		s.wsHub.SendToPlayer(player.ID, &model.WSEvent{Type: "achievement:unlocked", Data: data})
+	}
+}
+
+// ForPlayer returns every achievement with the player's unlock time, if any.
+func (s *AchievementService) ForPlayer(ctx context.Context, playerID string) ([]model.PlayerAchievement, error) {
+	unlocked, err := s.repo.ListForPlayer(ctx, playerID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]model.PlayerAchievement, len(model.Achievements))
+	for i, a := range model.Achievements {
+		out[i].Achievement = a
+		if at, ok := unlocked[a.ID]; ok {
+			out[i].UnlockedAt = &at
+		}
+	}
+	return out, nil
+}
+
+// Unlocked returns the player's unlocked achievements, oldest first.
+func (s *AchievementService) Unlocked(ctx context.Context, playerID string) ([]model.PlayerAchievement, error) {
+	all, err := s.ForPlayer(ctx, playerID)
+	if err != nil {
+		return nil, err
+	}
+	out := []model.PlayerAchievement{}
+	for _, a := range all {
+		if a.UnlockedAt != nil {
+			out = append(out, a)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].UnlockedAt.Before(*out[j].UnlockedAt) })
+	return out, nil
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"spacegame-backend/internal/model"
+	"spacegame-backend/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// AdminTokenPrefix marks API tokens, so they can share the Authorization header with player JWTs.
+const AdminTokenPrefix = "adm_"
+
+// maxAdminTokenName leaves room for the "token:" prefix in the 64-character actor columns.
+const maxAdminTokenName = 58
+
+var (
+	ErrInvalidAdminCredentials = errors.New("invalid admin credentials")
+	ErrAdminTokenNotFound      = errors.New("admin token not found")
+	ErrAdminTokenExists        = errors.New("an admin token with this name already exists")
+	ErrInvalidAdminToken       = errors.New("name must be 1-58 characters and at least one scope is required")
+	ErrUnknownScope            = errors.New("unknown scope")
+	ErrScopeNotHeld            = errors.New("cannot grant a scope you don't hold")
+)
+
+// AdminService resolves who is calling the admin API (staff account, scoped API token
+// or the legacy shared key), manages API tokens and keeps the admin audit log.
+type AdminService struct {
+	tokenRepo *repository.AdminTokenRepository
+	auditRepo *repository.AdminAuditRepository
+	authSvc   *AuthService
+	banSvc    *BanService
+	permSvc   *PermissionService
+	eventSvc  *EventService
+}
+
+func NewAdminService(
+	tokenRepo *repository.AdminTokenRepository,
+	auditRepo *repository.AdminAuditRepository,
+	authSvc *AuthService,
+	banSvc *BanService,
+	permSvc *PermissionService,
+	eventSvc *EventService,
+) *AdminService {
+	return &AdminService{
+		tokenRepo: tokenRepo,
+		auditRepo: auditRepo,
+		authSvc:   authSvc,
+		banSvc:    banSvc,
+		permSvc:   permSvc,
+		eventSvc:  eventSvc,
+	}
+}
+
+// AuthenticateAdmin resolves a bearer credential: an API token (adm_...) or a player
+// access token. Players are granted the permissions of their current role.
+func (s *AdminService) AuthenticateAdmin(ctx context.Context, credential string) (*model.AdminPrincipal, error) {
+	if strings.HasPrefix(credential, AdminTokenPrefix) {
+		token, err := s.tokenRepo.GetActiveByHash(ctx, hashToken(credential))
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrInvalidAdminCredentials
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := s.tokenRepo.Touch(ctx, token.ID); err != nil {
+			log.Printf("[ADMIN] failed to update last_used_at of token %s: %v", token.ID, err)
+		}
+		return &model.AdminPrincipal{Type: model.AdminActorToken, ID: token.ID, Name: "token:" + token.Name, Scopes: token.Scopes}, nil
+	}
+
+	claims, err := s.authSvc.ParseAccessToken(credential)
+	if err != nil {
+		return nil, ErrInvalidAdminCredentials
+	}
+	if s.banSvc.IsBanned(claims.PlayerID) {
+		return nil, ErrBanned
+	}
+	// The token's role claim predates any demotion: use the current role
+	role, err := s.permSvc.PlayerRole(ctx, claims.PlayerID)
+	if err != nil {
+		return nil, err
+	}
+	scopes := s.permSvc.Permissions(role)
+	if len(scopes) == 0 {
+		return nil, ErrInvalidAdminCredentials
+	}
+	return &model.AdminPrincipal{Type: model.AdminActorPlayer, ID: claims.PlayerID, Name: claims.Username, Scopes: scopes}, nil
+}
+
+// CreateToken issues an API token. A principal can only grant scopes it holds itself.
+func (s *AdminService) CreateToken(ctx context.Context, actor *model.AdminPrincipal, req *model.CreateAdminTokenRequest) (*model.CreatedAdminToken, error) {
+	name := strings.TrimSpace(req.Name)
+	// The token acts as "token:<name>", which must fit the 64-character actor columns
+	if name == "" || utf8.RuneCountInString(name) > maxAdminTokenName || len(req.Scopes) == 0 || req.ExpiresInDays < 0 {
+		return nil, ErrInvalidAdminToken
+	}
+	for _, scope := range req.Scopes {
+		if scope != permAll && !isKnownPermission(scope) {
+			return nil, ErrUnknownScope
+		}
+		if !actor.Allows(scope) {
+			return nil, ErrScopeNotHeld
+		}
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresInDays > 0 {
+		t := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		expiresAt = &t
+	}
+
+	secret, _, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	raw := AdminTokenPrefix + secret
+
+	token, err := s.tokenRepo.Create(ctx, name, hashToken(raw), req.Scopes, actor.Name, expiresAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrAdminTokenExists
+		}
+		return nil, err
+	}
+
+	s.eventSvc.RecordSecurityEvent(ctx, "admin_token_created", actor.Name, map[string]string{
+		"token_id": token.ID,
+		"name":     token.Name,
+		"scopes":   strings.Join(token.Scopes, ","),
+	})
+	return &model.CreatedAdminToken{AdminToken: *token, Token: raw}, nil
+}
+
+// ListTokens returns every API token (hashes are never exposed).
+func (s *AdminService) ListTokens(ctx context.Context) ([]model.AdminToken, error) {
+	return s.tokenRepo.List(ctx)
+}
+
+// RevokeToken disables an API token immediately.
+func (s *AdminService) RevokeToken(ctx context.Context, actor *model.AdminPrincipal, id string) error {
+	ok, err := s.tokenRepo.Revoke(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrAdminTokenNotFound
+	}
+	s.eventSvc.RecordSecurityEvent(ctx, "admin_token_revoked", actor.Name, map[string]string{"token_id": id})
+	return nil
+}
+
+// RecordAdminAction appends to the audit log. Failures are logged, never returned:
+// the action already happened.
+func (s *AdminService) RecordAdminAction(ctx context.Context, entry *model.AdminAuditEntry) {
+	if err := s.auditRepo.Insert(ctx, entry); err != nil {
+		log.Printf("[ADMIN] failed to write audit entry %s by %s: %v", entry.Action, entry.ActorName, err)
+	}
+}
+
+// QueryAudit browses the audit log, newest first.
+func (s *AdminService) QueryAudit(ctx context.Context, filter model.AdminAuditFilter) ([]model.AdminAuditEntry, error) {
+	if filter.Limit <= 0 || filter.Limit > 200 {
+		filter.Limit = 50
+	}
+	return s.auditRepo.Query(ctx, filter)
+}
+
+func isKnownPermission(scope string) bool {
+	for _, p := range KnownPermissions {
+		if p == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"log"
 	"strings"
 	"time"
 
@@ -24,6 +25,9 @@ var (
 	ErrInvalidToken       = errors.New("invalid or expired token")
 	ErrWeakPassword       = errors.New("password must be at least 6 characters")
 	ErrInvalidUsername     = errors.New("username must be 3-32 alphanumeric characters")
+	ErrSessionNotFound    = errors.New("session not found")
+	ErrAlreadyVerified    = errors.New("email is already verified")
+	ErrPlayerNotFound     = errors.New("player not found")
 )
 
 const (
@@ -32,20 +36,48 @@ const (
 )
 
 type AuthService struct {
-	playerRepo  *repository.PlayerRepository
-	sessionRepo *repository.SessionRepository
-	jwtSecret   []byte
+	playerRepo       *repository.PlayerRepository
+	sessionRepo      *repository.SessionRepository
+	accountTokenRepo *repository.AccountTokenRepository
+	twoFactorRepo    *repository.TwoFactorRepository
+	loginAttemptRepo *repository.LoginAttemptRepository
+	eventSvc         *EventService
+	mailer           Mailer
+	accountURL       string
+	keys             *KeySet
+	legacySecret     []byte
+	legacyUntil      time.Time
 }
 
-func NewAuthService(playerRepo *repository.PlayerRepository, sessionRepo *repository.SessionRepository, jwtSecret string) *AuthService {
+func NewAuthService(
+	playerRepo *repository.PlayerRepository,
+	sessionRepo *repository.SessionRepository,
+	accountTokenRepo *repository.AccountTokenRepository,
+	twoFactorRepo *repository.TwoFactorRepository,
+	loginAttemptRepo *repository.LoginAttemptRepository,
+	eventSvc *EventService,
+	mailer Mailer,
+	accountURL string,
+	keys *KeySet,
+	legacySecret string,
+	legacyUntil time.Time,
+) *AuthService {
 	return &AuthService{
-		playerRepo:  playerRepo,
-		sessionRepo: sessionRepo,
-		jwtSecret:   []byte(jwtSecret),
+		playerRepo:       playerRepo,
+		sessionRepo:      sessionRepo,
+		accountTokenRepo: accountTokenRepo,
+		twoFactorRepo:    twoFactorRepo,
+		loginAttemptRepo: loginAttemptRepo,
+		eventSvc:         eventSvc,
+		mailer:           mailer,
+		accountURL:       strings.TrimRight(accountURL, "/"),
+		keys:             keys,
+		legacySecret:     []byte(legacySecret),
+		legacyUntil:      legacyUntil,
 	}
 }
 
-func (s *AuthService) Register(ctx context.Context, req *model.RegisterRequest) (*model.AuthResponse, error) {
+func (s *AuthService) Register(ctx context.Context, req *model.RegisterRequest, meta model.SessionMeta) (*model.AuthResponse, error) {
 	// Validate
 	req.Username = strings.TrimSpace(req.Username)
 	req.Email = strings.TrimSpace(strings.ToLower(req.Email))
@@ -73,13 +105,15 @@ func (s *AuthService) Register(ctx context.Context, req *model.RegisterRequest)
 	}
 
 	// Generate tokens
-	tokens, err := s.generateTokenPair(ctx, player.ID, player.Username, player.Role)
+	tokens, err := s.generateTokenPair(ctx, player.ID, player.Username, player.Role, meta)
 	if err != nil {
 		return nil, err
 	}
 
 	_ = s.playerRepo.UpdateLoginTime(ctx, player.ID)
 
+	s.sendVerificationEmail(player)
+
 	return &model.AuthResponse{
 		AccessToken:  tokens.AccessToken,
 		RefreshToken: tokens.RefreshToken,
@@ -87,25 +121,53 @@ func (s *AuthService) Register(ctx context.Context, req *model.RegisterRequest)
 	}, nil
 }
 
-func (s *AuthService) Login(ctx context.Context, req *model.LoginRequest) (*model.AuthResponse, error) {
-	player, err := s.playerRepo.GetByUsername(ctx, strings.TrimSpace(req.Username))
+func (s *AuthService) Login(ctx context.Context, req *model.LoginRequest, meta model.SessionMeta) (*model.AuthResponse, error) {
+	username := strings.TrimSpace(req.Username)
+
+	if err := s.checkIPThrottle(ctx, meta.IPAddress); err != nil {
+		return nil, err
+	}
+
+	player, err := s.playerRepo.GetByUsername(ctx, username)
 	if err != nil {
+		s.recordLoginFailure(ctx, nil, username, meta)
 		return nil, ErrInvalidCredentials
 	}
 
-	if player.IsBanned {
-		return nil, ErrBanned
+	// Checked before the password so a locked account can't be probed. The refusal looks
+	// like a wrong password so it doesn't tell which usernames exist; the owner is told
+	// by email when the account locks.
+	if err := s.claimAccountAttempt(ctx, player.ID); err != nil {
+		return nil, ErrInvalidCredentials
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(player.PasswordHash), []byte(req.Password)); err != nil {
+		s.recordLoginFailure(ctx, player, username, meta)
 		return nil, ErrInvalidCredentials
 	}
 
-	tokens, err := s.generateTokenPair(ctx, player.ID, player.Username, player.Role)
+	// Only told with the right password, so bans can't be probed either
+	if player.IsBanned {
+		s.releaseAccountAttempt(ctx, player.ID)
+		return nil, ErrBanned
+	}
+
+	// Second factor: no tokens until the challenge is exchanged at /auth/2fa/verify
+	if player.TwoFactorEnabled {
+		s.releaseAccountAttempt(ctx, player.ID)
+		challenge, err := s.challengeToken(player.ID)
+		if err != nil {
+			return nil, err
+		}
+		return &model.AuthResponse{Status: "2fa_required", ChallengeToken: challenge}, nil
+	}
+
+	tokens, err := s.generateTokenPair(ctx, player.ID, player.Username, player.Role, meta)
 	if err != nil {
 		return nil, err
 	}
 
+	s.recordLoginSuccess(ctx, player, meta)
 	_ = s.playerRepo.UpdateLoginTime(ctx, player.ID)
 
 	return &model.AuthResponse{
@@ -115,19 +177,20 @@ func (s *AuthService) Login(ctx context.Context, req *model.LoginRequest) (*mode
 	}, nil
 }
 
-func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*model.TokenPair, error) {
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string, meta model.SessionMeta) (*model.TokenPair, error) {
 	tokenHash := hashToken(refreshToken)
 
-	playerID, err := s.sessionRepo.ValidateRefreshToken(ctx, tokenHash)
+	rec, err := s.sessionRepo.ValidateRefreshToken(ctx, tokenHash)
+	if errors.Is(err, repository.ErrRefreshTokenReused) {
+		s.handleTokenReuse(ctx, rec, meta)
+		return nil, ErrInvalidToken
+	}
 	if err != nil {
 		return nil, ErrInvalidToken
 	}
 
-	// Revoke old token
-	_ = s.sessionRepo.RevokeRefreshToken(ctx, tokenHash)
-
 	// Get player username
-	player, err := s.playerRepo.GetByID(ctx, playerID)
+	player, err := s.playerRepo.GetByID(ctx, rec.PlayerID)
 	if err != nil {
 		return nil, err
 	}
@@ -136,7 +199,50 @@ func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*model.
 		return nil, ErrBanned
 	}
 
-	return s.generateTokenPair(ctx, playerID, player.Username, player.Role)
+	// The rotated token continues the same device session
+	if meta.DeviceLabel == "" {
+		meta.DeviceLabel = rec.DeviceLabel
+	}
+	meta.StartedAt = rec.SessionStartedAt
+
+	refreshStr, newHash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	newRec, err := s.sessionRepo.RotateRefreshToken(ctx, rec, newHash, time.Now().Add(refreshTokenDuration), meta)
+	if errors.Is(err, repository.ErrRefreshTokenReused) {
+		// Lost a race against another refresh with the same token
+		s.handleTokenReuse(ctx, rec, meta)
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("rotate refresh token: %w", err)
+	}
+
+	return s.tokenPair(player.ID, player.Username, player.Role, newRec.FamilyID, refreshStr)
+}
+
+// handleTokenReuse revokes the whole token family when a rotated refresh token is
+// replayed: either the legitimate client or an attacker holds a stolen copy, and
+// we cannot tell which, so both are signed out.
+func (s *AuthService) handleTokenReuse(ctx context.Context, rec *model.RefreshTokenRecord, meta model.SessionMeta) {
+	if err := s.sessionRepo.RevokeFamily(ctx, rec.FamilyID); err != nil {
+		log.Printf("[AUTH] failed to revoke token family %s: %v", rec.FamilyID, err)
+	}
+	if s.eventSvc == nil {
+		return
+	}
+	playerName := ""
+	if player, err := s.playerRepo.GetByID(ctx, rec.PlayerID); err == nil {
+		playerName = player.Username
+	}
+	s.eventSvc.RecordSecurityEvent(ctx, "refresh_token_reuse", playerName, map[string]string{
+		"player_id":  rec.PlayerID,
+		"family_id":  rec.FamilyID,
+		"device":     rec.DeviceLabel,
+		"ip_address": meta.IPAddress,
+		"user_agent": meta.UserAgent,
+	})
 }
 
 func (s *AuthService) Logout(ctx context.Context, refreshToken string) error {
@@ -144,66 +250,155 @@ func (s *AuthService) Logout(ctx context.Context, refreshToken string) error {
 	return s.sessionRepo.RevokeRefreshToken(ctx, tokenHash)
 }
 
+// ListSessions returns the player's active sessions, flagging the one the request was made from.
+func (s *AuthService) ListSessions(ctx context.Context, playerID, currentSessionID string) ([]model.Session, error) {
+	sessions, err := s.sessionRepo.ListActiveForPlayer(ctx, playerID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+	return sessions, nil
+}
+
+// RevokeSession revokes one of the player's sessions. Access tokens already
+// issued to that device stay valid until they expire (accessTokenDuration).
+func (s *AuthService) RevokeSession(ctx context.Context, playerID, sessionID string) error {
+	ok, err := s.sessionRepo.RevokeByID(ctx, playerID, sessionID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// RevokeAllSessions signs the player out everywhere, including the current device.
+func (s *AuthService) RevokeAllSessions(ctx context.Context, playerID string) error {
+	return s.sessionRepo.RevokeAllForPlayer(ctx, playerID)
+}
+
+// Reauthenticate confirms a sensitive action with the password, plus a 2FA
+// (or recovery) code when two-factor authentication is enabled.
+func (s *AuthService) Reauthenticate(ctx context.Context, playerID, password, code string) error {
+	player, err := s.playerRepo.GetByID(ctx, playerID)
+	if err != nil {
+		return err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(player.PasswordHash), []byte(password)); err != nil {
+		return ErrInvalidCredentials
+	}
+	if !player.TwoFactorEnabled {
+		return nil
+	}
+	if code == "" {
+		return ErrInvalidTwoFactorCode
+	}
+	return s.verifySecondFactor(ctx, playerID, code)
+}
+
+// ValidateAccessToken returns the player ID, username and role of a valid access token.
 func (s *AuthService) ValidateAccessToken(tokenString string) (string, string, string, error) {
-	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
-		}
-		return s.jwtSecret, nil
-	})
+	claims, err := s.ParseAccessToken(tokenString)
+	if err != nil {
+		return "", "", "", err
+	}
+	return claims.PlayerID, claims.Username, claims.Role, nil
+}
+
+// ParseAccessToken verifies an access token against the key set and returns its claims.
+func (s *AuthService) ParseAccessToken(tokenString string) (*model.AccessClaims, error) {
+	token, err := jwt.Parse(tokenString, s.accessKeyFunc)
 	if err != nil {
-		return "", "", "", ErrInvalidToken
+		return nil, ErrInvalidToken
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok || !token.Valid {
-		return "", "", "", ErrInvalidToken
+		return nil, ErrInvalidToken
+	}
+
+	// Tokens issued before 2FA carry no typ claim; anything else (e.g. a 2FA challenge) is not an access token
+	if typ, ok := claims["typ"].(string); ok && typ != tokenTypeAccess {
+		return nil, ErrInvalidToken
 	}
 
-	playerID, _ := claims["sub"].(string)
-	username, _ := claims["username"].(string)
-	role, _ := claims["role"].(string)
-	if playerID == "" {
-		return "", "", "", ErrInvalidToken
+	ac := &model.AccessClaims{}
+	ac.PlayerID, _ = claims["sub"].(string)
+	ac.Username, _ = claims["username"].(string)
+	ac.Role, _ = claims["role"].(string)
+	ac.SessionID, _ = claims["sid"].(string)
+	if ac.PlayerID == "" {
+		return nil, ErrInvalidToken
 	}
 
-	return playerID, username, role, nil
+	return ac, nil
+}
+
+// accessKeyFunc resolves the key for an access token. HS256 tokens signed with the
+// legacy JWT_SECRET are still accepted until JWT_LEGACY_UNTIL, a fixed time after the
+// Ed25519 cutover, however often the backend restarts.
+func (s *AuthService) accessKeyFunc(t *jwt.Token) (interface{}, error) {
+	if _, ok := t.Method.(*jwt.SigningMethodHMAC); ok && len(s.legacySecret) > 0 && time.Now().Before(s.legacyUntil) {
+		return s.legacySecret, nil
+	}
+	return s.keys.keyFunc(t)
 }
 
-func (s *AuthService) generateTokenPair(ctx context.Context, playerID, username, role string) (*model.TokenPair, error) {
-	// Access token
+// JWKS returns the public keys access tokens can be verified with.
+func (s *AuthService) JWKS() *model.JWKS {
+	return s.keys.JWKS()
+}
+
+func (s *AuthService) generateTokenPair(ctx context.Context, playerID, username, role string, meta model.SessionMeta) (*model.TokenPair, error) {
+	refreshStr, tokenHash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// Store hash of refresh token (first token of a new family)
+	rec, err := s.sessionRepo.StoreRefreshToken(ctx, playerID, tokenHash, time.Now().Add(refreshTokenDuration), meta)
+	if err != nil {
+		return nil, fmt.Errorf("store refresh token: %w", err)
+	}
+
+	return s.tokenPair(playerID, username, role, rec.FamilyID, refreshStr)
+}
+
+// tokenPair signs an access token for the session and pairs it with its refresh token.
+// The sid claim lets the player see which session is the current one.
+func (s *AuthService) tokenPair(playerID, username, role, sessionID, refreshToken string) (*model.TokenPair, error) {
 	now := time.Now()
 	accessClaims := jwt.MapClaims{
 		"sub":      playerID,
 		"username": username,
 		"role":     role,
+		"sid":      sessionID,
+		"typ":      tokenTypeAccess,
 		"iat":      now.Unix(),
 		"exp":      now.Add(accessTokenDuration).Unix(),
 	}
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-	accessStr, err := accessToken.SignedString(s.jwtSecret)
+	accessStr, err := s.keys.sign(accessClaims)
 	if err != nil {
 		return nil, fmt.Errorf("sign access token: %w", err)
 	}
 
-	// Refresh token (random bytes)
+	return &model.TokenPair{
+		AccessToken:  accessStr,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// newRefreshToken returns a random refresh token and the hash stored for it.
+func newRefreshToken() (string, string, error) {
 	refreshBytes := make([]byte, 32)
 	if _, err := rand.Read(refreshBytes); err != nil {
-		return nil, fmt.Errorf("generate refresh token: %w", err)
+		return "", "", fmt.Errorf("generate refresh token: %w", err)
 	}
 	refreshStr := hex.EncodeToString(refreshBytes)
-
-	// Store hash of refresh token
-	tokenHash := hashToken(refreshStr)
-	expiresAt := now.Add(refreshTokenDuration)
-	if err := s.sessionRepo.StoreRefreshToken(ctx, playerID, tokenHash, expiresAt); err != nil {
-		return nil, fmt.Errorf("store refresh token: %w", err)
-	}
-
-	return &model.TokenPair{
-		AccessToken:  accessStr,
-		RefreshToken: refreshStr,
-	}, nil
+	return refreshStr, hashToken(refreshStr), nil
 }
 
 func hashToken(token string) string {
@@ -0,0 +1,290 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"spacegame-backend/internal/model"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorNotSetup       = errors.New("two-factor setup has not been started")
+)
+
+const (
+	totpIssuer             = "Imperion Online"
+	challengeTokenDuration = 5 * time.Minute
+	recoveryCodeCount      = 10
+
+	tokenTypeAccess    = "access"
+	tokenTypeChallenge = "2fa_challenge"
+)
+
+// SetupTwoFactor generates a new TOTP secret for the player. It only takes effect
+// once confirmed with a valid code through EnableTwoFactor.
+func (s *AuthService) SetupTwoFactor(ctx context.Context, playerID string) (*model.TwoFactorSetup, error) {
+	player, err := s.playerRepo.GetByID(ctx, playerID)
+	if err != nil {
+		return nil, err
+	}
+	if player.TwoFactorEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.twoFactorRepo.SetPendingSecret(ctx, playerID, secret)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	return &model.TwoFactorSetup{
+		Secret:          secret,
+		ProvisioningURI: totpProvisioningURI(totpIssuer, player.Username, secret),
+	}, nil
+}
+
+// EnableTwoFactor confirms enrolment with a code from the authenticator app and
+// returns the recovery codes. They are only shown this once.
+func (s *AuthService) EnableTwoFactor(ctx context.Context, playerID, code string) ([]string, error) {
+	secret, enabled, err := s.twoFactorRepo.GetSecret(ctx, playerID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if secret == "" {
+		return nil, ErrTwoFactorNotSetup
+	}
+	if !s.checkTOTP(ctx, playerID, secret, code) {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.twoFactorRepo.Enable(ctx, playerID, hashes); err != nil {
+		return nil, err
+	}
+
+	s.recordTwoFactorEvent(ctx, "2fa_enabled", playerID)
+	return codes, nil
+}
+
+// DisableTwoFactor turns 2FA off. Requires both the password and a current code
+// (or recovery code) so a stolen access token alone can't remove the second factor.
+func (s *AuthService) DisableTwoFactor(ctx context.Context, playerID, password, code string) error {
+	player, err := s.playerRepo.GetByID(ctx, playerID)
+	if err != nil {
+		return err
+	}
+	if !player.TwoFactorEnabled {
+		return ErrTwoFactorNotEnabled
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(player.PasswordHash), []byte(password)); err != nil {
+		return ErrInvalidCredentials
+	}
+	if err := s.verifySecondFactor(ctx, playerID, code); err != nil {
+		return err
+	}
+
+	if err := s.twoFactorRepo.Disable(ctx, playerID); err != nil {
+		return err
+	}
+
+	s.recordTwoFactorEvent(ctx, "2fa_disabled", playerID)
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes. Requires a TOTP code.
+func (s *AuthService) RegenerateRecoveryCodes(ctx context.Context, playerID, code string) ([]string, error) {
+	secret, enabled, err := s.twoFactorRepo.GetSecret(ctx, playerID)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if !s.checkTOTP(ctx, playerID, secret, code) {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.twoFactorRepo.ReplaceRecoveryCodes(ctx, playerID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// VerifyTwoFactorLogin exchanges a login challenge token and a valid code for a token pair.
+func (s *AuthService) VerifyTwoFactorLogin(ctx context.Context, challengeToken, code string, meta model.SessionMeta) (*model.AuthResponse, error) {
+	playerID, err := s.parseChallengeToken(challengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	player, err := s.playerRepo.GetByID(ctx, playerID)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	if player.IsBanned {
+		return nil, ErrBanned
+	}
+	if !player.TwoFactorEnabled {
+		// 2FA was disabled since the challenge was issued: make them log in again
+		return nil, ErrInvalidToken
+	}
+
+	// Codes are only 6 digits: guesses count towards the same lockout as passwords
+	if err := s.checkIPThrottle(ctx, meta.IPAddress); err != nil {
+		return nil, err
+	}
+	if err := s.claimAccountAttempt(ctx, player.ID); err != nil {
+		return nil, err
+	}
+
+	if err := s.verifySecondFactor(ctx, playerID, code); err != nil {
+		if errors.Is(err, ErrInvalidTwoFactorCode) {
+			s.recordLoginFailure(ctx, player, player.Username, meta)
+		} else {
+			s.releaseAccountAttempt(ctx, player.ID)
+		}
+		return nil, err
+	}
+
+	tokens, err := s.generateTokenPair(ctx, player.ID, player.Username, player.Role, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordLoginSuccess(ctx, player, meta)
+	_ = s.playerRepo.UpdateLoginTime(ctx, player.ID)
+
+	return &model.AuthResponse{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		Player:       player,
+	}, nil
+}
+
+// verifySecondFactor accepts either a TOTP code or an unused recovery code.
+func (s *AuthService) verifySecondFactor(ctx context.Context, playerID, code string) error {
+	secret, enabled, err := s.twoFactorRepo.GetSecret(ctx, playerID)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return ErrTwoFactorNotEnabled
+	}
+	if s.checkTOTP(ctx, playerID, secret, code) {
+		return nil
+	}
+
+	used, err := s.twoFactorRepo.ConsumeRecoveryCode(ctx, playerID, hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidTwoFactorCode
+	}
+
+	s.recordTwoFactorEvent(ctx, "2fa_recovery_code_used", playerID)
+	return nil
+}
+
+// checkTOTP validates a code and burns its time step so it can't be used twice.
+func (s *AuthService) checkTOTP(ctx context.Context, playerID, secret, code string) bool {
+	step, ok := validateTOTP(secret, code, time.Now())
+	if !ok {
+		return false
+	}
+	fresh, err := s.twoFactorRepo.UseStep(ctx, playerID, step)
+	return err == nil && fresh
+}
+
+// challengeToken signs the short-lived token returned by Login when a second factor is required.
+func (s *AuthService) challengeToken(playerID string) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"sub": playerID,
+		"typ": tokenTypeChallenge,
+		"iat": now.Unix(),
+		"exp": now.Add(challengeTokenDuration).Unix(),
+	}
+	str, err := s.keys.sign(claims)
+	if err != nil {
+		return "", fmt.Errorf("sign challenge token: %w", err)
+	}
+	return str, nil
+}
+
+func (s *AuthService) parseChallengeToken(tokenString string) (string, error) {
+	token, err := jwt.Parse(tokenString, s.keys.keyFunc)
+	if err != nil || !token.Valid {
+		return "", ErrInvalidToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return "", ErrInvalidToken
+	}
+	typ, _ := claims["typ"].(string)
+	playerID, _ := claims["sub"].(string)
+	if typ != tokenTypeChallenge || playerID == "" {
+		return "", ErrInvalidToken
+	}
+	return playerID, nil
+}
+
+func (s *AuthService) recordTwoFactorEvent(ctx context.Context, eventType, playerID string) {
+	if s.eventSvc == nil {
+		return
+	}
+	name := ""
+	if player, err := s.playerRepo.GetByID(ctx, playerID); err == nil {
+		name = player.Username
+	}
+	s.eventSvc.RecordSecurityEvent(ctx, eventType, name, map[string]string{"player_id": playerID})
+}
+
+// newRecoveryCodes returns recoveryCodeCount codes formatted as xxxxx-xxxxx, and their hashes.
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 6)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, fmt.Errorf("generate recovery code: %w", err)
+		}
+		raw := strings.ToLower(totpEncoding.EncodeToString(b))[:10]
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = hashToken(raw)
+	}
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
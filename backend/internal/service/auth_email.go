@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"spacegame-backend/internal/model"
+	"spacegame-backend/internal/repository"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	passwordResetTokenDuration = 1 * time.Hour
+	emailVerifyTokenDuration   = 48 * time.Hour
+	mailSendTimeout            = 30 * time.Second
+)
+
+// RequestPasswordReset mails a single-use reset link to the account owning the email.
+// Unknown addresses are silently ignored so the endpoint can't be used to probe accounts.
+func (s *AuthService) RequestPasswordReset(ctx context.Context, email string) error {
+	player, err := s.playerRepo.GetByEmail(ctx, strings.TrimSpace(email))
+	if err != nil {
+		return nil
+	}
+
+	token, err := s.createAccountToken(ctx, player.ID, repository.TokenPurposePasswordReset, passwordResetTokenDuration)
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf(`Bonjour %s,
+
+Une réinitialisation du mot de passe de ton compte Imperion Online a été demandée.
+
+%s
+
+Ce lien est valable 1 heure et ne peut être utilisé qu'une seule fois.
+Si tu n'es pas à l'origine de cette demande, ignore simplement cet email.
+`, player.Username, s.accountLink("reset-password", token))
+
+	s.sendMail(player.Email, "Imperion Online — Réinitialisation du mot de passe", body)
+	return nil
+}
+
+// ResetPassword sets a new password using a reset token and signs the player out everywhere.
+func (s *AuthService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	if len(newPassword) < 6 {
+		return ErrWeakPassword
+	}
+
+	playerID, err := s.accountTokenRepo.Consume(ctx, repository.TokenPurposePasswordReset, hashToken(token))
+	if err != nil {
+		return ErrInvalidToken
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("hash password: %w", err)
+	}
+	if err := s.playerRepo.UpdatePassword(ctx, playerID, string(hash)); err != nil {
+		return err
+	}
+
+	// Whoever knew the old password must not keep a session
+	if err := s.sessionRepo.RevokeAllForPlayer(ctx, playerID); err != nil {
+		return err
+	}
+
+	// Receiving the reset link proves ownership of the address
+	_ = s.playerRepo.MarkEmailVerified(ctx, playerID)
+
+	if s.eventSvc != nil {
+		name := ""
+		if player, err := s.playerRepo.GetByID(ctx, playerID); err == nil {
+			name = player.Username
+		}
+		s.eventSvc.RecordSecurityEvent(ctx, "password_reset", name, map[string]string{"player_id": playerID})
+	}
+	return nil
+}
+
+// ResendVerification mails a fresh verification link to the player.
+func (s *AuthService) ResendVerification(ctx context.Context, playerID string) error {
+	player, err := s.playerRepo.GetByID(ctx, playerID)
+	if err != nil {
+		return err
+	}
+	if player.EmailVerified {
+		return ErrAlreadyVerified
+	}
+	s.sendVerificationEmail(player)
+	return nil
+}
+
+// VerifyEmail consumes a verification token and marks the address as verified.
+func (s *AuthService) VerifyEmail(ctx context.Context, token string) error {
+	playerID, err := s.accountTokenRepo.Consume(ctx, repository.TokenPurposeEmailVerify, hashToken(token))
+	if err != nil {
+		return ErrInvalidToken
+	}
+	return s.playerRepo.MarkEmailVerified(ctx, playerID)
+}
+
+// sendVerificationEmail creates a verification token and mails it in the background.
+func (s *AuthService) sendVerificationEmail(player *model.Player) {
+	ctx, cancel := context.WithTimeout(context.Background(), mailSendTimeout)
+	defer cancel()
+
+	token, err := s.createAccountToken(ctx, player.ID, repository.TokenPurposeEmailVerify, emailVerifyTokenDuration)
+	if err != nil {
+		log.Printf("[AUTH] failed to create verification token for %s: %v", player.Username, err)
+		return
+	}
+
+	body := fmt.Sprintf(`Bienvenue %s !
+
+Confirme l'adresse email de ton compte Imperion Online :
+
+%s
+
+Ce lien est valable 48 heures.
+`, player.Username, s.accountLink("verify-email", token))
+
+	s.sendMail(player.Email, "Imperion Online — Confirme ton adresse email", body)
+}
+
+func (s *AuthService) createAccountToken(ctx context.Context, playerID, purpose string, ttl time.Duration) (string, error) {
+	token, tokenHash, err := newRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	if err := s.accountTokenRepo.Create(ctx, playerID, purpose, tokenHash, time.Now().Add(ttl)); err != nil {
+		return "", fmt.Errorf("store %s token: %w", purpose, err)
+	}
+	return token, nil
+}
+
+// accountLink builds the link included in emails. Without ACCOUNT_URL the raw
+// code is sent so it can be pasted into the launcher.
+func (s *AuthService) accountLink(page, token string) string {
+	if s.accountURL == "" {
+		return "Code : " + token
+	}
+	return s.accountURL + "/" + page + "?token=" + url.QueryEscape(token)
+}
+
+// sendMail delivers in the background: SMTP latency must not slow down the
+// request, nor reveal whether an address exists.
+func (s *AuthService) sendMail(to, subject, body string) {
+	if s.mailer == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), mailSendTimeout)
+		defer cancel()
+		if err := s.mailer.Send(ctx, to, subject, body); err != nil {
+			log.Printf("[AUTH] mail to %s failed: %v", to, err)
+		}
+	}()
+}
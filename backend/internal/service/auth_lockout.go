@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"spacegame-backend/internal/model"
+)
+
+// Brute-force protection. Failures are stored in Postgres so limits survive
+// deploys and apply across every backend instance.
+const (
+	loginFreeFailures   = 3                // consecutive failures before delays kick in
+	loginMaxDelay       = 60 * time.Second // cap of the progressive delay
+	loginLockThreshold  = 10               // consecutive failures that lock the account
+	loginLockDuration   = 15 * time.Minute
+	ipFailureWindow     = 15 * time.Minute
+	ipFailureLimit      = 50               // failures from one IP within the window, across all accounts
+	loginAttemptHistory = 50               // attempts shown to admins
+	loginClaimTTL       = 30 * time.Second // an attempt that never finished stops blocking the account
+)
+
+// LoginThrottledError is returned when an IP, or an account past its password check
+// (2FA), must wait before trying again. Login reports account throttling as invalid
+// credentials instead, like an unknown username.
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+	Locked     bool // account locked, as opposed to a progressive delay
+}
+
+func (e *LoginThrottledError) Error() string {
+	if e.Locked {
+		return fmt.Sprintf("account temporarily locked, retry in %s", e.RetryAfter.Round(time.Second))
+	}
+	return fmt.Sprintf("too many failed login attempts, retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+// checkIPThrottle refuses logins from an IP with too many recent failures.
+func (s *AuthService) checkIPThrottle(ctx context.Context, ip string) error {
+	if ip == "" {
+		return nil
+	}
+	now := time.Now()
+	count, oldest, err := s.loginAttemptRepo.CountRecentIPFailures(ctx, ip, now.Add(-ipFailureWindow))
+	if err != nil {
+		// Fail open: a DB hiccup must not lock everyone out
+		log.Printf("[AUTH] failed to count login failures for %s: %v", ip, err)
+		return nil
+	}
+	if count < ipFailureLimit || oldest == nil {
+		return nil
+	}
+	return &LoginThrottledError{RetryAfter: oldest.Add(ipFailureWindow).Sub(now)}
+}
+
+// claimAccountAttempt refuses logins on a locked account, before the progressive delay
+// since the last failure has elapsed, or while another attempt is being checked.
+// Otherwise the account is claimed until recordLoginFailure, recordLoginSuccess or
+// releaseAccountAttempt, so concurrent attempts can't all pass the same check.
+func (s *AuthService) claimAccountAttempt(ctx context.Context, playerID string) error {
+	st, err := s.loginAttemptRepo.GetLockState(ctx, playerID)
+	if err != nil {
+		log.Printf("[AUTH] failed to load lock state for %s: %v", playerID, err)
+		return nil
+	}
+
+	now := time.Now()
+	if st.LockedUntil != nil && st.LockedUntil.After(now) {
+		return &LoginThrottledError{RetryAfter: st.LockedUntil.Sub(now), Locked: true}
+	}
+	if st.LastFailedAt != nil {
+		if next := st.LastFailedAt.Add(loginDelay(st.FailedCount)); next.After(now) {
+			return &LoginThrottledError{RetryAfter: next.Sub(now)}
+		}
+	}
+
+	ok, err := s.loginAttemptRepo.Claim(ctx, playerID, st.FailedCount, loginDelay(st.FailedCount), loginClaimTTL)
+	if err != nil {
+		log.Printf("[AUTH] failed to claim login attempt for %s: %v", playerID, err)
+		return nil
+	}
+	if !ok {
+		return &LoginThrottledError{RetryAfter: time.Second}
+	}
+	return nil
+}
+
+// releaseAccountAttempt ends a claimed attempt that is neither a failure nor a login.
+func (s *AuthService) releaseAccountAttempt(ctx context.Context, playerID string) {
+	if err := s.loginAttemptRepo.Release(ctx, playerID); err != nil {
+		log.Printf("[AUTH] failed to release login attempt for %s: %v", playerID, err)
+	}
+}
+
+// loginDelay is the wait imposed after n consecutive failures: 1s, 2s, 4s... up to loginMaxDelay.
+func loginDelay(failures int) time.Duration {
+	if failures <= loginFreeFailures {
+		return 0
+	}
+	shift := failures - loginFreeFailures - 1
+	if shift > 6 {
+		return loginMaxDelay
+	}
+	d := time.Second << shift
+	if d > loginMaxDelay {
+		return loginMaxDelay
+	}
+	return d
+}
+
+// recordLoginFailure logs a failed attempt and locks the account every
+// loginLockThreshold consecutive failures. player is nil for unknown usernames.
+func (s *AuthService) recordLoginFailure(ctx context.Context, player *model.Player, username string, meta model.SessionMeta) {
+	username = truncateRunes(username, 64)
+	var playerID *string
+	if player != nil {
+		playerID = &player.ID
+	}
+	if err := s.loginAttemptRepo.Record(ctx, playerID, username, meta.IPAddress, false); err != nil {
+		log.Printf("[AUTH] failed to record login attempt: %v", err)
+	}
+	if player == nil {
+		return
+	}
+
+	count, err := s.loginAttemptRepo.AddFailure(ctx, player.ID)
+	if err != nil {
+		log.Printf("[AUTH] failed to count login failure for %s: %v", player.Username, err)
+		return
+	}
+	if count%loginLockThreshold != 0 {
+		return
+	}
+
+	until := time.Now().Add(loginLockDuration)
+	if err := s.loginAttemptRepo.Lock(ctx, player.ID, until); err != nil {
+		log.Printf("[AUTH] failed to lock account %s: %v", player.Username, err)
+		return
+	}
+	s.notifyAccountLocked(ctx, player, count, meta)
+}
+
+// recordLoginSuccess logs the attempt and clears the account's failures.
+func (s *AuthService) recordLoginSuccess(ctx context.Context, player *model.Player, meta model.SessionMeta) {
+	if err := s.loginAttemptRepo.Record(ctx, &player.ID, player.Username, meta.IPAddress, true); err != nil {
+		log.Printf("[AUTH] failed to record login attempt: %v", err)
+	}
+	if _, err := s.loginAttemptRepo.Reset(ctx, player.ID); err != nil {
+		log.Printf("[AUTH] failed to reset login failures for %s: %v", player.Username, err)
+	}
+}
+
+func (s *AuthService) notifyAccountLocked(ctx context.Context, player *model.Player, failures int, meta model.SessionMeta) {
+	if s.eventSvc != nil {
+		s.eventSvc.RecordSecurityEvent(ctx, "account_locked", player.Username, map[string]string{
+			"player_id":  player.ID,
+			"failures":   fmt.Sprint(failures),
+			"ip_address": meta.IPAddress,
+			"user_agent": meta.UserAgent,
+		})
+	}
+
+	body := fmt.Sprintf(`Bonjour %s,
+
+Ton compte Imperion Online a été temporairement verrouillé pendant %d minutes après %d tentatives de connexion échouées.
+
+Si ce n'était pas toi, quelqu'un essaie peut-être de deviner ton mot de passe :
+change-le dès que possible et active la double authentification.
+`, player.Username, int(loginLockDuration.Minutes()), failures)
+
+	s.sendMail(player.Email, "Imperion Online — Compte temporairement verrouillé", body)
+}
+
+// UnlockAccount clears an account's failures and lockout (admin action).
+func (s *AuthService) UnlockAccount(ctx context.Context, playerID string) error {
+	ok, err := s.loginAttemptRepo.Reset(ctx, playerID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrPlayerNotFound
+	}
+
+	if s.eventSvc != nil {
+		name := ""
+		if player, err := s.playerRepo.GetByID(ctx, playerID); err == nil {
+			name = player.Username
+		}
+		s.eventSvc.RecordSecurityEvent(ctx, "account_unlocked", name, map[string]string{"player_id": playerID})
+	}
+	return nil
+}
+
+// LoginActivity returns an account's lock state and recent login attempts (admin view).
+func (s *AuthService) LoginActivity(ctx context.Context, playerID string) (*model.LoginLockState, []model.LoginAttempt, error) {
+	st, err := s.loginAttemptRepo.GetLockState(ctx, playerID)
+	if err != nil {
+		return nil, nil, ErrPlayerNotFound
+	}
+	attempts, err := s.loginAttemptRepo.ListForPlayer(ctx, playerID, loginAttemptHistory)
+	if err != nil {
+		return nil, nil, err
+	}
+	if attempts == nil {
+		attempts = []model.LoginAttempt{}
+	}
+	return st, attempts, nil
+}
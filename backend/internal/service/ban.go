@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"spacegame-backend/internal/model"
+	"spacegame-backend/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrBanNotFound   = errors.New("ban not found")
+	ErrInvalidBan    = errors.New("ban requires a reason and either a positive duration_hours, an expires_at after its start or permanent")
+	ErrBanReasonSize = errors.New("ban reason is too long (max 1000 characters)")
+	ErrEmptyNote     = errors.New("note is required")
+)
+
+// BanService issues and lifts bans, and keeps an in-memory set of banned players so
+// every authenticated request can be checked without a database round-trip.
+type BanService struct {
+	banRepo     *repository.BanRepository
+	playerRepo  *repository.PlayerRepository
+	sessionRepo *repository.SessionRepository
+	wsHub       *WSHub
+	eventSvc    *EventService
+
+	mu     sync.RWMutex
+	banned map[string]*time.Time // player ID -> ban end (nil = permanent)
+	loaded bool
+}
+
+func NewBanService(banRepo *repository.BanRepository, playerRepo *repository.PlayerRepository, sessionRepo *repository.SessionRepository, wsHub *WSHub, eventSvc *EventService) *BanService {
+	return &BanService{
+		banRepo:     banRepo,
+		playerRepo:  playerRepo,
+		sessionRepo: sessionRepo,
+		wsHub:       wsHub,
+		eventSvc:    eventSvc,
+		banned:      make(map[string]*time.Time),
+	}
+}
+
+// IsBanned reports whether the player currently has a ban in force.
+func (s *BanService) IsBanned(playerID string) bool {
+	s.mu.RLock()
+	expiresAt, ok := s.banned[playerID]
+	s.mu.RUnlock()
+	return ok && (expiresAt == nil || time.Now().Before(*expiresAt))
+}
+
+// Refresh reloads the banned set from the database and kicks players that became
+// banned elsewhere (another backend instance, or a scheduled ban starting).
+func (s *BanService) Refresh(ctx context.Context) error {
+	if _, err := s.banRepo.SyncFlags(ctx); err != nil {
+		return fmt.Errorf("sync ban flags: %w", err)
+	}
+	active, err := s.banRepo.ListActive(ctx)
+	if err != nil {
+		return fmt.Errorf("load active bans: %w", err)
+	}
+
+	s.mu.Lock()
+	var newlyBanned []string
+	if s.loaded {
+		for playerID := range active {
+			if _, known := s.banned[playerID]; !known {
+				newlyBanned = append(newlyBanned, playerID)
+			}
+		}
+	}
+	s.banned = active
+	s.loaded = true
+	s.mu.Unlock()
+
+	for _, playerID := range newlyBanned {
+		if ban, err := s.banRepo.GetActive(ctx, playerID); err == nil {
+			s.kick(ban)
+		}
+	}
+	return nil
+}
+
+// Issue bans a player, signs them out everywhere and closes their websocket.
+func (s *BanService) Issue(ctx context.Context, playerID string, req *model.IssueBanRequest) (*model.Ban, error) {
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		return nil, ErrInvalidBan
+	}
+	if len(req.Reason) > 1000 {
+		return nil, ErrBanReasonSize
+	}
+	if req.IssuedBy == "" {
+		req.IssuedBy = "admin"
+	}
+
+	player, err := s.playerRepo.GetByID(ctx, playerID)
+	if err != nil {
+		return nil, ErrPlayerNotFound
+	}
+
+	startsAt := time.Now()
+	if req.StartsAt != nil {
+		startsAt = *req.StartsAt
+	}
+	// A permanent ban must be asked for: a missing or mistyped duration is not one
+	expiresAt := req.ExpiresAt
+	switch {
+	case req.DurationHours < 0:
+		return nil, ErrInvalidBan
+	case req.DurationHours > 0:
+		if expiresAt != nil || req.Permanent {
+			return nil, ErrInvalidBan
+		}
+		t := startsAt.Add(time.Duration(req.DurationHours) * time.Hour)
+		expiresAt = &t
+	case expiresAt != nil:
+		if req.Permanent || !expiresAt.After(startsAt) {
+			return nil, ErrInvalidBan
+		}
+	case !req.Permanent:
+		return nil, ErrInvalidBan
+	}
+
+	ban, err := s.banRepo.Create(ctx, playerID, req.Reason, req.IssuedBy, startsAt, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if ban.Active {
+		s.mu.Lock()
+		if cur, ok := s.banned[playerID]; !ok || (cur != nil && (expiresAt == nil || expiresAt.After(*cur))) {
+			s.banned[playerID] = expiresAt
+		}
+		s.mu.Unlock()
+
+		if err := s.sessionRepo.RevokeAllForPlayer(ctx, playerID); err != nil {
+			log.Printf("[BAN] failed to revoke sessions of %s: %v", player.Username, err)
+		}
+		s.kick(ban)
+	}
+
+	expiry := "permanent"
+	if expiresAt != nil {
+		expiry = expiresAt.UTC().Format(time.RFC3339)
+	}
+	if s.eventSvc != nil {
+		s.eventSvc.RecordSecurityEvent(ctx, "ban_issued", player.Username, map[string]string{
+			"player_id":  playerID,
+			"ban_id":     fmt.Sprint(ban.ID),
+			"reason":     req.Reason,
+			"issued_by":  req.IssuedBy,
+			"expires_at": expiry,
+		})
+	}
+	return ban, nil
+}
+
+// Lift ends a ban early.
+func (s *BanService) Lift(ctx context.Context, banID int64, liftedBy, note string) (*model.Ban, error) {
+	if liftedBy == "" {
+		liftedBy = "admin"
+	}
+	ban, err := s.banRepo.Lift(ctx, banID, liftedBy, appealLine(liftedBy, note))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrBanNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The player may still have another ban in force
+	if _, err := s.banRepo.GetActive(ctx, ban.PlayerID); errors.Is(err, pgx.ErrNoRows) {
+		s.mu.Lock()
+		delete(s.banned, ban.PlayerID)
+		s.mu.Unlock()
+	}
+
+	if s.eventSvc != nil {
+		name := ""
+		if player, err := s.playerRepo.GetByID(ctx, ban.PlayerID); err == nil {
+			name = player.Username
+		}
+		s.eventSvc.RecordSecurityEvent(ctx, "ban_lifted", name, map[string]string{
+			"player_id": ban.PlayerID,
+			"ban_id":    fmt.Sprint(ban.ID),
+			"lifted_by": liftedBy,
+		})
+	}
+	return ban, nil
+}
+
+// AddAppealNote appends a note (appeal content, moderator decision...) to a ban.
+func (s *BanService) AddAppealNote(ctx context.Context, banID int64, author, note string) (*model.Ban, error) {
+	note = strings.TrimSpace(note)
+	if note == "" {
+		return nil, ErrEmptyNote
+	}
+	if author == "" {
+		author = "admin"
+	}
+	ok, err := s.banRepo.AppendAppealNote(ctx, banID, appealLine(author, note))
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrBanNotFound
+	}
+	return s.banRepo.GetByID(ctx, banID)
+}
+
+// BanPlayerID returns the banned player of a ban ("" if there is no such ban).
+func (s *BanService) BanPlayerID(ctx context.Context, banID int64) (string, error) {
+	ban, err := s.banRepo.GetByID(ctx, banID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return ban.PlayerID, nil
+}
+
+// ListForPlayer returns a player's ban history.
+func (s *BanService) ListForPlayer(ctx context.Context, playerID string) ([]model.Ban, error) {
+	bans, err := s.banRepo.ListForPlayer(ctx, playerID)
+	if err != nil {
+		return nil, err
+	}
+	if bans == nil {
+		bans = []model.Ban{}
+	}
+	return bans, nil
+}
+
+// ActiveBan returns the ban currently in force for a player, or nil.
+func (s *BanService) ActiveBan(ctx context.Context, playerID string) *model.Ban {
+	ban, err := s.banRepo.GetActive(ctx, playerID)
+	if err != nil {
+		return nil
+	}
+	return ban
+}
+
+// kick tells the player why and closes their websocket connections.
+func (s *BanService) kick(ban *model.Ban) {
+	data, _ := json.Marshal(model.WSAccountBanned{Reason: ban.Reason, ExpiresAt: ban.ExpiresAt})
+	if n := s.wsHub.DisconnectPlayer(ban.PlayerID, &model.WSEvent{Type: "account:banned", Data: data}); n > 0 {
+		log.Printf("[BAN] disconnected %d websocket(s) of player %s", n, ban.PlayerID)
+	}
+}
+
+func appealLine(author, note string) string {
+	if note == "" {
+		return ""
+	}
+	return fmt.Sprintf("[%s] %s: %s\n", time.Now().UTC().Format("2006-01-02 15:04"), author, note)
+}
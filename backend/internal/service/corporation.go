@@ -8,6 +8,8 @@ import (
 
 	"spacegame-backend/internal/model"
 	"spacegame-backend/internal/repository"
+
+	"github.com/jackc/pgx/v5"
 )
 
 var (
@@ -28,12 +30,14 @@ var (
 )
 
 type CorporationService struct {
-	corpRepo   *repository.CorporationRepository
-	playerRepo *repository.PlayerRepository
+	corpRepo       *repository.CorporationRepository
+	playerRepo     *repository.PlayerRepository
+	achievementSvc *AchievementService
+	friendSvc      *FriendService
 }
 
-func NewCorporationService(corpRepo *repository.CorporationRepository, playerRepo *repository.PlayerRepository) *CorporationService {
-	return &CorporationService{corpRepo: corpRepo, playerRepo: playerRepo}
+func NewCorporationService(corpRepo *repository.CorporationRepository, playerRepo *repository.PlayerRepository, achievementSvc *AchievementService, friendSvc *FriendService) *CorporationService {
+	return &CorporationService{corpRepo: corpRepo, playerRepo: playerRepo, achievementSvc: achievementSvc, friendSvc: friendSvc}
 }
 
 func (s *CorporationService) Create(ctx context.Context, playerID string, req *model.CreateCorporationRequest) (*model.Corporation, error) {
@@ -81,6 +85,7 @@ func (s *CorporationService) Create(ctx context.Context, playerID string, req *m
 	if err := s.playerRepo.SetCorporationID(ctx, playerID, &corporation.ID); err != nil {
 		return nil, err
 	}
+	s.achievementSvc.Evaluate(ctx, playerID, model.AchievementStatCorporation)
 
 	return corporation, nil
 }
@@ -136,6 +141,9 @@ func (s *CorporationService) AddMember(ctx context.Context, playerID, corporatio
 		if err := s.requireRank(ctx, playerID, corporationID, 2); err != nil {
 			return err
 		}
+		if err := s.friendSvc.CheckNotBlocked(ctx, playerID, targetPlayerID); err != nil {
+			return err
+		}
 	}
 
 	// Check target not already in a corporation
@@ -167,6 +175,7 @@ func (s *CorporationService) AddMember(ctx context.Context, playerID, corporatio
 	if err := s.playerRepo.SetCorporationID(ctx, targetPlayerID, &corporationID); err != nil {
 		return err
 	}
+	s.achievementSvc.Evaluate(ctx, targetPlayerID, model.AchievementStatCorporation)
 
 	// Log activity
 	player, _ := s.playerRepo.GetByID(ctx, playerID)
@@ -231,56 +240,53 @@ func (s *CorporationService) SetMemberRank(ctx context.Context, playerID, corpor
 	return s.corpRepo.SetMemberRank(ctx, targetPlayerID, rankPriority)
 }
 
-func (s *CorporationService) Deposit(ctx context.Context, playerID, corporationID string, amount int64) (int64, error) {
+// Deposit moves credits from the player to the treasury. Returns the new treasury
+// and player balances.
+func (s *CorporationService) Deposit(ctx context.Context, playerID, corporationID string, amount int64) (int64, int64, error) {
 	if amount <= 0 {
-		return 0, ErrInvalidAmount
+		return 0, 0, ErrInvalidAmount
 	}
 
 	member, err := s.corpRepo.GetMember(ctx, playerID)
 	if err != nil {
-		return 0, ErrNotCorporationMember
+		return 0, 0, ErrNotCorporationMember
 	}
 	if member.CorporationID != corporationID {
-		return 0, ErrNotCorporationMember
+		return 0, 0, ErrNotCorporationMember
 	}
 
-	newBalance, err := s.corpRepo.UpdateTreasury(ctx, corporationID, amount)
-	if err != nil {
-		return 0, err
-	}
-
-	player, _ := s.playerRepo.GetByID(ctx, playerID)
-	name := ""
-	if player != nil {
-		name = player.Username
-	}
-	_ = s.corpRepo.AddTransaction(ctx, corporationID, playerID, name, "deposit", amount)
-
-	return newBalance, nil
+	return s.transferTreasury(ctx, playerID, corporationID, amount)
 }
 
-func (s *CorporationService) Withdraw(ctx context.Context, playerID, corporationID string, amount int64) (int64, error) {
+// Withdraw moves credits from the treasury to the player. Returns the new treasury
+// and player balances.
+func (s *CorporationService) Withdraw(ctx context.Context, playerID, corporationID string, amount int64) (int64, int64, error) {
 	if amount <= 0 {
-		return 0, ErrInvalidAmount
+		return 0, 0, ErrInvalidAmount
 	}
 
 	if err := s.requireRank(ctx, playerID, corporationID, 3); err != nil {
-		return 0, err
+		return 0, 0, err
 	}
 
-	newBalance, err := s.corpRepo.UpdateTreasury(ctx, corporationID, -amount)
-	if err != nil {
-		return 0, ErrInsufficientFunds
-	}
+	return s.transferTreasury(ctx, playerID, corporationID, -amount)
+}
 
+func (s *CorporationService) transferTreasury(ctx context.Context, playerID, corporationID string, amount int64) (int64, int64, error) {
 	player, _ := s.playerRepo.GetByID(ctx, playerID)
 	name := ""
 	if player != nil {
 		name = player.Username
 	}
-	_ = s.corpRepo.AddTransaction(ctx, corporationID, playerID, name, "withdraw", amount)
 
-	return newBalance, nil
+	treasury, credits, err := s.corpRepo.TransferTreasury(ctx, corporationID, playerID, name, amount)
+	switch {
+	case errors.Is(err, repository.ErrInsufficientCredits):
+		return 0, 0, ErrInsufficientCredits
+	case errors.Is(err, pgx.ErrNoRows):
+		return 0, 0, ErrInsufficientFunds
+	}
+	return treasury, credits, err
 }
 
 func (s *CorporationService) GetActivity(ctx context.Context, corporationID string, limit int) ([]*model.CorporationActivity, error) {
@@ -378,6 +384,13 @@ func (s *CorporationService) Apply(ctx context.Context, playerID, corporationID,
 	if err != nil {
 		return nil, ErrCorporationNotFound
 	}
+	blocked, err := s.friendSvc.BlockedByOfficers(ctx, corporationID, playerID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, ErrBlocked
+	}
 
 	// Get player name
 	player, err := s.playerRepo.GetByID(ctx, playerID)
@@ -399,7 +412,22 @@ func (s *CorporationService) GetApplications(ctx context.Context, playerID, corp
 	if err := s.requireRank(ctx, playerID, corporationID, 2); err != nil {
 		return nil, err
 	}
-	return s.corpRepo.GetApplications(ctx, corporationID)
+	apps, err := s.corpRepo.GetApplications(ctx, corporationID)
+	if err != nil {
+		return nil, err
+	}
+	// Hide applications from players the officer blocked
+	blocked, err := s.friendSvc.BlockedIDs(ctx, playerID)
+	if err != nil || len(blocked) == 0 {
+		return apps, err
+	}
+	visible := make([]*model.CorporationApplication, 0, len(apps))
+	for _, app := range apps {
+		if !blocked[app.PlayerID] {
+			visible = append(visible, app)
+		}
+	}
+	return visible, nil
 }
 
 func (s *CorporationService) GetMyApplications(ctx context.Context, playerID string) ([]*model.CorporationApplication, error) {
@@ -451,6 +479,7 @@ func (s *CorporationService) HandleApplication(ctx context.Context, playerID, co
 		if err := s.playerRepo.SetCorporationID(ctx, app.PlayerID, &corporationID); err != nil {
 			return err
 		}
+		s.achievementSvc.Evaluate(ctx, app.PlayerID, model.AchievementStatCorporation)
 
 		// Clean up all of this player's applications
 		_ = s.corpRepo.DeletePlayerApplications(ctx, app.PlayerID)
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"spacegame-backend/internal/model"
+	"spacegame-backend/internal/repository"
+)
+
+// CreditLedgerService exposes the credit ledger: player statements and the admin
+// check that every balance matches its ledger.
+type CreditLedgerService struct {
+	ledgerRepo *repository.CreditLedgerRepository
+}
+
+func NewCreditLedgerService(ledgerRepo *repository.CreditLedgerRepository) *CreditLedgerService {
+	return &CreditLedgerService{ledgerRepo: ledgerRepo}
+}
+
+// Statement returns a page of the player's ledger, newest first.
+func (s *CreditLedgerService) Statement(ctx context.Context, playerID string, beforeID int64, limit int) ([]model.CreditLedgerEntry, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	return s.ledgerRepo.ListForPlayer(ctx, playerID, beforeID, limit)
+}
+
+// Reconcile checks every player's credits against the sum of their ledger.
+func (s *CreditLedgerService) Reconcile(ctx context.Context) (*model.CreditReconciliation, error) {
+	players, mismatches, err := s.ledgerRepo.Reconcile(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(mismatches) > 0 {
+		log.Printf("[LEDGER] %d of %d balances don't match the credit ledger", len(mismatches), players)
+	}
+	return &model.CreditReconciliation{CheckedAt: time.Now().UTC(), Players: players, Mismatches: mismatches}, nil
+}
@@ -5,38 +5,47 @@ import (
 	"encoding/json"
 	"log"
 
+	"spacegame-backend/internal/model"
 	"spacegame-backend/internal/repository"
 )
 
 // EventService records game events and dispatches them to Discord.
 type EventService struct {
-	eventRepo *repository.EventRepository
-	webhooks  *DiscordWebhookService
+	eventRepo      *repository.EventRepository
+	webhooks       *DiscordWebhookService
+	achievementSvc *AchievementService
 }
 
-func NewEventService(eventRepo *repository.EventRepository, webhooks *DiscordWebhookService) *EventService {
+func NewEventService(eventRepo *repository.EventRepository, webhooks *DiscordWebhookService, achievementSvc *AchievementService) *EventService {
 	return &EventService{
-		eventRepo: eventRepo,
-		webhooks:  webhooks,
+		eventRepo:      eventRepo,
+		webhooks:       webhooks,
+		achievementSvc: achievementSvc,
 	}
 }
 
+// The Record* methods fed by /server/event take the reporting game server (nil for the legacy key).
+
 // RecordKill saves a kill event and sends it to the kill-feed webhook.
-func (s *EventService) RecordKill(ctx context.Context, killer, victim, weapon, systemName string, systemID int) {
+func (s *EventService) RecordKill(ctx context.Context, serverID *string, killer, victim, weapon, systemName string, systemID int) {
 	details, _ := json.Marshal(map[string]string{"weapon": weapon, "system": systemName})
-	_, err := s.eventRepo.Create(ctx, "kill", killer, victim, details, systemID)
+	_, err := s.eventRepo.Create(ctx, "kill", killer, victim, details, systemID, serverID)
 	if err != nil {
 		log.Printf("[events] failed to record kill: %v", err)
+	} else {
+		s.achievementSvc.EvaluateByNameLater(killer, model.AchievementStatKills)
 	}
 	s.webhooks.SendKillFeed(killer, victim, weapon, systemName)
 }
 
 // RecordDiscovery saves a discovery event and sends it to the events webhook.
-func (s *EventService) RecordDiscovery(ctx context.Context, player, what, systemName string, systemID int) {
+func (s *EventService) RecordDiscovery(ctx context.Context, serverID *string, player, what, systemName string, systemID int) {
 	details, _ := json.Marshal(map[string]string{"discovery": what, "system": systemName})
-	_, err := s.eventRepo.Create(ctx, "discovery", player, what, details, systemID)
+	_, err := s.eventRepo.Create(ctx, "discovery", player, what, details, systemID, serverID)
 	if err != nil {
 		log.Printf("[events] failed to record discovery: %v", err)
+	} else {
+		s.achievementSvc.EvaluateByNameLater(player, model.AchievementStatDiscoveries)
 	}
 	s.webhooks.SendGameEvent("discovery",
 		"🌟 Nouvelle découverte!",
@@ -45,9 +54,9 @@ func (s *EventService) RecordDiscovery(ctx context.Context, player, what, system
 }
 
 // RecordEconomyEvent saves an economy event and sends it to the events webhook.
-func (s *EventService) RecordEconomyEvent(ctx context.Context, eventType, details string) {
+func (s *EventService) RecordEconomyEvent(ctx context.Context, serverID *string, eventType, details string) {
 	detailsJSON, _ := json.Marshal(map[string]string{"info": details})
-	_, err := s.eventRepo.Create(ctx, "economy", "", "", detailsJSON, 0)
+	_, err := s.eventRepo.Create(ctx, "economy", "", "", detailsJSON, 0, serverID)
 	if err != nil {
 		log.Printf("[events] failed to record economy event: %v", err)
 	}
@@ -67,7 +76,7 @@ func (s *EventService) RecordBugReport(ctx context.Context, reporter, title, des
 		detailsMap["screenshot_b64"] = screenshotB64
 	}
 	details, _ := json.Marshal(detailsMap)
-	_, err := s.eventRepo.Create(ctx, "bug_report", reporter, "", details, systemID)
+	_, err := s.eventRepo.Create(ctx, "bug_report", reporter, "", details, systemID, nil)
 	if err != nil {
 		log.Printf("[events] failed to record bug report: %v", err)
 	}
@@ -75,11 +84,22 @@ func (s *EventService) RecordBugReport(ctx context.Context, reporter, title, des
 }
 
 // RecordCorporationEvent saves a corporation event and sends it to the corporation-activity webhook.
-func (s *EventService) RecordCorporationEvent(ctx context.Context, eventType, corporationName, details string) {
+func (s *EventService) RecordCorporationEvent(ctx context.Context, serverID *string, eventType, corporationName, details string) {
 	detailsJSON, _ := json.Marshal(map[string]string{"corporation": corporationName, "info": details})
-	_, err := s.eventRepo.Create(ctx, "corporation_"+eventType, corporationName, "", detailsJSON, 0)
+	_, err := s.eventRepo.Create(ctx, "corporation_"+eventType, corporationName, "", detailsJSON, 0, serverID)
 	if err != nil {
 		log.Printf("[events] failed to record corporation event: %v", err)
 	}
 	s.webhooks.SendCorporationEvent(eventType, corporationName, details)
 }
+
+// RecordSecurityEvent saves a security-relevant event (token reuse, lockouts, ...).
+// These are kept out of the public Discord channels and only logged server-side.
+func (s *EventService) RecordSecurityEvent(ctx context.Context, eventType, playerName string, details map[string]string) {
+	detailsJSON, _ := json.Marshal(details)
+	_, err := s.eventRepo.Create(ctx, "security_"+eventType, playerName, "", detailsJSON, 0, nil)
+	if err != nil {
+		log.Printf("[events] failed to record security event: %v", err)
+	}
+	log.Printf("[SECURITY] %s player=%s details=%s", eventType, playerName, detailsJSON)
+}
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"spacegame-backend/internal/model"
+	"spacegame-backend/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// MaxFriends is how many friends a player may have.
+const MaxFriends = 200
+
+var (
+	ErrFriendSelf            = errors.New("cannot befriend or block yourself")
+	ErrAlreadyFriends        = errors.New("already friends with this player")
+	ErrFriendRequestExists   = errors.New("friend request already sent")
+	ErrFriendRequestNotFound = errors.New("friend request not found")
+	ErrFriendNotFound        = errors.New("not friends with this player")
+	ErrFriendLimit           = errors.New("friend list is full")
+	ErrPlayerBlocked         = errors.New("unblock this player first")
+	ErrFriendRequestRefused  = errors.New("this player does not accept friend requests from you")
+	ErrNotBlocked            = errors.New("player is not blocked")
+	// ErrBlocked is returned by the other services when a block forbids an interaction.
+	// It doesn't tell which player blocked the other.
+	ErrBlocked = errors.New("a block prevents interacting with this player")
+)
+
+// FriendService manages the social graph: friend requests, friendships and blocks.
+// The other player is told about requests and acceptances over WebSocket.
+type FriendService struct {
+	repo        *repository.FriendRepository
+	playerRepo  *repository.PlayerRepository
+	presenceSvc *PresenceService
+	wsHub       *WSHub
+}
+
+func NewFriendService(repo *repository.FriendRepository, playerRepo *repository.PlayerRepository, presenceSvc *PresenceService, wsHub *WSHub) *FriendService {
+	return &FriendService{repo: repo, playerRepo: playerRepo, presenceSvc: presenceSvc, wsHub: wsHub}
+}
+
+// List returns the player's friends with their presence, pending requests and blocks.
+func (s *FriendService) List(ctx context.Context, playerID string) (*model.FriendList, error) {
+	friends, err := s.repo.ListFriends(ctx, playerID, time.Now().Add(-PresenceTimeout))
+	if err != nil {
+		return nil, err
+	}
+	s.presenceSvc.Resolve(friends)
+
+	out := &model.FriendList{Friends: friends}
+	if out.Incoming, err = s.repo.ListContacts(ctx, playerID, model.RelationPending, true); err != nil {
+		return nil, err
+	}
+	if out.Outgoing, err = s.repo.ListContacts(ctx, playerID, model.RelationPending, false); err != nil {
+		return nil, err
+	}
+	if out.Blocked, err = s.repo.ListContacts(ctx, playerID, model.RelationBlocked, false); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SendRequest asks username to be friends. If they already asked the player, their
+// request is accepted instead; the result tells which happened.
+func (s *FriendService) SendRequest(ctx context.Context, playerID, username string) (accepted bool, err error) {
+	target, err := s.playerRepo.GetByUsername(ctx, strings.TrimSpace(username))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, ErrPlayerNotFound
+	}
+	if err != nil {
+		return false, err
+	}
+	if target.ID == playerID {
+		return false, ErrFriendSelf
+	}
+
+	out, in, err := s.repo.Relations(ctx, playerID, target.ID)
+	if err != nil {
+		return false, err
+	}
+	switch {
+	case out == model.RelationBlocked:
+		return false, ErrPlayerBlocked
+	case in == model.RelationBlocked:
+		return false, ErrFriendRequestRefused
+	case out == model.RelationFriend:
+		return false, ErrAlreadyFriends
+	case out == model.RelationPending:
+		return false, ErrFriendRequestExists
+	case in == model.RelationPending:
+		return true, s.Respond(ctx, playerID, target.ID, true)
+	}
+
+	if err := s.checkLimit(ctx, playerID); err != nil {
+		return false, err
+	}
+	if err := s.repo.CreateRequest(ctx, playerID, target.ID); err != nil {
+		return false, err
+	}
+	s.notify(ctx, target.ID, "friend:request", playerID)
+	return false, nil
+}
+
+// Respond accepts or declines the friend request fromID sent to the player.
+func (s *FriendService) Respond(ctx context.Context, playerID, fromID string, accept bool) error {
+	if !accept {
+		ok, err := s.repo.DeleteRequest(ctx, fromID, playerID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrFriendRequestNotFound
+		}
+		return nil
+	}
+
+	if err := s.checkLimit(ctx, playerID); err != nil {
+		return err
+	}
+	if err := s.checkLimit(ctx, fromID); err != nil {
+		return err
+	}
+	ok, err := s.repo.Accept(ctx, fromID, playerID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrFriendRequestNotFound
+	}
+	s.notify(ctx, fromID, "friend:accepted", playerID)
+	return nil
+}
+
+// Remove ends a friendship, or cancels a request sent to or received from otherID.
+func (s *FriendService) Remove(ctx context.Context, playerID, otherID string) error {
+	ok, err := s.repo.Remove(ctx, playerID, otherID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrFriendNotFound
+	}
+	return nil
+}
+
+// Block stops otherID from sending friend requests to the player or seeing their
+// presence, and ends any friendship between them.
+func (s *FriendService) Block(ctx context.Context, playerID, otherID string) error {
+	if otherID == playerID {
+		return ErrFriendSelf
+	}
+	if _, err := s.playerRepo.GetByID(ctx, otherID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrPlayerNotFound
+		}
+		return err
+	}
+	return s.repo.Block(ctx, playerID, otherID)
+}
+
+// Unblock lifts the player's block of otherID.
+func (s *FriendService) Unblock(ctx context.Context, playerID, otherID string) error {
+	ok, err := s.repo.Unblock(ctx, playerID, otherID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotBlocked
+	}
+	return nil
+}
+
+// CheckNotBlocked returns ErrBlocked if either player blocked the other.
+func (s *FriendService) CheckNotBlocked(ctx context.Context, playerID, otherID string) error {
+	blocked, err := s.repo.Blocked(ctx, playerID, otherID)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return ErrBlocked
+	}
+	return nil
+}
+
+// BlockedIDs returns the set of players the player blocked.
+func (s *FriendService) BlockedIDs(ctx context.Context, playerID string) (map[string]bool, error) {
+	blocks, err := s.repo.BlocksOf(ctx, []string{playerID})
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]bool, len(blocks[playerID]))
+	for _, c := range blocks[playerID] {
+		ids[c.PlayerID] = true
+	}
+	return ids, nil
+}
+
+// BlocksOf returns the players each of playerIDs blocked, for the game servers to filter
+// live chat. Every requested player is present (with an empty list if needed).
+func (s *FriendService) BlocksOf(ctx context.Context, playerIDs []string) (map[string][]model.Contact, error) {
+	blocks, err := s.repo.BlocksOf(ctx, playerIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range playerIDs {
+		if blocks[id] == nil {
+			blocks[id] = []model.Contact{}
+		}
+	}
+	return blocks, nil
+}
+
+// BlockedByOfficers reports whether an officer of the corporation (rank 2+, those who
+// handle applications) blocked the player.
+func (s *FriendService) BlockedByOfficers(ctx context.Context, corporationID, playerID string) (bool, error) {
+	return s.repo.BlockedByMembers(ctx, corporationID, playerID, 2)
+}
+
+func (s *FriendService) checkLimit(ctx context.Context, playerID string) error {
+	n, err := s.repo.CountFriends(ctx, playerID)
+	if err != nil {
+		return err
+	}
+	if n >= MaxFriends {
+		return ErrFriendLimit
+	}
+	return nil
+}
+
+// notify pushes a friend event about fromID to playerID.
+func (s *FriendService) notify(ctx context.Context, playerID, eventType, fromID string) {
+	from, err := s.playerRepo.GetByID(ctx, fromID)
+	if err != nil {
+		return
+	}
+	data, _ := json.Marshal(model.Contact{PlayerID: from.ID, Username: from.Username, CreatedAt: time.Now().UTC()})
+	s.wsHub.SendToPlayer(playerID, &model.WSEvent{Type: eventType, Data: data})
+}
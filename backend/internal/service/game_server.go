@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"spacegame-backend/internal/model"
+	"spacegame-backend/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	// ServerSignatureMaxSkew is how far a signed request's timestamp may drift from our clock.
+	// Nonces are kept twice as long, so a replay is always caught by one check or the other.
+	ServerSignatureMaxSkew = 5 * time.Minute
+	gameServerTouchEvery   = time.Minute
+)
+
+var (
+	ErrGameServerNotFound = errors.New("game server not found")
+	ErrGameServerExists   = errors.New("a game server with this name already exists")
+	ErrInvalidServerName  = errors.New("name must be 1-64 characters")
+	ErrInvalidServerSig   = errors.New("invalid request signature")
+	ErrStaleServerRequest = errors.New("request timestamp outside the allowed window")
+	ErrReplayedRequest    = errors.New("request nonce already used")
+	ErrUnknownGameServer  = errors.New("unknown or revoked game server")
+)
+
+// GameServerService authenticates game servers. Active servers are cached in memory
+// (refreshed periodically, like bans) so signature checks don't hit the database;
+// only the nonce is stored per request.
+type GameServerService struct {
+	repo     *repository.GameServerRepository
+	eventSvc *EventService
+
+	mu       sync.RWMutex
+	servers  map[string]model.GameServer
+	lastSeen map[string]time.Time
+}
+
+func NewGameServerService(repo *repository.GameServerRepository, eventSvc *EventService) *GameServerService {
+	return &GameServerService{
+		repo:     repo,
+		eventSvc: eventSvc,
+		servers:  make(map[string]model.GameServer),
+		lastSeen: make(map[string]time.Time),
+	}
+}
+
+// Refresh reloads the active servers (picks up registrations/revocations made by other instances).
+func (s *GameServerService) Refresh(ctx context.Context) error {
+	list, err := s.repo.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	servers := make(map[string]model.GameServer, len(list))
+	for _, srv := range list {
+		if srv.RevokedAt == nil {
+			servers[srv.ID] = srv
+		}
+	}
+
+	s.mu.Lock()
+	s.servers = servers
+	s.mu.Unlock()
+	return nil
+}
+
+// List returns every registered server (secrets are not serialised).
+func (s *GameServerService) List(ctx context.Context) ([]model.GameServer, error) {
+	return s.repo.List(ctx)
+}
+
+// Register creates a server and returns its credentials. The secret is only shown here.
+func (s *GameServerService) Register(ctx context.Context, name string) (*model.GameServerCredentials, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 64 {
+		return nil, ErrInvalidServerName
+	}
+
+	secret, err := newServerSecret()
+	if err != nil {
+		return nil, err
+	}
+	srv, err := s.repo.Create(ctx, name, secret)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrGameServerExists
+		}
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.servers[srv.ID] = *srv
+	s.mu.Unlock()
+
+	s.eventSvc.RecordSecurityEvent(ctx, "game_server_registered", "", map[string]string{"server_id": srv.ID, "name": srv.Name})
+	log.Printf("[SERVERS] registered game server %s (%s)", srv.Name, srv.ID)
+	return &model.GameServerCredentials{ID: srv.ID, Name: srv.Name, Secret: secret}, nil
+}
+
+// RotateSecret issues a new secret; the old one stops working immediately.
+func (s *GameServerService) RotateSecret(ctx context.Context, id string) (*model.GameServerCredentials, error) {
+	secret, err := newServerSecret()
+	if err != nil {
+		return nil, err
+	}
+	srv, err := s.repo.SetSecret(ctx, id, secret)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrGameServerNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.servers[srv.ID] = *srv
+	s.mu.Unlock()
+
+	s.eventSvc.RecordSecurityEvent(ctx, "game_server_secret_rotated", "", map[string]string{"server_id": srv.ID, "name": srv.Name})
+	return &model.GameServerCredentials{ID: srv.ID, Name: srv.Name, Secret: secret}, nil
+}
+
+// Revoke disables a server; its requests are rejected from now on.
+func (s *GameServerService) Revoke(ctx context.Context, id string) error {
+	ok, err := s.repo.Revoke(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrGameServerNotFound
+	}
+
+	s.mu.Lock()
+	delete(s.servers, id)
+	s.mu.Unlock()
+
+	s.eventSvc.RecordSecurityEvent(ctx, "game_server_revoked", "", map[string]string{"server_id": id})
+	log.Printf("[SERVERS] revoked game server %s", id)
+	return nil
+}
+
+// VerifyRequest checks a signed request and returns the server that sent it.
+func (s *GameServerService) VerifyRequest(ctx context.Context, req *model.SignedServerRequest) (*model.GameServer, error) {
+	s.mu.RLock()
+	srv, ok := s.servers[req.ServerID]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, ErrUnknownGameServer
+	}
+
+	ts, err := strconv.ParseInt(req.Timestamp, 10, 64)
+	if err != nil {
+		return nil, ErrStaleServerRequest
+	}
+	skew := time.Since(time.Unix(ts, 0))
+	if skew > ServerSignatureMaxSkew || skew < -ServerSignatureMaxSkew {
+		return nil, ErrStaleServerRequest
+	}
+	if req.Nonce == "" || len(req.Nonce) > 64 {
+		return nil, ErrInvalidServerSig
+	}
+
+	expected := SignServerRequest(srv.Secret, req.Method, req.Path, req.Timestamp, req.Nonce, req.Body)
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(req.Signature))) {
+		return nil, ErrInvalidServerSig
+	}
+
+	// Only burn the nonce once the signature is valid, so forged requests can't exhaust it
+	fresh, err := s.repo.UseNonce(ctx, srv.ID, req.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	if !fresh {
+		return nil, ErrReplayedRequest
+	}
+
+	s.touch(srv.ID)
+	return &srv, nil
+}
+
+// PurgeNonces deletes nonces older than the replay window.
+func (s *GameServerService) PurgeNonces(ctx context.Context) (int64, error) {
+	return s.repo.DeleteNoncesOlderThan(ctx, 2*ServerSignatureMaxSkew)
+}
+
+// touch updates last_seen_at at most once a minute per server.
+func (s *GameServerService) touch(id string) {
+	now := time.Now()
+	s.mu.Lock()
+	if now.Sub(s.lastSeen[id]) < gameServerTouchEvery {
+		s.mu.Unlock()
+		return
+	}
+	s.lastSeen[id] = now
+	s.mu.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.repo.Touch(ctx, id); err != nil {
+			log.Printf("[SERVERS] failed to update last_seen_at of %s: %v", id, err)
+		}
+	}()
+}
+
+// SignServerRequest computes the X-Server-Signature header of a game server request:
+// hex(HMAC-SHA256(secret, METHOD \n path?query \n timestamp \n nonce \n hex(SHA256(body)))).
+func SignServerRequest(secret, method, path, timestamp, nonce string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strings.ToUpper(method) + "\n" + path + "\n" + timestamp + "\n" + nonce + "\n" + hex.EncodeToString(bodyHash[:])))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func newServerSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
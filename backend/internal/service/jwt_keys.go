@@ -0,0 +1,217 @@
+package service
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"spacegame-backend/internal/model"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// KeySet holds the Ed25519 keys used for JWTs: one signing key, and every public
+// key still accepted for verification. Keeping the previous key in the set after a
+// rotation lets tokens it signed live out their lifetime instead of logging everyone out.
+type KeySet struct {
+	signingKID string
+	signingKey ed25519.PrivateKey
+	verifyKeys map[string]ed25519.PublicKey
+	order      []string // kids in load order, for a stable JWKS
+}
+
+// LoadKeySet loads keys from PEM blocks (JWT_PRIVATE_KEYS) and from a directory
+// (JWT_KEYS_DIR) holding PKCS#8 private keys (*.pem) and verify-only public keys (*.pub.pem).
+// The signing key is signingKID if set, otherwise the last private key loaded (files
+// are read in name order, so date-named files make the newest key sign).
+// Without any key, an ephemeral one is generated when allowEphemeral is set (development
+// only: tokens then don't survive a restart nor work across replicas); otherwise it fails.
+func LoadKeySet(pemKeys, dir, signingKID string, allowEphemeral bool) (*KeySet, error) {
+	ks := &KeySet{verifyKeys: make(map[string]ed25519.PublicKey)}
+	var lastPrivate string
+	private := make(map[string]ed25519.PrivateKey)
+
+	addPEM := func(data []byte, source string) error {
+		for {
+			var block *pem.Block
+			block, data = pem.Decode(data)
+			if block == nil {
+				return nil
+			}
+			switch block.Type {
+			case "PRIVATE KEY":
+				key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+				if err != nil {
+					return fmt.Errorf("%s: %w", source, err)
+				}
+				priv, ok := key.(ed25519.PrivateKey)
+				if !ok {
+					return fmt.Errorf("%s: not an Ed25519 private key", source)
+				}
+				kid := ks.add(priv.Public().(ed25519.PublicKey))
+				private[kid] = priv
+				lastPrivate = kid
+			case "PUBLIC KEY":
+				key, err := x509.ParsePKIXPublicKey(block.Bytes)
+				if err != nil {
+					return fmt.Errorf("%s: %w", source, err)
+				}
+				pub, ok := key.(ed25519.PublicKey)
+				if !ok {
+					return fmt.Errorf("%s: not an Ed25519 public key", source)
+				}
+				ks.add(pub)
+			default:
+				return fmt.Errorf("%s: unexpected PEM block %q", source, block.Type)
+			}
+		}
+	}
+
+	if strings.TrimSpace(pemKeys) != "" {
+		// Env vars often carry "\n" literally
+		if err := addPEM([]byte(strings.ReplaceAll(pemKeys, `\n`, "\n")), "JWT_PRIVATE_KEYS"); err != nil {
+			return nil, err
+		}
+	}
+
+	if dir != "" {
+		files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(files)
+		for _, f := range files {
+			data, err := os.ReadFile(f)
+			if err != nil {
+				return nil, err
+			}
+			if err := addPEM(data, f); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if signingKID == "" {
+		signingKID = lastPrivate
+	}
+	if signingKID == "" {
+		if !allowEphemeral {
+			return nil, errors.New("no JWT signing key configured (set JWT_PRIVATE_KEYS or JWT_KEYS_DIR)")
+		}
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("generate jwt key: %w", err)
+		}
+		signingKID = ks.add(priv.Public().(ed25519.PublicKey))
+		private[signingKID] = priv
+		log.Printf("WARNING: no JWT signing key configured — using ephemeral key %s (set JWT_PRIVATE_KEYS or JWT_KEYS_DIR)", signingKID)
+	}
+
+	priv, ok := private[signingKID]
+	if !ok {
+		return nil, fmt.Errorf("jwt signing key %q not found", signingKID)
+	}
+	ks.signingKID = signingKID
+	ks.signingKey = priv
+
+	log.Printf("JWT keys loaded: signing with %s, %d verification key(s)", signingKID, len(ks.verifyKeys))
+	return ks, nil
+}
+
+// add registers a verification key and returns its kid.
+func (ks *KeySet) add(pub ed25519.PublicKey) string {
+	kid := KeyID(pub)
+	if _, exists := ks.verifyKeys[kid]; !exists {
+		ks.verifyKeys[kid] = pub
+		ks.order = append(ks.order, kid)
+	}
+	return kid
+}
+
+// KeyID derives a key's kid from its RFC 7638 JWK thumbprint, so the same key
+// always gets the same kid no matter where it is loaded from.
+func KeyID(pub ed25519.PublicKey) string {
+	x := base64.RawURLEncoding.EncodeToString(pub)
+	sum := sha256.Sum256([]byte(`{"crv":"Ed25519","kty":"OKP","x":"` + x + `"}`))
+	return base64.RawURLEncoding.EncodeToString(sum[:])[:16]
+}
+
+// sign signs claims with the current signing key and sets the kid header.
+func (ks *KeySet) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["kid"] = ks.signingKID
+	return token.SignedString(ks.signingKey)
+}
+
+// keyFunc resolves the verification key from the token's kid header.
+func (ks *KeySet) keyFunc(t *jwt.Token) (interface{}, error) {
+	if _, ok := t.Method.(*jwt.SigningMethodEd25519); !ok {
+		return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+	}
+	kid, _ := t.Header["kid"].(string)
+	pub, ok := ks.verifyKeys[kid]
+	if !ok {
+		return nil, errors.New("unknown key id")
+	}
+	return pub, nil
+}
+
+// JWKS returns the public keys in JSON Web Key Set format.
+func (ks *KeySet) JWKS() *model.JWKS {
+	set := &model.JWKS{Keys: make([]model.JWK, 0, len(ks.order))}
+	for _, kid := range ks.order {
+		set.Keys = append(set.Keys, model.JWK{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(ks.verifyKeys[kid]),
+			Kid: kid,
+			Use: "sig",
+			Alg: "EdDSA",
+		})
+	}
+	return set
+}
+
+// GenerateKeyPEM returns a new Ed25519 private key as PKCS#8 PEM, and its kid.
+func GenerateKeyPEM() ([]byte, string, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, "", err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return nil, "", err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), KeyID(pub), nil
+}
+
+// PublicKeyPEM returns the verify-only PEM for a private key PEM, used when retiring a signing key.
+func PublicKeyPEM(privatePEM []byte) ([]byte, error) {
+	block, _ := pem.Decode(privatePEM)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, errors.New("no PRIVATE KEY block found")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.New("not an Ed25519 private key")
+	}
+	der, err := x509.MarshalPKIXPublicKey(priv.Public())
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
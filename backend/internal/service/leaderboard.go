@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"time"
+
+	"spacegame-backend/internal/model"
+	"spacegame-backend/internal/repository"
+)
+
+// leaderboardSize is how many entries are kept per board and period.
+const leaderboardSize = 1000
+
+var (
+	ErrUnknownLeaderboard = errors.New("unknown leaderboard")
+	ErrUnknownPeriod      = errors.New("period not available for this leaderboard")
+)
+
+// LeaderboardService computes the public leaderboards into the leaderboard table
+// (Refresh runs periodically) and serves them page by page.
+type LeaderboardService struct {
+	repo *repository.LeaderboardRepository
+}
+
+func NewLeaderboardService(repo *repository.LeaderboardRepository) *LeaderboardService {
+	return &LeaderboardService{repo: repo}
+}
+
+// Boards lists the available boards and their periods.
+func (s *LeaderboardService) Boards() []model.LeaderboardInfo {
+	return model.Leaderboards
+}
+
+// Refresh recomputes every board for every period. A failing board is logged and
+// keeps its previous ranking; the number of boards refreshed is returned.
+func (s *LeaderboardService) Refresh(ctx context.Context) int {
+	now := time.Now()
+	refreshed := 0
+	for _, info := range model.Leaderboards {
+		for _, period := range info.Periods {
+			var since *time.Time
+			switch period {
+			case model.LeaderboardDaily:
+				t := now.Add(-24 * time.Hour)
+				since = &t
+			case model.LeaderboardWeekly:
+				t := now.AddDate(0, 0, -7)
+				since = &t
+			}
+			if _, err := s.repo.Recompute(ctx, info.Board, period, since, leaderboardSize); err != nil {
+				log.Printf("[LEADERBOARD] failed to compute %s/%s: %v", info.Board, period, err)
+				continue
+			}
+			refreshed++
+		}
+	}
+	return refreshed
+}
+
+// Get returns a page of a board (limit defaults to 50, at most 100).
+func (s *LeaderboardService) Get(ctx context.Context, board, period string, limit, offset int) (*model.Leaderboard, error) {
+	info := leaderboardInfo(board)
+	if info == nil {
+		return nil, ErrUnknownLeaderboard
+	}
+	if !slices.Contains(info.Periods, period) {
+		return nil, ErrUnknownPeriod
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return s.repo.Get(ctx, board, period, limit, offset)
+}
+
+func leaderboardInfo(board string) *model.LeaderboardInfo {
+	for i := range model.Leaderboards {
+		if model.Leaderboards[i].Board == board {
+			return &model.Leaderboards[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,310 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"spacegame-backend/internal/model"
+	"spacegame-backend/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// MailTTL is how long mail stays in the inbox. Attachments not claimed by then go
+	// back to the sender (or, for system mail, to the recipient).
+	MailTTL         = 30 * 24 * time.Hour
+	maxMailSubject  = 100
+	maxMailBody     = 2000
+	mailExpiryBatch = 500
+)
+
+var (
+	ErrMailNotFound         = errors.New("mail not found")
+	ErrMailSelf             = errors.New("cannot send mail to yourself")
+	ErrInvalidMail          = errors.New("subject must be 1-100 characters and body at most 2000")
+	ErrInvalidAttachments   = errors.New("credits can't be negative and up to 20 items, each with a source, a name and a positive quantity")
+	ErrCorpMailAttachments  = errors.New("attachments can't be sent to a whole corporation")
+	ErrMailItemsMissing     = errors.New("you don't hold the attached items")
+	ErrNothingToClaim       = errors.New("this mail has nothing to claim")
+	ErrMailHasAttachments   = errors.New("claim the attachments before deleting this mail")
+	ErrMailRecipientMissing = errors.New("to (username) or corporation is required")
+)
+
+// MailService runs the in-game mail. Attachments are taken from the sender when the
+// mail is sent and held until the recipient claims them; recipients are told about new
+// mail over WebSocket ("mail:new"). Other services send system mail through it.
+type MailService struct {
+	repo       *repository.MailRepository
+	playerRepo *repository.PlayerRepository
+	corpRepo   *repository.CorporationRepository
+	friendSvc  *FriendService
+	wsHub      *WSHub
+}
+
+func NewMailService(
+	repo *repository.MailRepository,
+	playerRepo *repository.PlayerRepository,
+	corpRepo *repository.CorporationRepository,
+	friendSvc *FriendService,
+	wsHub *WSHub,
+) *MailService {
+	return &MailService{repo: repo, playerRepo: playerRepo, corpRepo: corpRepo, friendSvc: friendSvc, wsHub: wsHub}
+}
+
+// Inbox returns the player's mail, newest first, with the unread count.
+func (s *MailService) Inbox(ctx context.Context, playerID string, unreadOnly bool, limit int) (*model.Inbox, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 100
+	}
+	mail, err := s.repo.ListInbox(ctx, playerID, unreadOnly, limit)
+	if err != nil {
+		return nil, err
+	}
+	unread, err := s.repo.CountUnread(ctx, playerID)
+	if err != nil {
+		return nil, err
+	}
+	return &model.Inbox{Mail: mail, Unread: unread}, nil
+}
+
+// Read returns one of the player's mails and marks it read.
+func (s *MailService) Read(ctx context.Context, playerID string, id int64) (*model.Mail, error) {
+	m, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrMailNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if m.RecipientID != playerID || !m.ExpiresAt.After(time.Now()) {
+		return nil, ErrMailNotFound
+	}
+	if m.ReadAt == nil {
+		if err := s.repo.MarkRead(ctx, id, playerID); err != nil {
+			return nil, err
+		}
+		now := time.Now().UTC()
+		m.ReadAt = &now
+	}
+	return m, nil
+}
+
+// Send mails a player, or every member of the sender's corporation (officers only).
+// Blocks apply: a player who blocked the sender (or was blocked by them) gets nothing.
+// Returns how many mails were sent.
+func (s *MailService) Send(ctx context.Context, playerID string, req *model.SendMailRequest) (int, error) {
+	subject := strings.TrimSpace(req.Subject)
+	if subject == "" || utf8.RuneCountInString(subject) > maxMailSubject || utf8.RuneCountInString(req.Body) > maxMailBody {
+		return 0, ErrInvalidMail
+	}
+	items, err := normaliseTradeItems(req.Items)
+	if err != nil || req.Credits < 0 {
+		return 0, ErrInvalidAttachments
+	}
+	sender, err := s.playerRepo.GetByID(ctx, playerID)
+	if err != nil {
+		return 0, err
+	}
+
+	if req.Corporation {
+		if req.Credits > 0 || len(items) > 0 {
+			return 0, ErrCorpMailAttachments
+		}
+		return s.sendToCorporation(ctx, sender, subject, req.Body)
+	}
+	if req.To == "" {
+		return 0, ErrMailRecipientMissing
+	}
+
+	recipient, err := s.playerRepo.GetByUsername(ctx, strings.TrimSpace(req.To))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrPlayerNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	if recipient.ID == playerID {
+		return 0, ErrMailSelf
+	}
+	if err := s.friendSvc.CheckNotBlocked(ctx, playerID, recipient.ID); err != nil {
+		return 0, err
+	}
+
+	m := &model.Mail{
+		RecipientID: recipient.ID,
+		SenderID:    &sender.ID,
+		SenderName:  sender.Username,
+		Subject:     subject,
+		Body:        req.Body,
+		Credits:     req.Credits,
+		Items:       items,
+		ExpiresAt:   time.Now().Add(MailTTL),
+	}
+	switch err := s.repo.Send(ctx, m); {
+	case errors.Is(err, repository.ErrInsufficientCredits):
+		return 0, ErrInsufficientCredits
+	case errors.Is(err, repository.ErrInsufficientItems):
+		return 0, ErrMailItemsMissing
+	case err != nil:
+		return 0, err
+	}
+	s.Announce(m)
+	return 1, nil
+}
+
+func (s *MailService) sendToCorporation(ctx context.Context, sender *model.Player, subject, body string) (int, error) {
+	member, err := s.corpRepo.GetMember(ctx, sender.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrNotCorporationMember
+	}
+	if err != nil {
+		return 0, err
+	}
+	if member.RankPriority < 2 {
+		return 0, ErrNotCorporationLeader
+	}
+	members, err := s.corpRepo.GetMembers(ctx, member.CorporationID)
+	if err != nil {
+		return 0, err
+	}
+
+	ids := make([]string, 0, len(members))
+	for _, m := range members {
+		if m.PlayerID != sender.ID {
+			ids = append(ids, m.PlayerID)
+		}
+	}
+	blocks, err := s.friendSvc.BlocksOf(ctx, append(ids, sender.ID))
+	if err != nil {
+		return 0, err
+	}
+	blocked := make(map[string]bool, len(blocks[sender.ID]))
+	for _, c := range blocks[sender.ID] {
+		blocked[c.PlayerID] = true
+	}
+	for _, id := range ids {
+		for _, c := range blocks[id] {
+			if c.PlayerID == sender.ID {
+				blocked[id] = true
+			}
+		}
+	}
+
+	expiresAt := time.Now().Add(MailTTL)
+	mails := make([]*model.Mail, 0, len(ids))
+	for _, id := range ids {
+		if blocked[id] {
+			continue
+		}
+		mails = append(mails, &model.Mail{
+			RecipientID:   id,
+			SenderID:      &sender.ID,
+			SenderName:    sender.Username,
+			CorporationID: &member.CorporationID,
+			Subject:       subject,
+			Body:          body,
+			ExpiresAt:     expiresAt,
+		})
+	}
+	if err := s.repo.SendMany(ctx, mails); err != nil {
+		return 0, err
+	}
+	s.Announce(mails...)
+	return len(mails), nil
+}
+
+// Claim gives the player the credits and items attached to a mail.
+func (s *MailService) Claim(ctx context.Context, playerID string, id int64) (*model.Mail, error) {
+	m, err := s.repo.Claim(ctx, id, playerID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrMailNotFound
+	}
+	if errors.Is(err, repository.ErrNothingToClaim) {
+		return nil, ErrNothingToClaim
+	}
+	return m, err
+}
+
+// Delete removes one of the player's mails once nothing is left to claim.
+func (s *MailService) Delete(ctx context.Context, playerID string, id int64) error {
+	ok, err := s.repo.Delete(ctx, id, playerID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if _, err := s.Read(ctx, playerID, id); err != nil {
+			return err
+		}
+		return ErrMailHasAttachments
+	}
+	return nil
+}
+
+// SystemMail builds a mail from the game itself (no sender to return attachments to).
+func (s *MailService) SystemMail(recipientID, senderName, subject, body string) *model.Mail {
+	return &model.Mail{
+		RecipientID: recipientID,
+		SenderName:  senderName,
+		Subject:     subject,
+		Body:        body,
+		ExpiresAt:   time.Now().Add(MailTTL),
+	}
+}
+
+// Announce pushes "mail:new" to the recipients of stored mails.
+func (s *MailService) Announce(mails ...*model.Mail) {
+	for _, m := range mails {
+		data, _ := json.Marshal(m)
+		s.wsHub.SendToPlayer(m.RecipientID, &model.WSEvent{Type: "mail:new", Data: data})
+	}
+}
+
+// ProcessExpired settles the expired mail: unclaimed attachments go back to the sender
+// in a return mail (or to the recipient when there is no sender), then expired mail is
+// deleted. Each mail is settled in its own transaction; failures are logged and retried
+// on the next run.
+func (s *MailService) ProcessExpired(ctx context.Context) (int64, error) {
+	expired, err := s.repo.ListExpiredWithAttachments(ctx, mailExpiryBatch)
+	if err != nil {
+		return 0, err
+	}
+	for i := range expired {
+		m := &expired[i]
+		if m.SenderID == nil {
+			if _, err := s.repo.DeliverExpired(ctx, m.ID); err != nil {
+				log.Printf("[MAIL] failed to deliver attachments of expired mail %d: %v", m.ID, err)
+			}
+			continue
+		}
+
+		recipientName := "?"
+		if recipient, err := s.playerRepo.GetByID(ctx, m.RecipientID); err == nil {
+			recipientName = recipient.Username
+		}
+		ret := s.SystemMail(*m.SenderID, recipientName, truncateRunes("Retour : "+m.Subject, maxMailSubject),
+			fmt.Sprintf("%s n'a pas récupéré les pièces jointes de ton courrier à temps, les voici.", recipientName))
+		ok, err := s.repo.ReturnExpired(ctx, m.ID, ret)
+		if err != nil {
+			log.Printf("[MAIL] failed to return attachments of expired mail %d: %v", m.ID, err)
+			continue
+		}
+		if ok {
+			s.Announce(ret)
+		}
+	}
+	return s.repo.DeleteExpired(ctx)
+}
+
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Mailer sends transactional email (verification links, password resets).
+type Mailer interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// SMTPMailer delivers mail through an SMTP relay.
+type SMTPMailer struct {
+	addr         string
+	auth         smtp.Auth
+	from         string // header form, e.g. "Imperion Online <no-reply@...>"
+	envelopeFrom string // bare address for MAIL FROM
+}
+
+func NewSMTPMailer(host string, port int, username, password, from string) *SMTPMailer {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	envelopeFrom := from
+	if addr, err := mail.ParseAddress(from); err == nil {
+		envelopeFrom = addr.Address
+	}
+	return &SMTPMailer{
+		addr:         host + ":" + strconv.Itoa(port),
+		auth:         auth,
+		from:         from,
+		envelopeFrom: envelopeFrom,
+	}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, to, subject, body string) error {
+	msg := buildMessage(m.from, to, subject, body)
+
+	// net/smtp has no context support — run it in the background and honour cancellation
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(m.addr, m.auth, m.envelopeFrom, []string{to}, msg)
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("smtp send: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// OutboxMailer is the local development mailer: messages are written as .eml
+// files to a directory (or only logged when no directory is configured).
+type OutboxMailer struct {
+	dir string
+}
+
+func NewOutboxMailer(dir string) *OutboxMailer {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			log.Printf("[mailer] cannot create outbox dir %s: %v — logging only", dir, err)
+			dir = ""
+		}
+	}
+	return &OutboxMailer{dir: dir}
+}
+
+func (m *OutboxMailer) Send(ctx context.Context, to, subject, body string) error {
+	if m.dir == "" {
+		log.Printf("[mailer] (outbox) to=%s subject=%q\n%s", to, subject, body)
+		return nil
+	}
+	name := fmt.Sprintf("%s_%s.eml", time.Now().Format("20060102-150405.000000"), sanitizeFileName(to))
+	path := filepath.Join(m.dir, name)
+	if err := os.WriteFile(path, buildMessage("outbox@localhost", to, subject, body), 0o644); err != nil {
+		return fmt.Errorf("write outbox: %w", err)
+	}
+	log.Printf("[mailer] (outbox) to=%s subject=%q → %s", to, subject, path)
+	return nil
+}
+
+func buildMessage(from, to, subject, body string) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + to + "\r\n")
+	b.WriteString("Subject: " + subject + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+func sanitizeFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' {
+			return r
+		}
+		return '_'
+	}, s)
+}
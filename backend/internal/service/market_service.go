@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"spacegame-backend/internal/model"
@@ -30,13 +31,20 @@ var validDurations = map[int]bool{24: true, 48: true, 72: true}
 
 const listingFeeRate = 0.05 // 5%
 
+// marketMailSender signs the mail the market sends to sellers.
+const marketMailSender = "Hôtel des Ventes"
+
+// MarketService runs the HDV. Sellers are paid directly and told about sales and expired
+// listings by mail.
 type MarketService struct {
-	marketRepo *repository.MarketRepository
-	playerRepo *repository.PlayerRepository
+	marketRepo     *repository.MarketRepository
+	playerRepo     *repository.PlayerRepository
+	achievementSvc *AchievementService
+	mailSvc        *MailService
 }
 
-func NewMarketService(marketRepo *repository.MarketRepository, playerRepo *repository.PlayerRepository) *MarketService {
-	return &MarketService{marketRepo: marketRepo, playerRepo: playerRepo}
+func NewMarketService(marketRepo *repository.MarketRepository, playerRepo *repository.PlayerRepository, achievementSvc *AchievementService, mailSvc *MailService) *MarketService {
+	return &MarketService{marketRepo: marketRepo, playerRepo: playerRepo, achievementSvc: achievementSvc, mailSvc: mailSvc}
 }
 
 func (s *MarketService) CreateListing(ctx context.Context, playerID string, playerName string, req *model.CreateListingRequest) (*model.MarketListing, error) {
@@ -70,12 +78,7 @@ func (s *MarketService) CreateListing(ctx context.Context, playerID string, play
 		return nil, ErrInsufficientCredits
 	}
 
-	// Debit fee from player
-	if err := s.playerRepo.AddCredits(ctx, playerID, -fee); err != nil {
-		return nil, err
-	}
-
-	// Create listing
+	// Create listing (the fee is debited in the same transaction)
 	listing := &model.MarketListing{
 		SellerID:     playerID,
 		SellerName:   playerName,
@@ -91,7 +94,11 @@ func (s *MarketService) CreateListing(ctx context.Context, playerID string, play
 		ExpiresAt:    time.Now().Add(time.Duration(req.DurationHours) * time.Hour),
 	}
 
-	return s.marketRepo.Create(ctx, listing)
+	created, err := s.marketRepo.Create(ctx, listing)
+	if errors.Is(err, repository.ErrInsufficientCredits) {
+		return nil, ErrInsufficientCredits
+	}
+	return created, err
 }
 
 func (s *MarketService) BuyListing(ctx context.Context, listingID int64, buyerID string, buyerName string) (*model.MarketListing, error) {
@@ -120,8 +127,25 @@ func (s *MarketService) BuyListing(ctx context.Context, listingID int64, buyerID
 		return nil, ErrInsufficientCredits
 	}
 
-	// Atomic buy (debit buyer, credit seller, mark sold)
-	return s.marketRepo.Buy(ctx, listingID, buyerID, buyerName)
+	// Atomic buy (debit buyer, credit seller, mail the seller, mark sold)
+	var notice *model.Mail
+	bought, err := s.marketRepo.Buy(ctx, listingID, buyerID, buyerName, func(l *model.MarketListing) *model.Mail {
+		notice = s.mailSvc.SystemMail(l.SellerID, marketMailSender,
+			fmt.Sprintf("Vente : %d x %s", l.Quantity, l.ItemName),
+			fmt.Sprintf("%s a acheté ton annonce (%d x %s) à %s. %d crédits ont été versés sur ton compte.",
+				buyerName, l.Quantity, l.ItemName, l.StationName, l.UnitPrice*int64(l.Quantity)))
+		return notice
+	})
+	if errors.Is(err, repository.ErrInsufficientCredits) {
+		return nil, ErrInsufficientCredits
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.mailSvc.Announce(notice)
+	s.achievementSvc.Evaluate(ctx, bought.SellerID, model.AchievementStatMarketSales)
+	return bought, nil
 }
 
 func (s *MarketService) CancelListing(ctx context.Context, listingID int64, playerID string) error {
@@ -158,6 +182,17 @@ func (s *MarketService) GetAvgPrices(ctx context.Context) (map[string]int64, err
 	return s.marketRepo.GetAvgPrices(ctx)
 }
 
+// ExpireListings closes the listings past their expiry and tells their sellers by mail.
+// Listings never held the items, so there is nothing to send back.
 func (s *MarketService) ExpireListings(ctx context.Context) (int64, error) {
-	return s.marketRepo.ExpireOld(ctx)
+	mails, err := s.marketRepo.ExpireOld(ctx, func(l *model.MarketListing) *model.Mail {
+		return s.mailSvc.SystemMail(l.SellerID, marketMailSender,
+			fmt.Sprintf("Annonce expirée : %d x %s", l.Quantity, l.ItemName),
+			fmt.Sprintf("Ton annonce (%d x %s) à %s a expiré sans trouver d'acheteur.", l.Quantity, l.ItemName, l.StationName))
+	})
+	if err != nil {
+		return 0, err
+	}
+	s.mailSvc.Announce(mails...)
+	return int64(len(mails)), nil
 }
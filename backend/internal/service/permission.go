@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"spacegame-backend/internal/model"
+	"spacegame-backend/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Permissions checked by RequirePermission (granted per role in role_permissions)
+const (
+	PermChatRead       = "chat.read"
+	PermPlayersView    = "players.view"
+	PermPlayersUnlock  = "players.unlock"
+	PermPlayersRestore = "players.restore"
+	PermBansView       = "bans.view"
+	PermBansIssue      = "bans.issue"
+	PermBansLift       = "bans.lift"
+	PermAnnounce       = "announce"
+	PermRolesManage    = "roles.manage"
+	PermStatsRead      = "stats.read"
+	PermChangelog      = "changelog.write"
+	PermGameServers    = "game_servers.manage"
+	PermAuditRead      = "audit.read"
+	PermAdminTokens    = "admin_tokens.manage"
+	PermEconomyAudit   = "economy.audit"
+
+	permAll = "*"
+
+	// RoleDefault is every account's role until changed (it ranks lowest)
+	RoleDefault = "player"
+
+	// playerRoleTTL bounds how long another instance keeps acting on a changed role
+	playerRoleTTL = 30 * time.Second
+)
+
+// KnownPermissions lists every permission, used to validate API token scopes.
+var KnownPermissions = []string{
+	PermChatRead, PermPlayersView, PermPlayersUnlock, PermBansView, PermBansIssue, PermBansLift,
+	PermAnnounce, PermRolesManage, PermStatsRead, PermChangelog, PermGameServers, PermAuditRead,
+	PermAdminTokens, PermPlayersRestore, PermEconomyAudit,
+}
+
+var ErrUnknownRole = errors.New("unknown role")
+
+// PermissionService answers role/permission checks from an in-memory copy of the
+// roles tables. Players' current roles are cached briefly, so staff checks don't trust
+// the role claim of a token issued before a demotion.
+type PermissionService struct {
+	roleRepo    *repository.RoleRepository
+	sessionRepo *repository.SessionRepository
+	eventSvc    *EventService
+
+	mu    sync.RWMutex
+	roles map[string]model.Role
+	perms map[string]map[string]bool
+
+	playerMu    sync.Mutex
+	playerRoles map[string]cachedRole
+}
+
+type cachedRole struct {
+	role      string
+	expiresAt time.Time
+}
+
+func NewPermissionService(roleRepo *repository.RoleRepository, sessionRepo *repository.SessionRepository, eventSvc *EventService) *PermissionService {
+	return &PermissionService{
+		roleRepo:    roleRepo,
+		sessionRepo: sessionRepo,
+		eventSvc:    eventSvc,
+		roles:       make(map[string]model.Role),
+		perms:       make(map[string]map[string]bool),
+		playerRoles: make(map[string]cachedRole),
+	}
+}
+
+// Refresh reloads roles and permissions from the database.
+func (s *PermissionService) Refresh(ctx context.Context) error {
+	list, err := s.roleRepo.ListRoles(ctx)
+	if err != nil {
+		return err
+	}
+
+	roles := make(map[string]model.Role, len(list))
+	perms := make(map[string]map[string]bool, len(list))
+	for _, role := range list {
+		roles[role.Name] = role
+		set := make(map[string]bool, len(role.Permissions))
+		for _, p := range role.Permissions {
+			set[p] = true
+		}
+		perms[role.Name] = set
+	}
+
+	s.mu.Lock()
+	s.roles = roles
+	s.perms = perms
+	s.mu.Unlock()
+	return nil
+}
+
+// HasPermission reports whether role grants permission.
+func (s *PermissionService) HasPermission(role, permission string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	set := s.perms[role]
+	return set[permAll] || set[permission]
+}
+
+// RoleAtLeast reports whether role ranks at or above minRole. Unknown roles rank nowhere.
+func (s *PermissionService) RoleAtLeast(role, minRole string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	r, ok := s.roles[role]
+	floor, floorOK := s.roles[minRole]
+	return ok && floorOK && r.Rank >= floor.Rank
+}
+
+// PlayerRole returns a player's current role ("" if the player doesn't exist).
+func (s *PermissionService) PlayerRole(ctx context.Context, playerID string) (string, error) {
+	now := time.Now()
+	s.playerMu.Lock()
+	cached, ok := s.playerRoles[playerID]
+	s.playerMu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.role, nil
+	}
+
+	role, err := s.roleRepo.GetPlayerRole(ctx, playerID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		role, err = "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	s.playerMu.Lock()
+	if len(s.playerRoles) > 10000 {
+		s.playerRoles = make(map[string]cachedRole)
+	}
+	s.playerRoles[playerID] = cachedRole{role: role, expiresAt: now.Add(playerRoleTTL)}
+	s.playerMu.Unlock()
+	return role, nil
+}
+
+// Outranks reports whether actorRole ranks strictly above the target player's current
+// role. Staff can't act on peers or superiors. A missing target is left to the handler.
+func (s *PermissionService) Outranks(ctx context.Context, actorRole, targetID string) (bool, error) {
+	targetRole, err := s.PlayerRole(ctx, targetID)
+	if err != nil || targetRole == "" {
+		return err == nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	actor, ok := s.roles[actorRole]
+	target := s.roles[targetRole]
+	return ok && actor.Rank > target.Rank, nil
+}
+
+// Permissions returns the permissions granted to a role.
+func (s *PermissionService) Permissions(role string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if r, ok := s.roles[role]; ok {
+		return r.Permissions
+	}
+	return []string{}
+}
+
+// ListRoles returns every role, lowest rank first.
+func (s *PermissionService) ListRoles(ctx context.Context) ([]model.Role, error) {
+	return s.roleRepo.ListRoles(ctx)
+}
+
+// SetPlayerRole changes a player's role and signs them out, so their next tokens
+// carry the new role claim.
+func (s *PermissionService) SetPlayerRole(ctx context.Context, actor, playerID, role string) error {
+	s.mu.RLock()
+	_, known := s.roles[role]
+	s.mu.RUnlock()
+	if !known {
+		return ErrUnknownRole
+	}
+
+	ok, err := s.roleRepo.SetPlayerRole(ctx, playerID, role)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrPlayerNotFound
+	}
+	s.playerMu.Lock()
+	delete(s.playerRoles, playerID)
+	s.playerMu.Unlock()
+
+	if err := s.sessionRepo.RevokeAllForPlayer(ctx, playerID); err != nil {
+		log.Printf("[ROLES] failed to revoke sessions of %s: %v", playerID, err)
+	}
+	if s.eventSvc != nil {
+		s.eventSvc.RecordSecurityEvent(ctx, "role_changed", actor, map[string]string{
+			"player_id": playerID,
+			"role":      role,
+		})
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"spacegame-backend/internal/model"
+	"spacegame-backend/internal/repository"
+)
+
+const (
+	// PlaySessionIdle is how long a session stays open without heartbeats (the game
+	// server sends one a minute).
+	PlaySessionIdle = 3 * time.Minute
+
+	maxConcurrencyPoints = 1000
+	maxRetentionDays     = 120
+)
+
+var ErrInvalidAnalyticsRange = errors.New("invalid time range or interval")
+
+// PlaySessionService tracks play sessions from game server heartbeats and serves the
+// playtime, concurrency and retention figures built from them.
+type PlaySessionService struct {
+	repo *repository.PlaySessionRepository
+}
+
+func NewPlaySessionService(repo *repository.PlaySessionRepository) *PlaySessionService {
+	return &PlaySessionService{repo: repo}
+}
+
+// Heartbeat records the players a game server reports as connected.
+func (s *PlaySessionService) Heartbeat(ctx context.Context, serverID *string, playerIDs []string, systems map[string]int) error {
+	return s.repo.Heartbeat(ctx, serverID, playerIDs, systems)
+}
+
+// CloseIdle ends the sessions whose server stopped reporting them.
+func (s *PlaySessionService) CloseIdle(ctx context.Context) (int64, error) {
+	return s.repo.CloseIdle(ctx, PlaySessionIdle)
+}
+
+// ListForPlayer returns a player's most recent sessions (limit defaults to 50, at most 200).
+func (s *PlaySessionService) ListForPlayer(ctx context.Context, playerID string, limit int) ([]model.PlaySession, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	return s.repo.ListForPlayer(ctx, playerID, limit)
+}
+
+// Concurrency returns how many players were online every step between from and to.
+func (s *PlaySessionService) Concurrency(ctx context.Context, from, to time.Time, step time.Duration) ([]model.ConcurrencyPoint, error) {
+	if step < time.Minute || !to.After(from) || to.Sub(from)/step > maxConcurrencyPoints {
+		return nil, ErrInvalidAnalyticsRange
+	}
+	return s.repo.Concurrency(ctx, from, to, step)
+}
+
+// Retention returns day 1/7/30 retention of the players registered in the last days days.
+func (s *PlaySessionService) Retention(ctx context.Context, days int) ([]model.RetentionCohort, error) {
+	if days <= 0 || days > maxRetentionDays {
+		return nil, ErrInvalidAnalyticsRange
+	}
+	return s.repo.Retention(ctx, days)
+}
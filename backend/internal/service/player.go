@@ -2,27 +2,601 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"spacegame-backend/internal/model"
 	"spacegame-backend/internal/repository"
+	"spacegame-backend/internal/stateschema"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// snapshotKeep is how many regular snapshots are kept per player; daily ones are
+	// kept for snapshotDailyDays on top of that.
+	snapshotKeep      = 20
+	snapshotDailyDays = 30
+	// patchSnapshotEvery throttles snapshots of patch saves (autosaves); full saves are
+	// always snapshotted.
+	patchSnapshotEvery = 10 * time.Minute
+
+	// MaxBatchSave is how many players a game server may save in one request (the body
+	// limit still applies); batchSaveWorkers of them are saved concurrently.
+	MaxBatchSave     = 100
+	batchSaveWorkers = 4
+
+	// Economy relayed by a game server (SaveEconomy): credits and items may grow by so
+	// much per minute since the last economy save, counting at most economyGainWindow.
+	maxCreditGainPerMinute = 1000000
+	maxItemGainPerMinute   = 500
+	economyGainWindow      = 10 * time.Minute
 )
 
+var (
+	ErrSaveVersionRequired = errors.New("save_version is required")
+	ErrStaleSave           = errors.New("state was modified since it was loaded")
+	ErrSnapshotNotFound    = errors.New("snapshot not found")
+	ErrStateUpgrade        = errors.New("state sections could not be upgraded from their schema_versions")
+	ErrEconomyRejected     = errors.New("economy change rejected")
+)
+
+// PlayerService loads and saves player state. Credits, kills, deaths, resources,
+// inventory and cargo are server-authoritative: the game client may not change them in its saves
+// (unless clientEconomyLegacy is set; such changes are kept but still recorded).
 type PlayerService struct {
-	playerRepo *repository.PlayerRepository
+	playerRepo          *repository.PlayerRepository
+	snapshotRepo        *repository.PlayerSnapshotRepository
+	eventSvc            *EventService
+	achievementSvc      *AchievementService
+	clientEconomyLegacy bool
+
+	snapshotMu   sync.Mutex
+	lastSnapshot map[string]time.Time // player ID -> time of their last snapshot
 }
 
-func NewPlayerService(playerRepo *repository.PlayerRepository) *PlayerService {
-	return &PlayerService{playerRepo: playerRepo}
+func NewPlayerService(playerRepo *repository.PlayerRepository, snapshotRepo *repository.PlayerSnapshotRepository, eventSvc *EventService, achievementSvc *AchievementService, clientEconomyLegacy bool) *PlayerService {
+	return &PlayerService{
+		playerRepo:          playerRepo,
+		snapshotRepo:        snapshotRepo,
+		eventSvc:            eventSvc,
+		achievementSvc:      achievementSvc,
+		clientEconomyLegacy: clientEconomyLegacy,
+		lastSnapshot:        make(map[string]time.Time),
+	}
 }
 
 func (s *PlayerService) GetState(ctx context.Context, playerID string) (*model.PlayerState, error) {
 	return s.playerRepo.GetFullState(ctx, playerID)
 }
 
-func (s *PlayerService) SaveState(ctx context.Context, playerID string, state *model.PlayerState) error {
-	return s.playerRepo.SaveFullState(ctx, playerID, state)
+// SaveState writes the state if its save_version matches the stored one and returns the
+// new version. On ErrStaleSave the returned version is the current one, so the caller can
+// reload. force skips the check; it is reserved for the authoritative game server.
+// Every successful save is snapshotted.
+func (s *PlayerService) SaveState(ctx context.Context, playerID string, state *model.PlayerState, serverID *string, force bool) (int64, error) {
+	return s.save(ctx, playerID, state, repository.SaveOptions{ServerID: serverID, Force: force, CreditReason: model.CreditReasonServerSave})
+}
+
+// SaveStates saves many players at once (e.g. when a game server shuts down). Each player
+// is saved in its own transaction as by SaveState, so one failure doesn't affect the
+// others; results are in the order of entries.
+func (s *PlayerService) SaveStates(ctx context.Context, entries []model.BatchSaveEntry, serverID *string) []model.BatchSaveResult {
+	results := make([]model.BatchSaveResult, len(entries))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(batchSaveWorkers, len(entries)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i] = s.saveEntry(ctx, &entries[i], serverID)
+			}
+		}()
+	}
+	for i := range entries {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return results
+}
+
+func (s *PlayerService) saveEntry(ctx context.Context, entry *model.BatchSaveEntry, serverID *string) model.BatchSaveResult {
+	res := model.BatchSaveResult{PlayerID: entry.PlayerID}
+	if entry.PlayerID == "" {
+		res.Error = "player_id is required"
+		return res
+	}
+	version, err := s.SaveState(ctx, entry.PlayerID, &entry.State, serverID, entry.Force)
+	switch {
+	case err == nil:
+		res.OK = true
+	case errors.Is(err, ErrSaveVersionRequired), errors.Is(err, ErrStaleSave), errors.Is(err, ErrStateUpgrade):
+		res.Error = err.Error()
+	default:
+		log.Printf("[PLAYER] batch save of %s failed: %v", entry.PlayerID, err)
+		res.Error = "failed to save state"
+	}
+	res.SaveVersion = version
+	return res
+}
+
+// SaveClientState saves a state sent by the game client. Changes to protected fields are
+// logged and dropped (the stored values are kept); the dropped fields are returned. In
+// legacy mode they are kept, and still logged. The comparison runs inside the save
+// transaction, so it can't race with another write.
+func (s *PlayerService) SaveClientState(ctx context.Context, playerID, username string, state *model.PlayerState) (int64, []string, error) {
+	if state.SaveVersion == nil {
+		return 0, nil, ErrSaveVersionRequired
+	}
+
+	var changed []string
+	var storedCredits int64
+	sentCredits := state.Credits
+	opts := repository.SaveOptions{CreditReason: model.CreditReasonClientSave}
+	opts.CheckProtected = func(stored *model.PlayerState) bool {
+		changed, storedCredits = protectedChanges(stored, state), stored.Credits
+		if len(changed) == 0 || s.clientEconomyLegacy {
+			return false
+		}
+		return true
+	}
+	version, err := s.save(ctx, playerID, state, opts)
+	if err != nil || len(changed) == 0 {
+		return version, nil, err
+	}
+	s.logEconomyWrite(ctx, username, changed, storedCredits, sentCredits)
+	if s.clientEconomyLegacy {
+		return version, nil, nil
+	}
+	return version, changed, nil
+}
+
+// PatchState applies the sections present in a patch sent by the game server (see SaveState).
+func (s *PlayerService) PatchState(ctx context.Context, playerID string, patch *model.PlayerStatePatch, serverID *string, force bool) (int64, error) {
+	return s.patch(ctx, playerID, patch, repository.SaveOptions{ServerID: serverID, Force: force, CreditReason: model.CreditReasonServerSave})
+}
+
+// SaveEconomy saves the credits, resources and inventory of a game client, relayed by its
+// game server. They are checked against the stored values inside the save transaction:
+// negative values are refused, and gains are bounded by the time since the player's last
+// economy save (see maxCreditGainPerMinute). Other sections of the patch are ignored.
+func (s *PlayerService) SaveEconomy(ctx context.Context, playerID string, patch *model.PlayerStatePatch, serverID *string) (int64, error) {
+	economy := &model.PlayerStatePatch{
+		SaveVersion: patch.SaveVersion,
+		Credits:     patch.Credits,
+		Resources:   patch.Resources,
+		Inventory:   patch.Inventory,
+	}
+	opts := repository.SaveOptions{ServerID: serverID, CreditReason: model.CreditReasonServerEconomy}
+	opts.CheckEconomy = func(stored *model.PlayerState, lastSaved *time.Time) error {
+		return checkEconomyGain(stored, economy, lastSaved)
+	}
+	version, err := s.patch(ctx, playerID, economy, opts)
+	if errors.Is(err, ErrEconomyRejected) {
+		log.Printf("[ECONOMY] rejected economy of %s: %v", playerID, err)
+	}
+	return version, err
+}
+
+// checkEconomyGain returns ErrEconomyRejected when sent holds negative values or more
+// credits or items than the game could have paid out since lastSaved.
+func checkEconomyGain(stored *model.PlayerState, sent *model.PlayerStatePatch, lastSaved *time.Time) error {
+	elapsed := economyGainWindow
+	if lastSaved != nil && time.Since(*lastSaved) < elapsed {
+		elapsed = time.Since(*lastSaved)
+	}
+	if sent.Credits != nil {
+		if *sent.Credits < 0 {
+			return fmt.Errorf("%w: negative credits", ErrEconomyRejected)
+		}
+		if gain := *sent.Credits - stored.Credits; float64(gain) > maxCreditGainPerMinute*elapsed.Minutes() {
+			return fmt.Errorf("%w: credits grew from %d to %d", ErrEconomyRejected, stored.Credits, *sent.Credits)
+		}
+	}
+
+	had, has := map[string]int{}, map[string]int{}
+	for _, r := range stored.Resources {
+		had["resource:"+r.ResourceID] += r.Quantity
+	}
+	for _, item := range stored.Inventory {
+		had["item:"+item.Category+"/"+item.ItemName] += item.Quantity
+	}
+	for _, r := range sent.Resources {
+		if r.Quantity < 0 {
+			return fmt.Errorf("%w: negative quantity of %s", ErrEconomyRejected, r.ResourceID)
+		}
+		has["resource:"+r.ResourceID] += r.Quantity
+	}
+	for _, item := range sent.Inventory {
+		if item.Quantity < 0 {
+			return fmt.Errorf("%w: negative quantity of %s", ErrEconomyRejected, item.ItemName)
+		}
+		has["item:"+item.Category+"/"+item.ItemName] += item.Quantity
+	}
+	gained := 0
+	for key, quantity := range has {
+		if quantity > had[key] {
+			gained += quantity - had[key]
+		}
+	}
+	if float64(gained) > maxItemGainPerMinute*elapsed.Minutes() {
+		return fmt.Errorf("%w: %d items gained", ErrEconomyRejected, gained)
+	}
+	return nil
+}
+
+// PatchClientState applies a patch sent by the game client, with the same protection of
+// economy fields as SaveClientState.
+func (s *PlayerService) PatchClientState(ctx context.Context, playerID, username string, patch *model.PlayerStatePatch) (int64, []string, error) {
+	if patch.SaveVersion == nil {
+		return 0, nil, ErrSaveVersionRequired
+	}
+
+	touchesProtected := patch.Credits != nil || patch.Kills != nil || patch.Deaths != nil ||
+		patch.Resources != nil || patch.Inventory != nil || patch.Cargo != nil
+	if !touchesProtected {
+		version, err := s.patch(ctx, playerID, patch, repository.SaveOptions{CreditReason: model.CreditReasonClientSave})
+		return version, nil, err
+	}
+
+	var changed []string
+	var storedCredits, sentCredits int64
+	opts := repository.SaveOptions{CreditReason: model.CreditReasonClientSave}
+	opts.CheckProtected = func(stored *model.PlayerState) bool {
+		sent := *stored
+		if patch.Credits != nil {
+			sent.Credits = *patch.Credits
+		}
+		if patch.Kills != nil {
+			sent.Kills = *patch.Kills
+		}
+		if patch.Deaths != nil {
+			sent.Deaths = *patch.Deaths
+		}
+		if patch.Resources != nil {
+			sent.Resources = patch.Resources
+		}
+		if patch.Inventory != nil {
+			sent.Inventory = patch.Inventory
+		}
+		if patch.Cargo != nil {
+			sent.Cargo = patch.Cargo
+		}
+		changed, storedCredits, sentCredits = protectedChanges(stored, &sent), stored.Credits, sent.Credits
+		return len(changed) > 0 && !s.clientEconomyLegacy
+	}
+	version, err := s.patch(ctx, playerID, patch, opts)
+	if err != nil || len(changed) == 0 {
+		return version, nil, err
+	}
+	s.logEconomyWrite(ctx, username, changed, storedCredits, sentCredits)
+	if s.clientEconomyLegacy {
+		return version, nil, nil
+	}
+	return version, changed, nil
+}
+
+func (s *PlayerService) patch(ctx context.Context, playerID string, patch *model.PlayerStatePatch, opts repository.SaveOptions) (int64, error) {
+	patch.Normalize()
+	if !opts.Force && patch.SaveVersion == nil {
+		return 0, ErrSaveVersionRequired
+	}
+	if err := stateschema.UpgradePatch(patch, patch.SchemaVersions); err != nil {
+		log.Printf("[PLAYER] failed to upgrade patch of %s: %v", playerID, err)
+		return 0, ErrStateUpgrade
+	}
+	// Position-only autosaves are not worth a snapshot (and must stay cheap); others get
+	// one at most every patchSnapshotEvery.
+	if !patch.PositionOnly() && s.patchSnapshotDue(playerID) {
+		setSnapshot(&opts, "save")
+	}
+	version, err := s.playerRepo.PatchState(ctx, playerID, patch, opts)
+	if errors.Is(err, repository.ErrStaleSave) {
+		return version, ErrStaleSave
+	}
+	if err != nil {
+		return 0, err
+	}
+	if opts.SnapshotReason != "" {
+		s.snapshotTaken(playerID)
+	}
+	return version, nil
+}
+
+// logEconomyWrite records a client save that changed protected fields (dropped, or kept in legacy mode).
+func (s *PlayerService) logEconomyWrite(ctx context.Context, username string, changed []string, storedCredits, sentCredits int64) {
+	outcome := "dropped"
+	if s.clientEconomyLegacy {
+		outcome = "accepted (legacy)"
+	}
+	log.Printf("[ECONOMY] %s client changes to %v for %s (credits %d -> %d)", outcome, changed, username, storedCredits, sentCredits)
+	s.eventSvc.RecordSecurityEvent(ctx, "client_economy_write", username, map[string]string{
+		"outcome":        outcome,
+		"fields":         strings.Join(changed, ","),
+		"credits_stored": strconv.FormatInt(storedCredits, 10),
+		"credits_sent":   strconv.FormatInt(sentCredits, 10),
+	})
+}
+
+func (s *PlayerService) save(ctx context.Context, playerID string, state *model.PlayerState, opts repository.SaveOptions) (int64, error) {
+	if !opts.Force && state.SaveVersion == nil {
+		return 0, ErrSaveVersionRequired
+	}
+	// A game client older than the backend sends the versions it knows (or none: version 0)
+	if err := stateschema.UpgradeState(state, state.SchemaVersions); err != nil {
+		log.Printf("[PLAYER] failed to upgrade state of %s: %v", playerID, err)
+		return 0, ErrStateUpgrade
+	}
+	setSnapshot(&opts, "save")
+	version, err := s.playerRepo.SaveFullState(ctx, playerID, state, opts)
+	if errors.Is(err, repository.ErrStaleSave) {
+		return version, ErrStaleSave
+	}
+	if err != nil {
+		return 0, err
+	}
+	s.snapshotTaken(playerID)
+	return version, nil
 }
 
 func (s *PlayerService) GetProfile(ctx context.Context, playerID string) (*model.PlayerProfile, error) {
-	return s.playerRepo.GetProfile(ctx, playerID)
+	profile, err := s.playerRepo.GetProfile(ctx, playerID)
+	if err != nil {
+		return nil, err
+	}
+	if profile.Achievements, err = s.achievementSvc.Unlocked(ctx, playerID); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// ListSnapshots returns a player's snapshots, newest first (without their state).
+func (s *PlayerService) ListSnapshots(ctx context.Context, playerID string) ([]model.PlayerSnapshot, error) {
+	return s.snapshotRepo.ListForPlayer(ctx, playerID)
+}
+
+// GetSnapshot returns a snapshot with its state.
+func (s *PlayerService) GetSnapshot(ctx context.Context, playerID string, id int64) (*model.PlayerSnapshot, error) {
+	snap, err := s.snapshotRepo.Get(ctx, playerID, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrSnapshotNotFound
+	}
+	return snap, err
+}
+
+// DiffSnapshot lists the top-level fields that differ between a snapshot and another
+// snapshot, or the player's current state when againstID is 0.
+func (s *PlayerService) DiffSnapshot(ctx context.Context, playerID string, id, againstID int64) (*model.SnapshotDiff, error) {
+	from, err := s.GetSnapshot(ctx, playerID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &model.SnapshotDiff{From: id, To: "current"}
+	var to json.RawMessage
+	if againstID > 0 {
+		other, err := s.GetSnapshot(ctx, playerID, againstID)
+		if err != nil {
+			return nil, err
+		}
+		diff.To = strconv.FormatInt(againstID, 10)
+		to = other.State
+	} else {
+		current, err := s.playerRepo.GetFullState(ctx, playerID)
+		if err != nil {
+			return nil, err
+		}
+		if to, err = json.Marshal(current); err != nil {
+			return nil, err
+		}
+	}
+
+	diff.Changes, err = diffStates(from.State, to)
+	if err != nil {
+		return nil, err
+	}
+	return diff, nil
+}
+
+// RestoreSnapshot overwrites the player's state with a snapshot. The save_version is
+// bumped, so a client still holding the old state gets a conflict and reloads.
+func (s *PlayerService) RestoreSnapshot(ctx context.Context, playerID string, id int64, actor string) (int64, error) {
+	snap, err := s.GetSnapshot(ctx, playerID, id)
+	if err != nil {
+		return 0, err
+	}
+
+	var state model.PlayerState
+	if err := json.Unmarshal(snap.State, &state); err != nil {
+		return 0, fmt.Errorf("decode snapshot %d: %w", id, err)
+	}
+	// Snapshots taken before schema versions existed are at version 0
+	if err := stateschema.UpgradeState(&state, state.SchemaVersions); err != nil {
+		return 0, fmt.Errorf("upgrade snapshot %d: %w", id, err)
+	}
+	opts := repository.SaveOptions{
+		Force:        true,
+		CreditReason: model.CreditReasonAdminRestore,
+		CreditRef:    "snapshot:" + strconv.FormatInt(id, 10),
+	}
+	setSnapshot(&opts, "restore")
+	opts.SnapshotBy = actor
+	version, err := s.playerRepo.SaveFullState(ctx, playerID, &state, opts)
+	if err != nil {
+		return 0, err
+	}
+	s.snapshotTaken(playerID)
+
+	s.eventSvc.RecordSecurityEvent(ctx, "player_state_restored", actor, map[string]string{
+		"player_id":   playerID,
+		"snapshot_id": strconv.FormatInt(id, 10),
+	})
+	log.Printf("[PLAYER] %s restored snapshot %d of %s (now version %d)", actor, id, playerID, version)
+	return version, nil
+}
+
+// PurgeDailySnapshots deletes daily snapshots past their retention.
+func (s *PlayerService) PurgeDailySnapshots(ctx context.Context) (int64, error) {
+	s.snapshotMu.Lock()
+	for id, at := range s.lastSnapshot {
+		if time.Since(at) >= patchSnapshotEvery {
+			delete(s.lastSnapshot, id)
+		}
+	}
+	s.snapshotMu.Unlock()
+	return s.snapshotRepo.DeleteDailyOlderThan(ctx, snapshotDailyDays)
+}
+
+// patchSnapshotDue reports whether a patch save should be snapshotted.
+func (s *PlayerService) patchSnapshotDue(playerID string) bool {
+	s.snapshotMu.Lock()
+	defer s.snapshotMu.Unlock()
+	return time.Since(s.lastSnapshot[playerID]) >= patchSnapshotEvery
+}
+
+// snapshotTaken records that a save of the player was just snapshotted.
+func (s *PlayerService) snapshotTaken(playerID string) {
+	s.snapshotMu.Lock()
+	s.lastSnapshot[playerID] = time.Now()
+	s.snapshotMu.Unlock()
+}
+
+// setSnapshot makes the save snapshot the state it stores, inside its transaction, on
+// behalf of the game server that sent it (if any).
+func setSnapshot(opts *repository.SaveOptions, reason string) {
+	opts.SnapshotReason = reason
+	opts.SnapshotKeep = snapshotKeep
+	if opts.ServerID != nil {
+		opts.SnapshotBy = *opts.ServerID
+	}
+}
+
+// protectedChanges lists the server-authoritative fields that differ between the stored
+// state and one sent by the client.
+func protectedChanges(stored, sent *model.PlayerState) []string {
+	var changed []string
+	if sent.Credits != stored.Credits {
+		changed = append(changed, "credits")
+	}
+	if sent.Kills != stored.Kills {
+		changed = append(changed, "kills")
+	}
+	if sent.Deaths != stored.Deaths {
+		changed = append(changed, "deaths")
+	}
+
+	resources := map[string]int{}
+	for _, r := range stored.Resources {
+		resources[r.ResourceID] += r.Quantity
+	}
+	for _, r := range sent.Resources {
+		resources[r.ResourceID] -= r.Quantity
+	}
+	if !allZero(resources) {
+		changed = append(changed, "resources")
+	}
+
+	inventory := map[string]int{}
+	for _, item := range stored.Inventory {
+		inventory[item.Category+"/"+item.ItemName] += item.Quantity
+	}
+	for _, item := range sent.Inventory {
+		inventory[item.Category+"/"+item.ItemName] -= item.Quantity
+	}
+	if !allZero(inventory) {
+		changed = append(changed, "inventory")
+	}
+
+	// A state without cargo leaves it as it is
+	if sent.Cargo != nil {
+		cargo := map[string]int{}
+		for _, item := range stored.Cargo {
+			cargo[item.ItemName] += item.Quantity
+		}
+		for _, item := range sent.Cargo {
+			cargo[item.ItemName] -= item.Quantity
+		}
+		if !allZero(cargo) {
+			changed = append(changed, "cargo")
+		}
+	}
+	return changed
+}
+
+func allZero(m map[string]int) bool {
+	for _, v := range m {
+		if v != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// diffStates compares two serialised PlayerStates field by field. save_version and
+// has_saved are bookkeeping and ignored.
+func diffStates(before, after json.RawMessage) ([]model.SnapshotChange, error) {
+	var a, b map[string]json.RawMessage
+	if err := json.Unmarshal(before, &a); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(after, &b); err != nil {
+		return nil, err
+	}
+
+	fields := map[string]bool{}
+	for k := range a {
+		fields[k] = true
+	}
+	for k := range b {
+		fields[k] = true
+	}
+	delete(fields, "save_version")
+	delete(fields, "has_saved")
+
+	names := make([]string, 0, len(fields))
+	for k := range fields {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	changes := []model.SnapshotChange{}
+	for _, name := range names {
+		if !sameJSON(a[name], b[name]) {
+			changes = append(changes, model.SnapshotChange{Field: name, Before: nullIfEmpty(a[name]), After: nullIfEmpty(b[name])})
+		}
+	}
+	return changes, nil
+}
+
+// sameJSON compares decoded values, so key order and whitespace don't count.
+func sameJSON(a, b json.RawMessage) bool {
+	var va, vb any
+	if len(a) > 0 {
+		if err := json.Unmarshal(a, &va); err != nil {
+			return false
+		}
+	}
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, &vb); err != nil {
+			return false
+		}
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+func nullIfEmpty(raw json.RawMessage) json.RawMessage {
+	if len(raw) == 0 {
+		return json.RawMessage(`null`)
+	}
+	return raw
 }
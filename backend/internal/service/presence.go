@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"spacegame-backend/internal/model"
+	"spacegame-backend/internal/repository"
+)
+
+// PresenceTimeout is how long after its last game server heartbeat a player is still
+// considered in game.
+const PresenceTimeout = 2 * time.Minute
+
+// PresenceService tells where players are: in a system (game server heartbeats, stored
+// in last_seen_at so every instance agrees), online (connected to this instance's
+// WebSocket) or offline. Friends are told over WebSocket when a player comes online.
+type PresenceService struct {
+	friendRepo *repository.FriendRepository
+	playerRepo *repository.PlayerRepository
+	wsHub      *WSHub
+}
+
+func NewPresenceService(friendRepo *repository.FriendRepository, playerRepo *repository.PlayerRepository, wsHub *WSHub) *PresenceService {
+	return &PresenceService{friendRepo: friendRepo, playerRepo: playerRepo, wsHub: wsHub}
+}
+
+// Heartbeat records the players a game server reports, and announces the ones that
+// just joined the game (unless they were already online on the WebSocket).
+func (s *PresenceService) Heartbeat(ctx context.Context, playerIDs []string, serverID *string) error {
+	joined, err := s.playerRepo.UpdateLastSeen(ctx, playerIDs, serverID, time.Now().Add(-PresenceTimeout))
+	if err != nil {
+		return err
+	}
+	for _, id := range joined {
+		if s.wsHub.IsConnected(id) {
+			continue
+		}
+		player, err := s.playerRepo.GetByID(ctx, id)
+		if err != nil {
+			log.Printf("[PRESENCE] failed to load player %s: %v", id, err)
+			continue
+		}
+		s.notifyFriends(ctx, player.ID, player.Username, model.PresenceInSystem)
+	}
+	return nil
+}
+
+// Connected is called by the WebSocket hub when a player opens their first connection.
+func (s *PresenceService) Connected(playerID, username string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	inGame, err := s.playerRepo.SeenSince(ctx, playerID, time.Now().Add(-PresenceTimeout))
+	if err != nil {
+		log.Printf("[PRESENCE] failed to check presence of %s: %v", username, err)
+		return
+	}
+	if !inGame {
+		s.notifyFriends(ctx, playerID, username, model.PresenceOnline)
+	}
+}
+
+// Resolve completes presences computed from heartbeats (see FriendRepository.ListFriends)
+// with the WebSocket connections.
+func (s *PresenceService) Resolve(friends []model.Friend) {
+	for i := range friends {
+		if friends[i].Presence.Status == model.PresenceOffline && s.wsHub.IsConnected(friends[i].PlayerID) {
+			friends[i].Presence.Status = model.PresenceOnline
+		}
+	}
+}
+
+// notifyFriends pushes "friend:online" to the player's connected friends. Failures are
+// logged: presence is best effort.
+func (s *PresenceService) notifyFriends(ctx context.Context, playerID, username, status string) {
+	ids, err := s.friendRepo.FriendIDs(ctx, playerID)
+	if err != nil {
+		log.Printf("[PRESENCE] failed to list friends of %s: %v", username, err)
+		return
+	}
+	if len(ids) == 0 {
+		return
+	}
+	data, _ := json.Marshal(model.FriendPresenceEvent{PlayerID: playerID, Username: username, Status: status})
+	for _, id := range ids {
+		s.wsHub.SendToPlayer(id, &model.WSEvent{Type: "friend:online", Data: data})
+	}
+}
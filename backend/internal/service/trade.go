@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"spacegame-backend/internal/model"
+	"spacegame-backend/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	// TradeTTL is how long a trade stays open without its offers changing.
+	TradeTTL = 15 * time.Minute
+	// MaxOpenTrades is how many open trades a player may be part of.
+	MaxOpenTrades = 5
+	// MaxTradeItems is how many different items one side may offer.
+	MaxTradeItems = 20
+)
+
+var (
+	ErrTradeNotFound     = errors.New("trade not found")
+	ErrTradeSelf         = errors.New("cannot trade with yourself")
+	ErrTradeExists       = errors.New("a trade with this player is already open")
+	ErrTradeLimit        = errors.New("too many open trades")
+	ErrTradeClosed       = errors.New("trade is no longer open")
+	ErrTradeChanged      = errors.New("the offers changed, review them before confirming")
+	ErrInvalidTradeOffer = errors.New("invalid offer: credits can't be negative and up to 20 items, each with a source, a name and a positive quantity")
+	ErrTradeItemsMissing = errors.New("offered items are no longer held")
+)
+
+// TradeService runs player-to-player trades. Each side sets an offer (credits and
+// items); nothing is moved until both confirmed the same revision of the offers, then
+// the repository swaps everything in one transaction. Both players are kept up to date
+// over WebSocket ("trade:*" events carrying the trade).
+type TradeService struct {
+	repo       *repository.TradeRepository
+	playerRepo *repository.PlayerRepository
+	friendSvc  *FriendService
+	wsHub      *WSHub
+}
+
+func NewTradeService(repo *repository.TradeRepository, playerRepo *repository.PlayerRepository, friendSvc *FriendService, wsHub *WSHub) *TradeService {
+	return &TradeService{repo: repo, playerRepo: playerRepo, friendSvc: friendSvc, wsHub: wsHub}
+}
+
+// Open starts a trade with username, with empty offers.
+func (s *TradeService) Open(ctx context.Context, playerID, username string) (*model.Trade, error) {
+	player, err := s.playerRepo.GetByID(ctx, playerID)
+	if err != nil {
+		return nil, err
+	}
+	partner, err := s.playerRepo.GetByUsername(ctx, strings.TrimSpace(username))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrPlayerNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if partner.ID == playerID {
+		return nil, ErrTradeSelf
+	}
+	if err := s.friendSvc.CheckNotBlocked(ctx, playerID, partner.ID); err != nil {
+		return nil, err
+	}
+	for _, id := range []string{playerID, partner.ID} {
+		n, err := s.repo.CountOpen(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if n >= MaxOpenTrades {
+			return nil, ErrTradeLimit
+		}
+	}
+
+	trade, err := s.repo.Create(ctx, playerID, player.Username, partner.ID, partner.Username, time.Now().Add(TradeTTL))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrTradeExists
+		}
+		return nil, err
+	}
+	s.notify(partner.ID, "trade:request", trade)
+	return trade, nil
+}
+
+// Get returns a trade the player is part of.
+func (s *TradeService) Get(ctx context.Context, playerID string, id int64) (*model.Trade, error) {
+	trade, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrTradeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if trade.Side(playerID) == "" {
+		return nil, ErrTradeNotFound
+	}
+	return trade, nil
+}
+
+// ListOpen returns the player's open trades.
+func (s *TradeService) ListOpen(ctx context.Context, playerID string) ([]model.Trade, error) {
+	return s.repo.ListForPlayer(ctx, playerID, true, 0)
+}
+
+// History returns the player's closed trades, newest first.
+func (s *TradeService) History(ctx context.Context, playerID string, limit int) ([]model.Trade, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	return s.repo.ListForPlayer(ctx, playerID, false, limit)
+}
+
+// SetOffer replaces the player's offer. Both confirmations are cleared.
+func (s *TradeService) SetOffer(ctx context.Context, playerID string, id int64, req *model.TradeOfferRequest) (*model.Trade, error) {
+	items, err := normaliseTradeItems(req.Items)
+	if err != nil {
+		return nil, err
+	}
+	if req.Credits < 0 {
+		return nil, ErrInvalidTradeOffer
+	}
+
+	trade, err := s.open(ctx, playerID, id)
+	if err != nil {
+		return nil, err
+	}
+	err = s.repo.SetOffer(ctx, id, trade.Side(playerID), req.Credits, items, time.Now().Add(TradeTTL))
+	if err != nil {
+		return nil, tradeRepoError(err)
+	}
+	return s.refresh(ctx, id, "trade:updated")
+}
+
+// Confirm accepts the offers as of revision. When the other player already confirmed
+// them the trade is executed; the returned trade is then completed.
+func (s *TradeService) Confirm(ctx context.Context, playerID string, id int64, revision int) (*model.Trade, error) {
+	trade, err := s.open(ctx, playerID, id)
+	if err != nil {
+		return nil, err
+	}
+	completed, err := s.repo.Confirm(ctx, id, trade.Side(playerID), revision)
+	if err != nil {
+		return nil, tradeRepoError(err)
+	}
+	if completed {
+		return s.refresh(ctx, id, "trade:completed")
+	}
+	return s.refresh(ctx, id, "trade:updated")
+}
+
+// Cancel closes an open trade; the other player is told.
+func (s *TradeService) Cancel(ctx context.Context, playerID string, id int64) error {
+	ok, err := s.repo.Cancel(ctx, id, playerID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if _, err := s.Get(ctx, playerID, id); err != nil {
+			return err
+		}
+		return ErrTradeClosed
+	}
+	_, err = s.refresh(ctx, id, "trade:cancelled")
+	return err
+}
+
+// ExpireOld closes the trades left open past their expiry.
+func (s *TradeService) ExpireOld(ctx context.Context) (int64, error) {
+	return s.repo.ExpireOld(ctx)
+}
+
+// open returns a trade the player is part of if it is still open and no block was
+// placed between the players since it started.
+func (s *TradeService) open(ctx context.Context, playerID string, id int64) (*model.Trade, error) {
+	trade, err := s.Get(ctx, playerID, id)
+	if err != nil {
+		return nil, err
+	}
+	if trade.Status != model.TradeOpen {
+		return nil, ErrTradeClosed
+	}
+	if err := s.friendSvc.CheckNotBlocked(ctx, playerID, trade.Other(playerID)); err != nil {
+		return nil, err
+	}
+	return trade, nil
+}
+
+// refresh reloads a trade and pushes it to both players.
+func (s *TradeService) refresh(ctx context.Context, id int64, eventType string) (*model.Trade, error) {
+	trade, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.notify(trade.Initiator.PlayerID, eventType, trade)
+	s.notify(trade.Partner.PlayerID, eventType, trade)
+	return trade, nil
+}
+
+func (s *TradeService) notify(playerID, eventType string, trade *model.Trade) {
+	data, _ := json.Marshal(trade)
+	s.wsHub.SendToPlayer(playerID, &model.WSEvent{Type: eventType, Data: data})
+}
+
+// normaliseTradeItems validates an offer's items and merges duplicates.
+func normaliseTradeItems(items []model.TradeItem) ([]model.TradeItem, error) {
+	out := make([]model.TradeItem, 0, len(items))
+	index := make(map[[3]string]int, len(items))
+	for _, item := range items {
+		item.ItemName = strings.TrimSpace(item.ItemName)
+		switch item.Source {
+		case model.TradeSourceInventory:
+			if item.Category == "" {
+				return nil, ErrInvalidTradeOffer
+			}
+		case model.TradeSourceCargo:
+			item.Category = ""
+		default:
+			return nil, ErrInvalidTradeOffer
+		}
+		if item.ItemName == "" || item.Quantity <= 0 {
+			return nil, ErrInvalidTradeOffer
+		}
+		// Taken from the player's cargo when the offer is stored
+		item.ItemType, item.IconColor = "", nil
+
+		key := [3]string{item.Source, item.Category, item.ItemName}
+		if i, ok := index[key]; ok {
+			out[i].Quantity += item.Quantity
+			continue
+		}
+		index[key] = len(out)
+		out = append(out, item)
+	}
+	if len(out) > MaxTradeItems {
+		return nil, ErrInvalidTradeOffer
+	}
+	return out, nil
+}
+
+func tradeRepoError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrTradeClosed):
+		return ErrTradeClosed
+	case errors.Is(err, repository.ErrTradeRevision):
+		return ErrTradeChanged
+	case errors.Is(err, repository.ErrInsufficientItems):
+		return ErrTradeItemsMissing
+	case errors.Is(err, repository.ErrInsufficientCredits):
+		return ErrInsufficientCredits
+	}
+	return err
+}
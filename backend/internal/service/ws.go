@@ -16,52 +16,45 @@ type WSClient struct {
 	Username      string
 	CorporationID string
 	Send          chan []byte
+	Done          chan struct{} // closed by Close; Send is only closed by the connection itself
+
+	closeOnce sync.Once
+}
+
+// Close asks the connection to shut down: its writer flushes what is queued, then
+// closes the socket, which ends the reader and unregisters the client.
+func (c *WSClient) Close() {
+	c.closeOnce.Do(func() { close(c.Done) })
 }
 
 type WSHub struct {
-	clients    map[*WSClient]bool
-	register   chan *WSClient
-	unregister chan *WSClient
-	broadcast  chan []byte
-	mu         sync.RWMutex
-	done       chan struct{}
+	clients   map[*WSClient]bool
+	broadcast chan []byte
+	mu        sync.RWMutex
+	done      chan struct{}
+
+	// onConnect runs when a player opens their first connection
+	onConnect func(playerID, username string)
 }
 
 func NewWSHub() *WSHub {
 	return &WSHub{
-		clients:    make(map[*WSClient]bool),
-		register:   make(chan *WSClient),
-		unregister: make(chan *WSClient),
-		broadcast:  make(chan []byte, 256),
-		done:       make(chan struct{}),
+		clients:   make(map[*WSClient]bool),
+		broadcast: make(chan []byte, 256),
+		done:      make(chan struct{}),
 	}
 }
 
 func (h *WSHub) Run() {
 	for {
 		select {
-		case client := <-h.register:
-			h.mu.Lock()
-			h.clients[client] = true
-			h.mu.Unlock()
-			log.Printf("WS: %s connected (total: %d)", client.Username, len(h.clients))
-
-		case client := <-h.unregister:
-			h.mu.Lock()
-			if _, ok := h.clients[client]; ok {
-				delete(h.clients, client)
-				close(client.Send)
-			}
-			h.mu.Unlock()
-			log.Printf("WS: %s disconnected (total: %d)", client.Username, len(h.clients))
-
 		case message := <-h.broadcast:
 			h.mu.Lock()
 			for client := range h.clients {
 				select {
 				case client.Send <- message:
 				default:
-					close(client.Send)
+					client.Close()
 					delete(h.clients, client)
 				}
 			}
@@ -77,12 +70,33 @@ func (h *WSHub) Shutdown() {
 	close(h.done)
 }
 
+// OnConnect sets a callback run, in its own goroutine, when a player who had no
+// connection connects. Must be called before Run.
+func (h *WSHub) OnConnect(fn func(playerID, username string)) {
+	h.onConnect = fn
+}
+
+// Register and Unregister update the hub synchronously, so a client is never
+// registered after the connection that owns it has ended.
 func (h *WSHub) Register(client *WSClient) {
-	h.register <- client
+	h.mu.Lock()
+	first := !h.connectedLocked(client.PlayerID)
+	h.clients[client] = true
+	total := len(h.clients)
+	h.mu.Unlock()
+	log.Printf("WS: %s connected (total: %d)", client.Username, total)
+	if first && h.onConnect != nil {
+		go h.onConnect(client.PlayerID, client.Username)
+	}
 }
 
+// Once Unregister returns nothing else sends on client.Send, so the connection may close it.
 func (h *WSHub) Unregister(client *WSClient) {
-	h.unregister <- client
+	h.mu.Lock()
+	delete(h.clients, client)
+	total := len(h.clients)
+	h.mu.Unlock()
+	log.Printf("WS: %s disconnected (total: %d)", client.Username, total)
 }
 
 func (h *WSHub) Broadcast(event *model.WSEvent) {
@@ -112,8 +126,74 @@ func (h *WSHub) BroadcastToCorporation(corporationID string, event *model.WSEven
 	}
 }
 
+// SendToPlayer pushes an event to every connection of a player.
+func (h *WSHub) SendToPlayer(playerID string, event *model.WSEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for client := range h.clients {
+		if client.PlayerID == playerID {
+			select {
+			case client.Send <- data:
+			default:
+			}
+		}
+	}
+}
+
+// DisconnectPlayer sends a final event to every connection of a player and closes them.
+// Returns the number of connections closed.
+func (h *WSHub) DisconnectPlayer(playerID string, event *model.WSEvent) int {
+	var data []byte
+	if event != nil {
+		data, _ = json.Marshal(event)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	n := 0
+	for client := range h.clients {
+		if client.PlayerID != playerID {
+			continue
+		}
+		if data != nil {
+			select {
+			case client.Send <- data:
+			default:
+			}
+		}
+		// The writer flushes what is queued, then closes the connection
+		client.Close()
+		delete(h.clients, client)
+		n++
+	}
+	return n
+}
+
 func (h *WSHub) OnlineCount() int {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 	return len(h.clients)
 }
+
+// IsConnected reports whether the player has at least one connection.
+func (h *WSHub) IsConnected(playerID string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.connectedLocked(playerID)
+}
+
+func (h *WSHub) connectedLocked(playerID string) bool {
+	for client := range h.clients {
+		if client.PlayerID == playerID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package stateschema
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"spacegame-backend/internal/model"
+)
+
+// Sections of the player state stored as opaque JSON, each with its own schema version
+// (players.schema_versions). The gameplay sections share the gameplay_state column.
+const (
+	Fleet           = "fleet"
+	StationServices = "station_services"
+	Settings        = "settings"
+	Missions        = "missions"
+	Factions        = "factions"
+	EconomySim      = "economy_sim"
+	Pois            = "pois"
+)
+
+// Sections lists every versioned section.
+var Sections = []string{Fleet, StationServices, Settings, Missions, Factions, EconomySim, Pois}
+
+// Upgrade turns a section stored at one version into the next one.
+type Upgrade func(data json.RawMessage) (json.RawMessage, error)
+
+// upgrades[section][i] upgrades version i to i+1, so a section's current version is the
+// number of upgrades registered for it. When the game changes the shape of a section,
+// append an upgrade here (instead of an SQL migration like 017): saves are upgraded when
+// loaded, and `go run ./cmd/upgradestates` rewrites the stored rows. Saves are upgraded
+// from the schema_versions they send, so bump the game's SaveManager.SCHEMA_VERSIONS
+// in the release that writes the new shape. Never edit or remove a registered upgrade.
+var upgrades = map[string][]Upgrade{}
+
+// Current returns the current version of a section.
+func Current(section string) int {
+	return len(upgrades[section])
+}
+
+// CurrentVersions returns the current version of every section.
+func CurrentVersions() map[string]int {
+	versions := make(map[string]int, len(Sections))
+	for _, s := range Sections {
+		versions[s] = Current(s)
+	}
+	return versions
+}
+
+// UpgradeSections upgrades the sections in place from the versions they were stored at
+// (a missing version is 0). Absent (nil) sections are skipped. Reports whether any
+// section changed.
+func UpgradeSections(sections map[string]json.RawMessage, versions map[string]int) (bool, error) {
+	changed := false
+	for section, data := range sections {
+		if data == nil {
+			continue
+		}
+		from := versions[section]
+		for v := from; v < Current(section); v++ {
+			next, err := upgrades[section][v](data)
+			if err != nil {
+				return false, fmt.Errorf("upgrade %s from version %d: %w", section, v, err)
+			}
+			data = next
+		}
+		if from < Current(section) {
+			sections[section] = data
+			changed = true
+		}
+	}
+	return changed, nil
+}
+
+// UpgradeState upgrades the sections of a state stored (or sent) at versions, like
+// UpgradeSections: nil or missing versions are 0, as for a game client that predates
+// schema_versions. The state is then marked current.
+func UpgradeState(state *model.PlayerState, versions map[string]int) error {
+	sections := map[string]json.RawMessage{
+		Fleet: state.Fleet, StationServices: state.StationServices, Settings: state.Settings,
+		Missions: state.Missions, Factions: state.Factions, EconomySim: state.EconomySim, Pois: state.Pois,
+	}
+	if _, err := UpgradeSections(sections, versions); err != nil {
+		return err
+	}
+	state.Fleet, state.StationServices, state.Settings = sections[Fleet], sections[StationServices], sections[Settings]
+	state.Missions, state.Factions = sections[Missions], sections[Factions]
+	state.EconomySim, state.Pois = sections[EconomySim], sections[Pois]
+	state.SchemaVersions = CurrentVersions()
+	return nil
+}
+
+// UpgradePatch is UpgradeState for the sections present in a patch.
+func UpgradePatch(patch *model.PlayerStatePatch, versions map[string]int) error {
+	sections := map[string]json.RawMessage{
+		Fleet: patch.Fleet, StationServices: patch.StationServices, Settings: patch.Settings,
+		Missions: patch.Missions, Factions: patch.Factions, EconomySim: patch.EconomySim, Pois: patch.Pois,
+	}
+	if _, err := UpgradeSections(sections, versions); err != nil {
+		return err
+	}
+	patch.Fleet, patch.StationServices, patch.Settings = sections[Fleet], sections[StationServices], sections[Settings]
+	patch.Missions, patch.Factions = sections[Missions], sections[Factions]
+	patch.EconomySim, patch.Pois = sections[EconomySim], sections[Pois]
+	return nil
+}
@@ -0,0 +1,106 @@
+package stateschema
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"spacegame-backend/internal/model"
+)
+
+// renameShipKey is a v0 -> v1 fleet upgrade: each ship's "ship" key becomes "ship_id".
+func renameShipKey(data json.RawMessage) (json.RawMessage, error) {
+	var ships []map[string]any
+	if err := json.Unmarshal(data, &ships); err != nil {
+		return nil, err
+	}
+	for _, ship := range ships {
+		if v, ok := ship["ship"]; ok {
+			ship["ship_id"] = v
+			delete(ship, "ship")
+		}
+	}
+	return json.Marshal(ships)
+}
+
+// register replaces the upgrades of section for the duration of the test.
+func register(t *testing.T, section string, ups ...Upgrade) {
+	t.Helper()
+	saved, had := upgrades[section]
+	upgrades[section] = ups
+	t.Cleanup(func() {
+		if had {
+			upgrades[section] = saved
+		} else {
+			delete(upgrades, section)
+		}
+	})
+}
+
+const (
+	fleetV0 = `[{"ship":"fighter"}]`
+	fleetV1 = `[{"ship_id":"fighter"}]`
+)
+
+func TestUpgradeStateFromVersionZero(t *testing.T) {
+	register(t, Fleet, renameShipKey)
+
+	for name, versions := range map[string]map[string]int{
+		"nil versions":     nil,
+		"missing section":  {Settings: 0},
+		"explicit version": {Fleet: 0},
+	} {
+		t.Run(name, func(t *testing.T) {
+			state := &model.PlayerState{Fleet: json.RawMessage(fleetV0)}
+			if err := UpgradeState(state, versions); err != nil {
+				t.Fatalf("UpgradeState: %v", err)
+			}
+			if string(state.Fleet) != fleetV1 {
+				t.Errorf("fleet = %s, want %s", state.Fleet, fleetV1)
+			}
+			if state.SchemaVersions[Fleet] != 1 {
+				t.Errorf("fleet version = %d, want 1", state.SchemaVersions[Fleet])
+			}
+		})
+	}
+}
+
+func TestUpgradeStateAlreadyCurrent(t *testing.T) {
+	register(t, Fleet, renameShipKey)
+
+	state := &model.PlayerState{Fleet: json.RawMessage(fleetV1)}
+	if err := UpgradeState(state, map[string]int{Fleet: 1}); err != nil {
+		t.Fatalf("UpgradeState: %v", err)
+	}
+	if string(state.Fleet) != fleetV1 {
+		t.Errorf("fleet = %s, want it unchanged", state.Fleet)
+	}
+}
+
+func TestUpgradePatchFromVersionZero(t *testing.T) {
+	register(t, Fleet, renameShipKey)
+
+	patch := &model.PlayerStatePatch{Fleet: json.RawMessage(fleetV0)}
+	if err := UpgradePatch(patch, nil); err != nil {
+		t.Fatalf("UpgradePatch: %v", err)
+	}
+	if string(patch.Fleet) != fleetV1 {
+		t.Errorf("fleet = %s, want %s", patch.Fleet, fleetV1)
+	}
+	if patch.Settings != nil {
+		t.Errorf("settings = %s, want absent sections left absent", patch.Settings)
+	}
+}
+
+func TestUpgradeSectionsError(t *testing.T) {
+	errBroken := errors.New("broken")
+	register(t, Fleet, func(json.RawMessage) (json.RawMessage, error) { return nil, errBroken })
+
+	sections := map[string]json.RawMessage{Fleet: json.RawMessage(fleetV0)}
+	if _, err := UpgradeSections(sections, nil); !errors.Is(err, errBroken) {
+		t.Fatalf("err = %v, want %v", err, errBroken)
+	}
+	if string(sections[Fleet]) != fleetV0 {
+		t.Errorf("fleet = %s, want it unchanged after a failed upgrade", sections[Fleet])
+	}
+}
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS account_tokens;
+ALTER TABLE players DROP COLUMN IF EXISTS email_verified_at;
@@ -0,0 +1,15 @@
+-- Email verification and password reset
+ALTER TABLE players ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMPTZ;
+
+-- Single-use, time-limited tokens mailed to the player (only the hash is stored)
+CREATE TABLE account_tokens (
+    id          BIGSERIAL PRIMARY KEY,
+    player_id   UUID NOT NULL REFERENCES players(id) ON DELETE CASCADE,
+    purpose     VARCHAR(32) NOT NULL,           -- 'password_reset' | 'email_verify'
+    token_hash  VARCHAR(64) NOT NULL UNIQUE,
+    expires_at  TIMESTAMPTZ NOT NULL,
+    used_at     TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_account_tokens_player ON account_tokens(player_id, purpose);
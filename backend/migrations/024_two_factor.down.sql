@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS totp_recovery_codes;
+ALTER TABLE players DROP COLUMN IF EXISTS totp_last_step;
+ALTER TABLE players DROP COLUMN IF EXISTS totp_enabled_at;
+ALTER TABLE players DROP COLUMN IF EXISTS totp_secret;
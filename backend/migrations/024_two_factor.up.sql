@@ -0,0 +1,16 @@
+-- TOTP two-factor authentication (RFC 6238)
+ALTER TABLE players ADD COLUMN IF NOT EXISTS totp_secret VARCHAR(64);
+ALTER TABLE players ADD COLUMN IF NOT EXISTS totp_enabled_at TIMESTAMPTZ;
+-- Last accepted 30s time step, so a code can't be replayed within its window
+ALTER TABLE players ADD COLUMN IF NOT EXISTS totp_last_step BIGINT NOT NULL DEFAULT 0;
+
+-- Single-use recovery codes (only the hash is stored)
+CREATE TABLE totp_recovery_codes (
+    id          BIGSERIAL PRIMARY KEY,
+    player_id   UUID NOT NULL REFERENCES players(id) ON DELETE CASCADE,
+    code_hash   VARCHAR(64) NOT NULL,
+    used_at     TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_totp_recovery_codes_player ON totp_recovery_codes(player_id);
@@ -0,0 +1,17 @@
+-- Failed-login tracking (per account and per IP) for brute-force protection
+CREATE TABLE login_attempts (
+    id          BIGSERIAL PRIMARY KEY,
+    player_id   UUID REFERENCES players(id) ON DELETE CASCADE,  -- NULL when the username doesn't exist
+    username    VARCHAR(64) NOT NULL,
+    ip_address  VARCHAR(64) NOT NULL DEFAULT '',
+    success     BOOLEAN NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_login_attempts_player ON login_attempts(player_id, created_at DESC);
+CREATE INDEX idx_login_attempts_ip ON login_attempts(ip_address, created_at DESC) WHERE success = FALSE;
+
+-- Consecutive failures since the last successful login, and temporary lockout
+ALTER TABLE players ADD COLUMN IF NOT EXISTS failed_login_count INT NOT NULL DEFAULT 0;
+ALTER TABLE players ADD COLUMN IF NOT EXISTS last_failed_login_at TIMESTAMPTZ;
+ALTER TABLE players ADD COLUMN IF NOT EXISTS locked_until TIMESTAMPTZ;
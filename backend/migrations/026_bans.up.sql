@@ -0,0 +1,20 @@
+-- Ban history: players.is_banned stays as a denormalized "has an active ban" flag
+CREATE TABLE bans (
+    id            BIGSERIAL PRIMARY KEY,
+    player_id     UUID NOT NULL REFERENCES players(id) ON DELETE CASCADE,
+    reason        TEXT NOT NULL,
+    issued_by     VARCHAR(64) NOT NULL,
+    starts_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at    TIMESTAMPTZ,                     -- NULL = permanent
+    lifted_at     TIMESTAMPTZ,
+    lifted_by     VARCHAR(64),
+    appeal_notes  TEXT NOT NULL DEFAULT '',
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_bans_player ON bans(player_id, created_at DESC);
+CREATE INDEX idx_bans_open ON bans(player_id) WHERE lifted_at IS NULL;
+
+-- Existing bans become permanent bans with an unknown reason
+INSERT INTO bans (player_id, reason, issued_by)
+SELECT id, 'Legacy ban (before ban history)', 'system' FROM players WHERE is_banned = TRUE;
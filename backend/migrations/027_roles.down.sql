@@ -0,0 +1,3 @@
+ALTER TABLE players DROP CONSTRAINT IF EXISTS fk_players_role;
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS roles;
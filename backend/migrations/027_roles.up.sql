@@ -0,0 +1,38 @@
+-- Roles and their permissions (players.role references roles.name)
+CREATE TABLE roles (
+    name        VARCHAR(16) PRIMARY KEY,
+    rank        INT NOT NULL,           -- RequireRole(x) admits every role ranked >= x
+    description TEXT NOT NULL DEFAULT ''
+);
+
+INSERT INTO roles (name, rank, description) VALUES
+    ('player',      0, 'Regular player'),
+    ('moderator',  10, 'Chat and ban moderation'),
+    ('gm',         20, 'Game master: moderation and announcements'),
+    ('admin',     100, 'Full access');
+
+CREATE TABLE role_permissions (
+    role        VARCHAR(16) NOT NULL REFERENCES roles(name) ON UPDATE CASCADE ON DELETE CASCADE,
+    permission  VARCHAR(64) NOT NULL,   -- '*' grants everything
+    PRIMARY KEY (role, permission)
+);
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('moderator', 'chat.read'),
+    ('moderator', 'players.view'),
+    ('moderator', 'players.unlock'),
+    ('moderator', 'bans.view'),
+    ('moderator', 'bans.issue'),
+    ('moderator', 'bans.lift'),
+    ('gm',        'chat.read'),
+    ('gm',        'players.view'),
+    ('gm',        'players.unlock'),
+    ('gm',        'bans.view'),
+    ('gm',        'bans.issue'),
+    ('gm',        'bans.lift'),
+    ('gm',        'announce'),
+    ('admin',     '*');
+
+UPDATE players SET role = 'player' WHERE role NOT IN (SELECT name FROM roles);
+ALTER TABLE players ADD CONSTRAINT fk_players_role
+    FOREIGN KEY (role) REFERENCES roles(name) ON UPDATE CASCADE;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_players_deletion_due;
+ALTER TABLE players DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE players DROP COLUMN IF EXISTS deletion_scheduled_at;
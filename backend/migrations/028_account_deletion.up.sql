@@ -0,0 +1,7 @@
+-- Self-service account deletion with a grace period. When it runs, the players row is
+-- kept as an anonymised tombstone so market and corporation history stay consistent.
+ALTER TABLE players ADD COLUMN IF NOT EXISTS deletion_scheduled_at TIMESTAMPTZ;
+ALTER TABLE players ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+CREATE INDEX idx_players_deletion_due ON players(deletion_scheduled_at)
+    WHERE deletion_scheduled_at IS NOT NULL AND deleted_at IS NULL;
@@ -0,0 +1,6 @@
+ALTER TABLE game_events DROP COLUMN IF EXISTS server_id;
+ALTER TABLE chat_messages DROP COLUMN IF EXISTS server_id;
+ALTER TABLE fleet_ships DROP COLUMN IF EXISTS updated_by_server_id;
+ALTER TABLE players DROP COLUMN IF EXISTS last_server_id;
+DROP TABLE IF EXISTS game_server_nonces;
+DROP TABLE IF EXISTS game_servers;
@@ -0,0 +1,26 @@
+-- Registered game servers, each with its own HMAC secret (replaces the shared SERVER_KEY).
+-- The secret is stored as-is: it is needed to verify signatures.
+CREATE TABLE game_servers (
+    id           UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name         VARCHAR(64) NOT NULL UNIQUE,
+    secret       VARCHAR(128) NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMPTZ,
+    revoked_at   TIMESTAMPTZ
+);
+
+-- Nonces of signed requests, kept for the timestamp window to reject replays.
+CREATE TABLE game_server_nonces (
+    server_id  UUID NOT NULL REFERENCES game_servers(id) ON DELETE CASCADE,
+    nonce      VARCHAR(64) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (server_id, nonce)
+);
+
+CREATE INDEX idx_game_server_nonces_created ON game_server_nonces(created_at);
+
+-- Originating server of writes made through /api/v1/server (NULL = legacy shared key)
+ALTER TABLE players ADD COLUMN IF NOT EXISTS last_server_id UUID REFERENCES game_servers(id) ON DELETE SET NULL;
+ALTER TABLE fleet_ships ADD COLUMN IF NOT EXISTS updated_by_server_id UUID REFERENCES game_servers(id) ON DELETE SET NULL;
+ALTER TABLE chat_messages ADD COLUMN IF NOT EXISTS server_id UUID REFERENCES game_servers(id) ON DELETE SET NULL;
+ALTER TABLE game_events ADD COLUMN IF NOT EXISTS server_id UUID REFERENCES game_servers(id) ON DELETE SET NULL;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS admin_audit_log;
+DROP TABLE IF EXISTS admin_api_tokens;
@@ -0,0 +1,31 @@
+-- Scoped API tokens for automation (CI changelog job, scripts). Only the hash is stored.
+CREATE TABLE admin_api_tokens (
+    id           UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name         VARCHAR(64) NOT NULL UNIQUE,
+    token_hash   VARCHAR(64) NOT NULL UNIQUE,
+    scopes       TEXT[] NOT NULL DEFAULT '{}',
+    created_by   VARCHAR(64) NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at   TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    revoked_at   TIMESTAMPTZ
+);
+
+-- Every request made on /admin and /mod routes, with who made it.
+CREATE TABLE admin_audit_log (
+    id          BIGSERIAL PRIMARY KEY,
+    actor_type  VARCHAR(16) NOT NULL,  -- player, token, admin_key
+    actor_id    VARCHAR(64) NOT NULL DEFAULT '',
+    actor_name  VARCHAR(64) NOT NULL DEFAULT '',
+    action      VARCHAR(128) NOT NULL, -- method + route pattern
+    target      VARCHAR(255) NOT NULL DEFAULT '',
+    path        VARCHAR(512) NOT NULL DEFAULT '',
+    payload     JSONB,
+    status      SMALLINT NOT NULL DEFAULT 0,
+    ip_address  VARCHAR(64) NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_admin_audit_created ON admin_audit_log(created_at DESC);
+CREATE INDEX idx_admin_audit_actor ON admin_audit_log(actor_name, id DESC);
+CREATE INDEX idx_admin_audit_target ON admin_audit_log(target, id DESC) WHERE target != '';
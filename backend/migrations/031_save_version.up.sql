@@ -0,0 +1,2 @@
+-- Optimistic concurrency for player saves: bumped on every SaveFullState.
+ALTER TABLE players ADD COLUMN IF NOT EXISTS save_version BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,16 @@
+-- Copies of the player state taken on every save, for admin rollback.
+-- The last N regular snapshots are kept per player, plus the first snapshot of each
+-- day (daily = TRUE) for 30 days.
+CREATE TABLE player_snapshots (
+    id           BIGSERIAL PRIMARY KEY,
+    player_id    UUID NOT NULL REFERENCES players(id) ON DELETE CASCADE,
+    save_version BIGINT NOT NULL,
+    daily        BOOLEAN NOT NULL DEFAULT FALSE,
+    reason       VARCHAR(32) NOT NULL DEFAULT 'save', -- save, restore
+    created_by   VARCHAR(64) NOT NULL DEFAULT '',      -- admin who restored, game server ID, or '' for the client
+    state        JSONB NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_player_snapshots_player ON player_snapshots(player_id, id DESC);
+CREATE INDEX idx_player_snapshots_daily ON player_snapshots(created_at) WHERE daily;
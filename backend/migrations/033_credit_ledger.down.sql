@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS credit_ledger;
+DROP FUNCTION IF EXISTS credit_ledger_append_only();
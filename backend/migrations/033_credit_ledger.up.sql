@@ -0,0 +1,27 @@
+-- Append-only record of every change to players.credits, written in the same
+-- transaction as the change. SUM(delta) per player must equal players.credits.
+CREATE TABLE credit_ledger (
+    id            BIGSERIAL PRIMARY KEY,
+    player_id     UUID NOT NULL REFERENCES players(id),
+    delta         BIGINT NOT NULL,
+    balance_after BIGINT NOT NULL,
+    reason        VARCHAR(32) NOT NULL,            -- see model.CreditReason*
+    reference_id  VARCHAR(64) NOT NULL DEFAULT '', -- listing, corporation, game server... depending on reason
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_credit_ledger_player ON credit_ledger(player_id, id DESC);
+
+CREATE FUNCTION credit_ledger_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'credit_ledger is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_credit_ledger_append_only
+    BEFORE UPDATE OR DELETE ON credit_ledger
+    FOR EACH ROW EXECUTE FUNCTION credit_ledger_append_only();
+
+-- Opening balances, so the ledger reconciles from the start
+INSERT INTO credit_ledger (player_id, delta, balance_after, reason)
+SELECT id, credits, credits, 'opening_balance' FROM players WHERE credits != 0;
@@ -0,0 +1,13 @@
+-- Public leaderboards, recomputed periodically by the backend. Each (board, period) is
+-- replaced as a whole in one transaction, so readers always see a complete ranking.
+CREATE TABLE leaderboard_entries (
+    board        VARCHAR(32) NOT NULL,             -- see model.Leaderboard*
+    period       VARCHAR(16) NOT NULL,             -- daily, weekly, all_time
+    rank         INTEGER NOT NULL,
+    subject_id   UUID NOT NULL,                    -- player or corporation
+    subject_name VARCHAR(64) NOT NULL,
+    subject_tag  VARCHAR(8) NOT NULL DEFAULT '',   -- corporation tag
+    value        DOUBLE PRECISION NOT NULL,
+    computed_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (board, period, rank)
+);
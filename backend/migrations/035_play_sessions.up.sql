@@ -0,0 +1,15 @@
+-- Play sessions, built from game server heartbeats: a session opens when a player first
+-- appears in a heartbeat and ends when they stop appearing (ended_at = last heartbeat).
+CREATE TABLE play_sessions (
+    id              BIGSERIAL PRIMARY KEY,
+    player_id       UUID NOT NULL REFERENCES players(id) ON DELETE CASCADE,
+    server_id       UUID REFERENCES game_servers(id) ON DELETE SET NULL, -- NULL for the legacy shared key
+    started_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    ended_at        TIMESTAMPTZ,
+    systems_visited INTEGER[] NOT NULL DEFAULT '{}'
+);
+
+CREATE UNIQUE INDEX idx_play_sessions_open ON play_sessions(player_id) WHERE ended_at IS NULL;
+CREATE INDEX idx_play_sessions_player ON play_sessions(player_id, started_at DESC);
+CREATE INDEX idx_play_sessions_started ON play_sessions(started_at);
@@ -0,0 +1,7 @@
+-- Unlocked achievements. Definitions live in the backend (model.Achievements).
+CREATE TABLE player_achievements (
+    player_id      UUID NOT NULL REFERENCES players(id) ON DELETE CASCADE,
+    achievement_id VARCHAR(64) NOT NULL,
+    unlocked_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (player_id, achievement_id)
+);
@@ -0,0 +1,3 @@
+-- Format version of each JSON section of the save (fleet, settings, gameplay_state keys...).
+-- Missing sections are at version 0; upgrades live in internal/stateschema.
+ALTER TABLE players ADD COLUMN IF NOT EXISTS schema_versions JSONB NOT NULL DEFAULT '{}';
@@ -0,0 +1,14 @@
+-- Social graph between players, one row per direction:
+--   pending  player_id sent a friend request to other_id
+--   friend   accepted friendship (stored in both directions)
+--   blocked  player_id blocked other_id (replaces any friendship or request between them)
+CREATE TABLE player_relations (
+    player_id  UUID NOT NULL REFERENCES players(id) ON DELETE CASCADE,
+    other_id   UUID NOT NULL REFERENCES players(id) ON DELETE CASCADE,
+    status     VARCHAR(16) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (player_id, other_id),
+    CHECK (player_id <> other_id)
+);
+
+CREATE INDEX idx_player_relations_other ON player_relations(other_id, status);
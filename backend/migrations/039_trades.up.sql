@@ -0,0 +1,42 @@
+-- Player-to-player trades. Each side offers credits and items; nothing moves until both
+-- players confirmed the current revision of the offers, then the swap is done in one
+-- transaction. Closed trades are kept as the trade log.
+CREATE TABLE trades (
+    id                  BIGSERIAL PRIMARY KEY,
+    initiator_id        UUID NOT NULL REFERENCES players(id) ON DELETE CASCADE,
+    initiator_name      VARCHAR(32) NOT NULL,
+    partner_id          UUID NOT NULL REFERENCES players(id) ON DELETE CASCADE,
+    partner_name        VARCHAR(32) NOT NULL,
+    initiator_credits   BIGINT NOT NULL DEFAULT 0 CHECK (initiator_credits >= 0),
+    partner_credits     BIGINT NOT NULL DEFAULT 0 CHECK (partner_credits >= 0),
+    initiator_confirmed BOOLEAN NOT NULL DEFAULT FALSE,
+    partner_confirmed   BOOLEAN NOT NULL DEFAULT FALSE,
+    revision            INTEGER NOT NULL DEFAULT 1, -- bumped by every offer change
+    status              VARCHAR(16) NOT NULL DEFAULT 'open', -- open, completed, cancelled, expired
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at          TIMESTAMPTZ NOT NULL,
+    closed_at           TIMESTAMPTZ,
+    CHECK (initiator_id <> partner_id)
+);
+
+-- At most one open trade between two players
+CREATE UNIQUE INDEX idx_trades_open_pair ON trades(LEAST(initiator_id, partner_id), GREATEST(initiator_id, partner_id))
+    WHERE status = 'open';
+CREATE INDEX idx_trades_initiator ON trades(initiator_id, created_at DESC);
+CREATE INDEX idx_trades_partner ON trades(partner_id, created_at DESC);
+CREATE INDEX idx_trades_expires ON trades(expires_at) WHERE status = 'open';
+
+CREATE TABLE trade_items (
+    id         BIGSERIAL PRIMARY KEY,
+    trade_id   BIGINT NOT NULL REFERENCES trades(id) ON DELETE CASCADE,
+    side       VARCHAR(16) NOT NULL, -- initiator, partner
+    source     VARCHAR(16) NOT NULL, -- inventory, cargo
+    category   VARCHAR(16) NOT NULL DEFAULT '', -- inventory items only
+    item_name  VARCHAR(64) NOT NULL,
+    item_type  VARCHAR(32) NOT NULL DEFAULT '', -- cargo items only
+    icon_color VARCHAR(32),
+    quantity   INTEGER NOT NULL CHECK (quantity > 0)
+);
+
+CREATE INDEX idx_trade_items_trade ON trade_items(trade_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS mail_items;
+DROP TABLE IF EXISTS mail;
@@ -0,0 +1,35 @@
+-- In-game mail. Attachments (credits and items) are taken from the sender when the
+-- mail is sent and held here until the recipient claims them. sender_id is NULL for
+-- system mail (market, returned mail); corporation_id is set on corporation-wide mail.
+CREATE TABLE mail (
+    id             BIGSERIAL PRIMARY KEY,
+    recipient_id   UUID NOT NULL REFERENCES players(id) ON DELETE CASCADE,
+    sender_id      UUID REFERENCES players(id) ON DELETE SET NULL,
+    sender_name    VARCHAR(32) NOT NULL,
+    corporation_id UUID REFERENCES corporations(id) ON DELETE SET NULL,
+    subject        VARCHAR(100) NOT NULL,
+    body           TEXT NOT NULL DEFAULT '',
+    credits        BIGINT NOT NULL DEFAULT 0 CHECK (credits >= 0),
+    read_at        TIMESTAMPTZ,
+    claimed_at     TIMESTAMPTZ,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at     TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_mail_recipient ON mail(recipient_id, created_at DESC);
+CREATE INDEX idx_mail_unread ON mail(recipient_id) WHERE read_at IS NULL;
+CREATE INDEX idx_mail_sender ON mail(sender_id);
+CREATE INDEX idx_mail_expires ON mail(expires_at);
+
+CREATE TABLE mail_items (
+    id         BIGSERIAL PRIMARY KEY,
+    mail_id    BIGINT NOT NULL REFERENCES mail(id) ON DELETE CASCADE,
+    source     VARCHAR(16) NOT NULL, -- inventory, cargo
+    category   VARCHAR(16) NOT NULL DEFAULT '', -- inventory items only
+    item_name  VARCHAR(64) NOT NULL,
+    item_type  VARCHAR(32) NOT NULL DEFAULT '', -- cargo items only
+    icon_color VARCHAR(32),
+    quantity   INTEGER NOT NULL CHECK (quantity > 0)
+);
+
+CREATE INDEX idx_mail_items_mail ON mail_items(mail_id);
@@ -0,0 +1,2 @@
+-- Achievements count a player's kills and discoveries by actor name on every new one.
+CREATE INDEX IF NOT EXISTS idx_game_events_actor ON game_events(event_type, actor_name);
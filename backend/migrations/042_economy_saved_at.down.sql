@@ -0,0 +1 @@
+ALTER TABLE players DROP COLUMN IF EXISTS economy_saved_at;
@@ -0,0 +1,3 @@
+-- When the game server last saved the player's economy (POST /api/v1/server/economy):
+-- credits and items may only grow so fast since then.
+ALTER TABLE players ADD COLUMN IF NOT EXISTS economy_saved_at TIMESTAMPTZ;
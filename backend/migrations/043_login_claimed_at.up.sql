@@ -0,0 +1,3 @@
+-- Set while a login attempt on the account is being checked: the throttle check and
+-- the claim are one statement, so concurrent guesses can't all pass the same check.
+ALTER TABLE players ADD COLUMN IF NOT EXISTS login_claimed_at TIMESTAMPTZ;
@@ -51,6 +51,12 @@ echo ""
 JWT_SECRET=$(gen_secret 32)
 SERVER_KEY=$(gen_secret 16)
 ADMIN_KEY=$(gen_secret 16)
+# Ed25519 key signing access tokens (required with ENV=production), newlines escaped as \n
+if command -v go &> /dev/null; then
+    JWT_PRIVATE_KEYS=$(cd backend && go run ./cmd/jwtkey | awk '{printf "%s\\n", $0}')
+else
+    JWT_PRIVATE_KEYS=$(openssl genpkey -algorithm ed25519 | awk '{printf "%s\\n", $0}')
+fi
 
 echo -e "${YELLOW}Generated secrets (save these!):${NC}"
 echo "  JWT_SECRET  = $JWT_SECRET"
@@ -82,6 +88,7 @@ echo "Setting backend environment variables..."
 railway variables \
     --set "DATABASE_URL=\${{Postgres.DATABASE_URL}}" \
     --set "JWT_SECRET=$JWT_SECRET" \
+    --set "JWT_PRIVATE_KEYS=$JWT_PRIVATE_KEYS" \
     --set "SERVER_KEY=$SERVER_KEY" \
     --set "ADMIN_KEY=$ADMIN_KEY" \
     --set "ENV=production" \
@@ -159,6 +166,8 @@ echo "  2. In Railway dashboard, set Dockerfile Path for gameserver to 'gameserv
 echo "  3. Verify both services deploy successfully (check logs)"
 echo "  4. Update constants.gd with the Railway URLs"
 echo "  5. Push the constants.gd update → triggers redeploy"
+echo "  6. Register the game server (POST /api/v1/admin/game-servers) and set SERVER_ID"
+echo "     and SERVER_SECRET on the gameserver service so it signs its backend requests"
 echo ""
 echo -e "${GREEN}Secrets (save in a safe place!):${NC}"
 echo "  JWT_SECRET  = $JWT_SECRET"
@@ -13,11 +13,27 @@ signal load_failed(error: String)
 
 const AUTO_SAVE_INTERVAL: float = 30.0  # Reduced from 60s to limit credit revert window
 const MIN_SAVE_INTERVAL: float = 5.0
+const ECONOMY_SAVE_TIMEOUT: float = 20.0  # Server answers after its backend request (15s timeout)
+
+## Format version of each JSON section this client writes (backend stateschema).
+## Bump a section when its shape changes, together with the backend upgrade to it:
+## the backend upgrades saves from the versions they are sent with.
+const SCHEMA_VERSIONS: Dictionary = {
+	"fleet": 0,
+	"station_services": 0,
+	"settings": 0,
+	"missions": 0,
+	"factions": 0,
+	"economy_sim": 0,
+	"pois": 0,
+}
 
 var _is_dirty: bool = false
 var _last_save_time: float = 0.0
 var _auto_save_timer: Timer = null
 var _saving: bool = false
+var _save_version: int = -1  # save_version of the state we loaded/last saved (-1 = not loaded yet)
+var _economy_result: Dictionary = {}  # Answer to the pending economy save (empty while waiting)
 
 
 func _ready() -> void:
@@ -32,6 +48,7 @@ func _ready() -> void:
 
 	# Save immediately on REAL network disconnect (not initial connection failures)
 	NetworkManager.server_connection_lost.connect(_on_network_disconnected)
+	NetworkManager.economy_save_result.connect(_on_economy_saved)
 
 
 func _notification(what: int) -> void:
@@ -79,17 +96,56 @@ func save_player_state(force: bool = false) -> bool:
 	if not force and (now - _last_save_time) < MIN_SAVE_INTERVAL:
 		return false
 
+	if _save_version < 0:
+		return false
+
 	_saving = true
 	var state =_collect_state()
-
-	var result =await ApiClient.put_async("/api/v1/player/state", state)
+	state["save_version"] = _save_version
+	state["schema_versions"] = SCHEMA_VERSIONS
+
+	# Credits, resources and inventory are only accepted from the game server:
+	# send them there first, then save the rest with the version it returns.
+	var result: Dictionary = {}
+	if NetworkManager.is_connected_to_server() and not NetworkManager.is_server():
+		result = await _save_economy(state)
+		if result.get("ok", false):
+			_save_version = int(result.get("save_version", _save_version + 1))
+			state["save_version"] = _save_version
+	if result.is_empty() or result.get("ok", false):
+		result = await ApiClient.put_async("/api/v1/player/state", state)
 	_saving = false
 	_last_save_time = Time.get_ticks_msec() / 1000.0
 
 	if result.get("ok", false) or result.get("_status_code", 0) == 200:
+		_save_version = int(result.get("save_version", _save_version + 1))
 		_is_dirty = false
 		save_completed.emit()
+		var ignored = result.get("ignored_fields", [])
+		if ignored is Array and not ignored.is_empty():
+			# Credits/inventory etc. are server-authoritative: resync with what the backend kept
+			push_warning("SaveManager: Backend kept its own %s, reloading state" % str(ignored))
+			var fresh := await load_player_state()
+			if not fresh.is_empty():
+				apply_state(fresh)
 		return true
+	elif result.get("_status_code", 0) == 409:
+		# Someone else (another session or the game server) saved since we loaded:
+		# take the server's state instead of overwriting it.
+		push_warning("SaveManager: Stale save rejected, reloading state from server")
+		save_failed.emit("stale")
+		var fresh := await load_player_state()
+		if not fresh.is_empty():
+			apply_state(fresh)
+		return false
+	elif result.get("_status_code", 0) == 422:
+		# The game server refused our credits/inventory: go back to the saved ones.
+		push_warning("SaveManager: Economy rejected by server (%s), reloading state" % result.get("error", ""))
+		save_failed.emit("economy_rejected")
+		var fresh := await load_player_state()
+		if not fresh.is_empty():
+			apply_state(fresh)
+		return false
 	else:
 		var error: String = result.get("error", "save failed")
 		save_failed.emit(error)
@@ -97,6 +153,27 @@ func save_player_state(force: bool = false) -> bool:
 		return false
 
 
+## Send the economy part of state to the game server and wait for its answer.
+func _save_economy(state: Dictionary) -> Dictionary:
+	_economy_result = {}
+	NetworkManager.save_economy({
+		"save_version": state["save_version"],
+		"credits": state.get("credits", 0),
+		"resources": state.get("resources", []),
+		"inventory": state.get("inventory", []),
+	})
+	var deadline := Time.get_ticks_msec() + int(ECONOMY_SAVE_TIMEOUT * 1000.0)
+	while _economy_result.is_empty() and Time.get_ticks_msec() < deadline:
+		await get_tree().process_frame
+	if _economy_result.is_empty():
+		return {"_status_code": 0, "error": "game server did not answer"}
+	return _economy_result
+
+
+func _on_economy_saved(result: Dictionary) -> void:
+	_economy_result = result
+
+
 # --- Load ---
 
 func load_player_state() -> Dictionary:
@@ -108,6 +185,7 @@ func load_player_state() -> Dictionary:
 	var status: int = result.get("_status_code", 0)
 
 	if status == 200:
+		_save_version = int(result.get("save_version", 0))
 		load_completed.emit(result)
 		return result
 	else:
@@ -3,12 +3,22 @@ extends Node
 
 # =============================================================================
 # Server Backend Client — HTTP client for the Godot game server (headless)
-# to communicate with the Go backend. Authenticated via SERVER_KEY header.
-# Used for fleet persistence (sync positions, report deaths, load deployed).
+# to communicate with the Go backend. Requests are signed with the server's
+# identity (SERVER_ID + SERVER_SECRET, from POST /admin/game-servers); without
+# one, falls back to the legacy shared SERVER_KEY header.
+# Used for fleet persistence (sync positions, report deaths, load deployed),
+# chat, blocks, and the players' economy saves (relayed by NetEconomyServer).
 # =============================================================================
 
 const REQUEST_TIMEOUT: float = 15.0
 const MAX_RETRIES: int = 3
+const METHOD_NAMES := {
+	HTTPClient.METHOD_GET: "GET",
+	HTTPClient.METHOD_POST: "POST",
+	HTTPClient.METHOD_PUT: "PUT",
+	HTTPClient.METHOD_DELETE: "DELETE",
+	HTTPClient.METHOD_PATCH: "PATCH",
+}
 var _retry_delays := [2.0, 5.0, 10.0]
 
 
@@ -16,22 +26,61 @@ func _get_base_url() -> String:
 	return Constants.BACKEND_URL
 
 
-func _get_server_key() -> String:
-	var env_key: String = OS.get_environment("SERVER_KEY")
-	if env_key != "":
-		return env_key
+## Reads a setting from the environment (Railway) or a CLI arg (local dev).
+static func _get_setting(env_name: String, arg_name: String, fallback: String = "") -> String:
+	var env_value: String = OS.get_environment(env_name)
+	if env_value != "":
+		return env_value
 	var args: PackedStringArray = OS.get_cmdline_args() + OS.get_cmdline_user_args()
 	for i in args.size():
-		if args[i] == "--server-key" and i + 1 < args.size():
+		if args[i] == arg_name and i + 1 < args.size():
 			return args[i + 1]
-	return "dev-server-key"
+	return fallback
+
+
+static func _get_server_key() -> String:
+	return _get_setting("SERVER_KEY", "--server-key", "dev-server-key")
+
+
+## Headers for a request to /api/v1/server/*. With SERVER_ID and SERVER_SECRET set,
+## the request is signed: X-Server-Signature = hex(HMAC-SHA256(secret,
+## METHOD\npath?query\ntimestamp\nnonce\nhex(SHA256(body)))). body must be the exact
+## string sent. Build headers per attempt: each nonce is only accepted once.
+static func make_headers(http_method: int, url: String, body: String = "") -> PackedStringArray:
+	var headers := PackedStringArray(["Content-Type: application/json"])
+	var server_id := _get_setting("SERVER_ID", "--server-id")
+	var secret := _get_setting("SERVER_SECRET", "--server-secret")
+	if server_id == "" or secret == "":
+		headers.append("X-Server-Key: " + _get_server_key())
+		return headers
+
+	var timestamp := str(int(Time.get_unix_time_from_system()))
+	var nonce := Crypto.new().generate_random_bytes(16).hex_encode()
+	var canonical := "\n".join([
+		METHOD_NAMES.get(http_method, "GET"),
+		_request_uri(url),
+		timestamp,
+		nonce,
+		body.sha256_text(),
+	])
+	var hmac := HMACContext.new()
+	hmac.start(HashingContext.HASH_SHA256, secret.to_utf8_buffer())
+	hmac.update(canonical.to_utf8_buffer())
 
+	headers.append("X-Server-Id: " + server_id)
+	headers.append("X-Server-Timestamp: " + timestamp)
+	headers.append("X-Server-Nonce: " + nonce)
+	headers.append("X-Server-Signature: " + hmac.finish().hex_encode())
+	return headers
 
-func _make_headers() -> PackedStringArray:
-	return PackedStringArray([
-		"Content-Type: application/json",
-		"X-Server-Key: " + _get_server_key(),
-	])
+
+## Path and query of a URL ("https://host/api/v1/x?y=1" -> "/api/v1/x?y=1").
+static func _request_uri(url: String) -> String:
+	var scheme_end := url.find("://")
+	var path_start := url.find("/", scheme_end + 3 if scheme_end >= 0 else 0)
+	if path_start < 0:
+		return "/"
+	return url.substr(path_start)
 
 
 ## Internal: execute an HTTP request with automatic retries and response logging.
@@ -48,9 +97,9 @@ func _request_with_retry(method_name: String, url: String, http_method: int, jso
 
 		var err: Error
 		if json_str != "":
-			err = http.request(url, _make_headers(), http_method, json_str)
+			err = http.request(url, make_headers(http_method, url, json_str), http_method, json_str)
 		else:
-			err = http.request(url, _make_headers(), http_method)
+			err = http.request(url, make_headers(http_method, url), http_method)
 
 		if err != OK:
 			http.queue_free()
@@ -86,7 +135,7 @@ func get_deployed_fleet_ships() -> Array:
 	http.timeout = REQUEST_TIMEOUT
 	add_child(http)
 
-	var err := http.request(url, _make_headers(), HTTPClient.METHOD_GET)
+	var err := http.request(url, make_headers(HTTPClient.METHOD_GET, url), HTTPClient.METHOD_GET)
 	if err != OK:
 		http.queue_free()
 		push_error("ServerBackendClient: GET deployed failed: %s" % error_string(err))
@@ -132,13 +181,13 @@ func report_fleet_death(player_uuid: String, fleet_index: int) -> bool:
 # HEARTBEAT
 # =============================================================================
 
-## POST /api/v1/server/heartbeat → update last_seen_at for connected players.
-## Fire-and-forget, 1 retry.
-func send_heartbeat(player_uuids: Array) -> bool:
+## POST /api/v1/server/heartbeat → update last_seen_at and play sessions for connected
+## players. systems maps player_uuid -> current system_id. Fire-and-forget, 1 retry.
+func send_heartbeat(player_uuids: Array, systems: Dictionary = {}) -> bool:
 	if player_uuids.is_empty():
 		return true
 	var url: String = _get_base_url() + "/api/v1/server/heartbeat"
-	var json_str := JSON.stringify({"player_ids": player_uuids})
+	var json_str := JSON.stringify({"player_ids": player_uuids, "systems": systems})
 	return await _request_with_retry("POST heartbeat", url, HTTPClient.METHOD_POST, json_str, 1)
 
 
@@ -159,15 +208,18 @@ func post_chat_message(channel: int, system_id: int, sender_name: String, text:
 
 
 ## GET /api/v1/server/chat/history → retrieve recent messages for given channels.
-## Returns Array of Dictionaries with keys: sender_name, text, channel, created_at.
-func get_chat_history(channels: Array, system_id: int, limit: int = 50) -> Array:
+## With player_uuid, messages between that player and players blocked either way are
+## left out. Returns Array of Dictionaries with keys: sender_name, text, channel, created_at.
+func get_chat_history(channels: Array, system_id: int, limit: int = 50, player_uuid: String = "") -> Array:
 	var ch_str: String = ",".join(channels.map(func(c): return str(c)))
 	var url: String = _get_base_url() + "/api/v1/server/chat/history?channels=%s&system_id=%d&limit=%d" % [ch_str, system_id, limit]
+	if player_uuid != "":
+		url += "&player_id=" + player_uuid.uri_encode()
 	var http := HTTPRequest.new()
 	http.timeout = REQUEST_TIMEOUT
 	add_child(http)
 
-	var err := http.request(url, _make_headers(), HTTPClient.METHOD_GET)
+	var err := http.request(url, make_headers(HTTPClient.METHOD_GET, url), HTTPClient.METHOD_GET)
 	if err != OK:
 		http.queue_free()
 		push_error("ServerBackendClient: GET chat/history failed: %s" % error_string(err))
@@ -186,3 +238,90 @@ func get_chat_history(channels: Array, system_id: int, limit: int = 50) -> Array
 	if parsed is Dictionary:
 		return parsed.get("messages", [])
 	return []
+
+
+# =============================================================================
+# PLAYER BLOCKS
+# =============================================================================
+
+## POST /api/v1/server/blocks → players each given player blocked.
+## Returns Dictionary player_uuid -> Array of {player_id, username, created_at}
+## (every requested player is present), or {} on failure.
+func get_blocks(player_uuids: Array) -> Dictionary:
+	if player_uuids.is_empty():
+		return {}
+	var url: String = _get_base_url() + "/api/v1/server/blocks"
+	var http := HTTPRequest.new()
+	http.timeout = REQUEST_TIMEOUT
+	add_child(http)
+
+	var json_str := JSON.stringify({"player_ids": player_uuids})
+	var err := http.request(url, make_headers(HTTPClient.METHOD_POST, url, json_str), HTTPClient.METHOD_POST, json_str)
+	if err != OK:
+		http.queue_free()
+		push_error("ServerBackendClient: POST blocks failed: %s" % error_string(err))
+		return {}
+
+	var result: Array = await http.request_completed
+	http.queue_free()
+
+	var response_code: int = result[1]
+	var body_str: String = result[3].get_string_from_utf8() if result[3].size() > 0 else ""
+	if response_code != 200:
+		push_error("ServerBackendClient: POST blocks returned %d: %s" % [response_code, body_str])
+		return {}
+
+	var parsed = JSON.parse_string(body_str)
+	if parsed is Dictionary and parsed.get("blocks") is Dictionary:
+		return parsed["blocks"]
+	return {}
+
+
+# =============================================================================
+# PLAYER ECONOMY
+# =============================================================================
+
+## POST /api/v1/server/validate-token → who a player access token belongs to.
+## Returns {valid, player_id, username, role} (valid=false for a bad or banned token).
+func validate_token(token: String) -> Dictionary:
+	var url: String = _get_base_url() + "/api/v1/server/validate-token"
+	var result := await _request_json("POST validate-token", url, HTTPClient.METHOD_POST, JSON.stringify({"token": token}))
+	if result.get("_status_code", 0) != 200:
+		return {"valid": false}
+	return result
+
+
+## POST /api/v1/server/economy → save a player's credits, resources and inventory.
+## state must carry the save_version the client loaded. The backend bounds the gains
+## since the last economy save (422 when rejected). Returns the response body
+## ({ok, save_version} or {error}) with "_status_code" (0 when the request failed).
+## No retry: the client saves again on its next cycle.
+func save_economy(player_uuid: String, state: Dictionary) -> Dictionary:
+	var url: String = _get_base_url() + "/api/v1/server/economy"
+	var json_str := JSON.stringify({"player_id": player_uuid, "state": state})
+	return await _request_json("POST economy", url, HTTPClient.METHOD_POST, json_str)
+
+
+## Internal: one request, returning the JSON body with "_status_code" added.
+func _request_json(method_name: String, url: String, http_method: int, json_str: String) -> Dictionary:
+	var http := HTTPRequest.new()
+	http.timeout = REQUEST_TIMEOUT
+	add_child(http)
+
+	var err := http.request(url, make_headers(http_method, url, json_str), http_method, json_str)
+	if err != OK:
+		http.queue_free()
+		push_error("ServerBackendClient: %s failed: %s" % [method_name, error_string(err)])
+		return {"_status_code": 0, "error": error_string(err)}
+
+	var result: Array = await http.request_completed
+	http.queue_free()
+
+	var response_code: int = result[1]
+	var body_str: String = result[3].get_string_from_utf8() if result[3].size() > 0 else ""
+	var parsed = JSON.parse_string(body_str) if body_str != "" else null
+	var data: Dictionary = parsed if parsed is Dictionary else {}
+	data["_status_code"] = response_code
+	if response_code != 200:
+		push_error("ServerBackendClient: %s returned %d: %s" % [method_name, response_code, body_str])
+	return data
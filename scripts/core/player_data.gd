@@ -166,8 +166,8 @@ func collect_save_state(player_ship, system_transition) -> Dictionary:
 	if inventory:
 		state["inventory"] = inventory.serialize()
 
-	# Cargo — top-level empty, per-ship cargo is inside fleet
-	state["cargo"] = []
+	# Cargo — per-ship cargo is inside fleet. The top-level cargo is held by the
+	# backend (trades, mail) and left out so saves don't overwrite it.
 
 	# Equipment
 	if player_ship:
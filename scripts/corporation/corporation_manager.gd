@@ -238,6 +238,7 @@ func deposit_funds(amount: float) -> bool:
 		push_warning("CorporationManager: deposit failed — %s" % result.get("error", "unknown"))
 		return false
 	corporation_data.treasury_balance = float(result.get("treasury", corporation_data.treasury_balance + amount))
+	_sync_player_credits(result)
 
 	if player_member:
 		player_member.contribution_total += amount
@@ -259,6 +260,7 @@ func withdraw_funds(amount: float) -> bool:
 		push_warning("CorporationManager: withdraw failed — %s" % result.get("error", "unknown"))
 		return false
 	corporation_data.treasury_balance = float(result.get("treasury", corporation_data.treasury_balance - amount))
+	_sync_player_credits(result)
 
 	var t := { "timestamp": int(Time.get_unix_time_from_system()), "type": "Retrait", "amount": -amount, "actor": player_member.display_name if player_member else "?" }
 	transactions.append(t)
@@ -267,6 +269,12 @@ func withdraw_funds(amount: float) -> bool:
 	return true
 
 
+## Treasury transfers move the player's credits on the backend: mirror the new balance.
+func _sync_player_credits(result: Dictionary) -> void:
+	if result.has("credits") and GameManager.player_economy:
+		GameManager.player_economy.set_credits(int(result["credits"]))
+
+
 func set_diplomacy_relation(target_corporation_id: String, relation: String) -> bool:
 	if not player_has_permission(CorporationRank.PERM_DIPLOMACY) or not AuthManager.is_authenticated:
 		return false
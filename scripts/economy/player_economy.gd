@@ -38,6 +38,14 @@ func add_credits(amount: int) -> void:
 	credits_changed.emit(credits)
 
 
+## Align with a balance returned by the backend (it is authoritative for transfers).
+func set_credits(amount: int) -> void:
+	if credits == amount:
+		return
+	credits = amount
+	credits_changed.emit(credits)
+
+
 func spend_credits(amount: int) -> bool:
 	if credits < amount:
 		return false
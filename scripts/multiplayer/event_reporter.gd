@@ -6,7 +6,7 @@ extends Node
 # Sends notable game events to the backend API for Discord integration.
 # Active on the dedicated game server (headless).
 # Checks server status lazily on each send — no timing issues with init order.
-# Uses X-Server-Key auth (server-to-server), not player JWT.
+# Uses server-to-server auth (ServerBackendClient.make_headers), not player JWT.
 # =============================================================================
 
 
@@ -51,19 +51,11 @@ func _send_event(data: Dictionary) -> void:
 	# Only send events from the server (host or dedicated)
 	if not NetworkManager or not NetworkManager.is_server():
 		return
-	var server_key := _get_server_key()
-	if server_key == "":
-		print("[EventReporter] No server key — skipping event")
-		return
 	var url: String = Constants.BACKEND_URL
 	url += "/api/v1/server/event"
 
-	var headers := PackedStringArray([
-		"Content-Type: application/json",
-		"X-Server-Key: " + server_key,
-	])
-
 	var json_str := JSON.stringify(data)
+	var headers := ServerBackendClient.make_headers(HTTPClient.METHOD_POST, url, json_str)
 	print("[EventReporter] Sending %s to %s" % [data.get("type", "?"), url])
 	var http := HTTPRequest.new()
 	add_child(http)
@@ -75,16 +67,3 @@ func _send_event(data: Dictionary) -> void:
 			print("[EventReporter] HTTP %d (result=%d): %s" % [code, result, body.get_string_from_utf8()])
 		http.queue_free()
 	)
-
-
-func _get_server_key() -> String:
-	# Check environment variable first (Railway deployment)
-	var env_key: String = OS.get_environment("SERVER_KEY")
-	if env_key != "":
-		return env_key
-	# Then check CLI arg (local dev: --server-key <key>)
-	var args: PackedStringArray = OS.get_cmdline_args() + OS.get_cmdline_user_args()
-	for i in args.size():
-		if args[i] == "--server-key" and i + 1 < args.size():
-			return args[i + 1]
-	return "dev-server-key"
@@ -22,6 +22,13 @@ var _chat_preload_done: bool = false
 const HEARTBEAT_INTERVAL: float = 60.0
 var _heartbeat_timer: float = 0.0
 
+# Player blocks: uuid -> {blocked_uuid: blocked_name}, loaded when a player joins and
+# refreshed with each heartbeat. Messages are never relayed between two players when
+# either blocked the other. The backend has no way to notify the game server, so a block
+# or unblock made while connected reaches live chat and whispers up to
+# HEARTBEAT_INTERVAL later (the backend's chat history applies it at once).
+var _blocks: Dictionary = {}
+
 
 func _init(nm: NetworkManagerSystem) -> void:
 	_nm = nm
@@ -107,17 +114,38 @@ func store_message(channel: int, sender_name: String, text: String, override_sys
 # History delivery
 # -------------------------------------------------------------------------
 
+## Load a newly registered player's blocks, then send them the chat history. The
+## backend's history leaves out players blocked either way; the buffer is the fallback.
+func welcome_peer(peer_id: int, system_id: int, player_uuid: String) -> void:
+	if player_uuid != "":
+		await _refresh_blocks([player_uuid])
+		if await _send_backend_history_to_peer(peer_id, system_id, player_uuid):
+			return
+	send_history_to_peer(peer_id, system_id)
+
+
 ## Send chat history to a newly connected peer (from in-memory buffer — instant).
 func send_history_to_peer(peer_id: int, system_id: int) -> void:
 	print("[Chat] _send_chat_history: peer=%d sys=%d buffer=%d" % [peer_id, system_id, _chat_buffer.size()])
 	if _chat_buffer.is_empty():
 		print("[Chat] _send_chat_history: buffer empty, skipping")
 		return
+	var blocked_names: Dictionary = {}
+	var peer_uuid: String = _nm._peer_registry.get_peer_to_uuid().get(peer_id, "")
+	for blocked_name in _blocks.get(peer_uuid, {}).values():
+		blocked_names[String(blocked_name).to_lower()] = true
+	# Connected players who blocked this one (offline ones are only known to the backend)
+	for uuid in _blocks:
+		var pid: int = _nm.get_uuid_peer(uuid)
+		if peer_uuid != "" and _blocks[uuid].has(peer_uuid) and _nm.peers.has(pid):
+			blocked_names[String(_nm.peers[pid].player_name).to_lower()] = true
 	var history: Array = []
 	for entry in _chat_buffer:
 		var ch: int = entry.get("ch", 0)
 		if ch == 1 and entry.get("sys", -1) != system_id:
 			continue
+		if blocked_names.has(String(entry.get("s", "")).to_lower()):
+			continue
 		history.append({"s": entry.get("s", ""), "t": entry.get("t", ""), "ts": entry.get("ts", ""), "ch": ch, "ctag": entry.get("ctag", ""), "rl": entry.get("rl", "player")})
 	print("[Chat] _send_chat_history: after filter=%d (from %d)" % [history.size(), _chat_buffer.size()])
 	if history.is_empty():
@@ -128,6 +156,28 @@ func send_history_to_peer(peer_id: int, system_id: int) -> void:
 	_nm._rpc_chat_history.rpc_id(peer_id, history)
 
 
+## Send a player the history read from the backend, filtered for their blocks.
+## Returns false when the backend had nothing (or failed): use the buffer instead.
+func _send_backend_history_to_peer(peer_id: int, system_id: int, player_uuid: String) -> bool:
+	if _chat_backend_client == null:
+		return false
+	var backend_msgs: Array = await _chat_backend_client.get_chat_history([0, 1, 2, 3], system_id, CHAT_HISTORY_LIMIT, player_uuid)
+	if backend_msgs.is_empty():
+		return false
+	if not _nm.multiplayer.get_peers().has(peer_id):
+		return true
+	var history: Array = []
+	for msg in backend_msgs:
+		var ts: String = ""
+		var created: String = msg.get("created_at", "")
+		if created.length() >= 16:
+			ts = created.substr(11, 5)
+		history.append({"s": msg.get("sender_name", ""), "t": msg.get("text", ""), "ts": ts, "ch": int(msg.get("channel", 0)), "ctag": "", "rl": "player"})
+	print("[Chat] _send_backend_history_to_peer: sending %d messages to peer %d" % [history.size(), peer_id])
+	_nm._rpc_chat_history.rpc_id(peer_id, history)
+	return true
+
+
 ## Async: preload from backend DB at server startup, then deliver to waiting clients.
 func preload_and_emit_chat_history() -> void:
 	print("[Chat] Starting async preload from backend...")
@@ -166,7 +216,7 @@ func route_chat(sender_id: int, channel: int, text: String) -> void:
 			var sys_peers: Array[int] = _nm.get_peers_in_system(sender_sys)
 			print("[Chat] SYSTEM relay: sender_sys=%d peers_in_sys=%s" % [sender_sys, str(sys_peers)])
 			for pid in sys_peers:
-				if pid == sender_id:
+				if pid == sender_id or is_blocked(sender_id, pid):
 					continue
 				_nm._rpc_receive_chat.rpc_id(pid, sender_name, channel, text, sender_ctag, sender_role)
 		5:  # GROUP → peers in same group
@@ -174,7 +224,7 @@ func route_chat(sender_id: int, channel: int, text: String) -> void:
 			if gid > 0 and _nm._group_mgr._groups.has(gid):
 				var members: Array = _nm._group_mgr._groups[gid]["members"]
 				for pid in members:
-					if pid == sender_id:
+					if pid == sender_id or is_blocked(sender_id, pid):
 						continue
 					_nm._rpc_receive_chat.rpc_id(pid, sender_name, channel, text, sender_ctag, sender_role)
 		2:  # CORP → peers with same corporation_tag
@@ -182,14 +232,14 @@ func route_chat(sender_id: int, channel: int, text: String) -> void:
 			if sender_tag == "":
 				return
 			for pid in _nm.peers:
-				if pid == sender_id:
+				if pid == sender_id or is_blocked(sender_id, pid):
 					continue
 				if _nm.peers[pid].corporation_tag == sender_tag:
 					_nm._rpc_receive_chat.rpc_id(pid, sender_name, channel, text, sender_ctag, sender_role)
 		_:  # GLOBAL, TRADE, etc. → all except sender
 			print("[Chat] GLOBAL relay: all peers=%s" % [str(_nm.peers.keys())])
 			for pid in _nm.peers:
-				if pid == sender_id:
+				if pid == sender_id or is_blocked(sender_id, pid):
 					continue
 				print("[Chat] Relaying to peer %d" % pid)
 				_nm._rpc_receive_chat.rpc_id(pid, sender_name, channel, text, sender_ctag, sender_role)
@@ -204,9 +254,22 @@ func handle_whisper(sender_id: int, target_name: String, text: String) -> void:
 	if target_pid == -1:
 		_nm._rpc_receive_whisper.rpc_id(sender_id, "SYSTÈME", "Joueur '%s' introuvable." % target_name)
 		return
+	if is_blocked(sender_id, target_pid):
+		_nm._rpc_receive_whisper.rpc_id(sender_id, "SYSTÈME", "Impossible d'envoyer un message à '%s'." % target_name)
+		return
 	_nm._rpc_receive_whisper.rpc_id(target_pid, sender_name, text)
 
 
+## True if either peer blocked the other.
+func is_blocked(pid_a: int, pid_b: int) -> bool:
+	var peer_to_uuid: Dictionary = _nm._peer_registry.get_peer_to_uuid()
+	var uuid_a: String = peer_to_uuid.get(pid_a, "")
+	var uuid_b: String = peer_to_uuid.get(pid_b, "")
+	if uuid_a == "" or uuid_b == "":
+		return false
+	return _blocks.get(uuid_a, {}).has(uuid_b) or _blocks.get(uuid_b, {}).has(uuid_a)
+
+
 # -------------------------------------------------------------------------
 # Private helpers
 # -------------------------------------------------------------------------
@@ -236,12 +299,33 @@ func _send_heartbeat() -> void:
 	if _heartbeat_backend_client == null:
 		return
 	var uuids: Array = []
+	var systems: Dictionary = {}
 	var peer_to_uuid: Dictionary = _nm._peer_registry.get_peer_to_uuid()
 	for pid in peer_to_uuid:
 		if _nm.peers.has(pid):
 			var uuid: String = peer_to_uuid[pid]
 			if uuid != "":
 				uuids.append(uuid)
+				systems[uuid] = _nm.peers[pid].system_id
 	if uuids.is_empty():
 		return
-	_heartbeat_backend_client.send_heartbeat(uuids)
+	_heartbeat_backend_client.send_heartbeat(uuids, systems)
+	_refresh_blocks(uuids, true)
+
+
+## Reload the blocks of the given players (keeps the cache if the backend is unreachable).
+## prune drops the players not in uuids (used with the full list from the heartbeat).
+func _refresh_blocks(uuids: Array, prune: bool = false) -> void:
+	if _heartbeat_backend_client == null:
+		return
+	var blocks: Dictionary = await _heartbeat_backend_client.get_blocks(uuids)
+	if blocks.is_empty():
+		return
+	if prune:
+		_blocks.clear()
+	for uuid in blocks:
+		var blocked: Dictionary = {}
+		for entry in blocks[uuid]:
+			if entry is Dictionary:
+				blocked[entry.get("player_id", "")] = entry.get("username", "")
+		_blocks[uuid] = blocked
@@ -0,0 +1,108 @@
+class_name NetEconomyServer
+extends RefCounted
+
+# =============================================================================
+# NetEconomyServer — Saves the players' economy (credits, resources, inventory)
+# to the backend. The backend no longer takes these from client saves: clients
+# send them here, the server checks the sender's token and the shape of the
+# values, then relays them with its own identity. The backend bounds the gains
+# against the stored values (POST /server/economy). Server-side only.
+# =============================================================================
+
+var _nm: NetworkManagerSystem
+
+# Backend client (lazy-init, server-only)
+var _backend_client: ServerBackendClient = null
+
+# peer_id -> {token, uuid}: tokens already checked with the backend
+var _verified: Dictionary = {}
+
+
+func _init(nm: NetworkManagerSystem) -> void:
+	_nm = nm
+
+
+# -------------------------------------------------------------------------
+# Public API
+# -------------------------------------------------------------------------
+
+## Check and save a client's economy, then send the result back to the client
+## (the backend response with "_status_code"; 401 bad token, 422 rejected values).
+func handle_save(sender_id: int, economy: Dictionary, token: String) -> void:
+	_ensure_backend_client()
+	var uuid: String = await _verify_sender(sender_id, token)
+	if uuid == "":
+		_reply(sender_id, {"_status_code": 401, "error": "invalid token"})
+		return
+
+	var reason: String = _check_economy(economy)
+	if reason != "":
+		push_warning("[Economy] Rejected save of %s (peer %d): %s" % [uuid, sender_id, reason])
+		_reply(sender_id, {"_status_code": 422, "error": reason})
+		return
+
+	var state: Dictionary = {
+		"save_version": economy.get("save_version"),
+		"credits": int(economy.get("credits", 0)),
+		"resources": economy.get("resources", []),
+		"inventory": economy.get("inventory", []),
+	}
+	var result: Dictionary = await _backend_client.save_economy(uuid, state)
+	if result.get("_status_code", 0) == 422:
+		push_warning("[Economy] Backend rejected save of %s (peer %d): %s" % [uuid, sender_id, result.get("error", "")])
+	_reply(sender_id, result)
+
+
+## Forget a disconnected peer's verified token.
+func forget_peer(peer_id: int) -> void:
+	_verified.erase(peer_id)
+
+
+# -------------------------------------------------------------------------
+# Internals
+# -------------------------------------------------------------------------
+
+## Player UUID the token belongs to ("" if invalid). Checked once per token.
+func _verify_sender(sender_id: int, token: String) -> String:
+	if token == "":
+		return ""
+	var cached: Dictionary = _verified.get(sender_id, {})
+	if cached.get("token", "") == token:
+		return cached["uuid"]
+
+	var result: Dictionary = await _backend_client.validate_token(token)
+	if not result.get("valid", false):
+		return ""
+	var uuid: String = str(result.get("player_id", ""))
+	_verified[sender_id] = {"token": token, "uuid": uuid}
+	return uuid
+
+
+## Why the economy can't be sent to the backend ("" if well-formed).
+func _check_economy(economy: Dictionary) -> String:
+	if economy.get("save_version") == null:
+		return "save_version is required"
+	var credits: int = int(economy.get("credits", 0))
+	if credits < 0:
+		return "negative credits"
+	for key in ["resources", "inventory"]:
+		var items = economy.get(key, [])
+		if not items is Array:
+			return "%s must be a list" % key
+		for item in items:
+			if not item is Dictionary or int(item.get("quantity", 0)) < 0:
+				return "invalid %s entry" % key
+	return ""
+
+
+func _reply(sender_id: int, result: Dictionary) -> void:
+	if _nm.multiplayer.get_peers().has(sender_id):
+		_nm._rpc_economy_saved.rpc_id(sender_id, result)
+
+
+func _ensure_backend_client() -> void:
+	if _backend_client != null:
+		return
+	_backend_client = ServerBackendClient.new()
+	_backend_client.name = "EconomyBackendClient"
+	_nm.add_child(_backend_client)
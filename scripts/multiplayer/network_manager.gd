@@ -17,6 +17,7 @@ extends Node
 #   NetGroupManager    — Ephemeral party system
 #   NetPlayerEvents    — Death, respawn, ship change, system change
 #   NetCombatServer    — PvP hit validation
+#   NetEconomyServer   — Checks and saves client credits/inventory to backend
 #
 # CRITICAL: ALL @rpc methods MUST STAY in this file.
 # Godot assigns RPC IDs per-node-path in declaration order.
@@ -95,6 +96,9 @@ signal npc_fire_received(npc_id: String, weapon_name: String, fire_pos: Array, f
 # Cargo crate sync
 signal crate_picked_up_received(crate_id: String)
 
+# Economy save result (server -> saving client)
+signal economy_save_result(result: Dictionary)
+
 enum ConnectionState { DISCONNECTED, CONNECTING, CONNECTED }
 
 var connection_state: ConnectionState = ConnectionState.DISCONNECTED
@@ -115,6 +119,7 @@ var _chat_server: NetChatServer = null
 var _group_mgr: NetGroupManager = null
 var _player_events: NetPlayerEvents = null
 var _combat_server: NetCombatServer = null
+var _economy_server: NetEconomyServer = null
 
 
 # -------------------------------------------------------------------------
@@ -163,6 +168,7 @@ func _ready() -> void:
 	_group_mgr = NetGroupManager.new(self)
 	_player_events = NetPlayerEvents.new(self)
 	_combat_server = NetCombatServer.new(self)
+	_economy_server = NetEconomyServer.new(self)
 
 	multiplayer.peer_connected.connect(_on_peer_connected)
 	multiplayer.peer_disconnected.connect(_on_peer_disconnected)
@@ -272,6 +278,7 @@ func _on_peer_disconnected(id: int) -> void:
 
 	if is_server():
 		_group_mgr.handle_peer_disconnect(id)
+		_economy_server.forget_peer(id)
 
 	_peer_registry.remove_peer(id)
 
@@ -396,6 +403,14 @@ func send_admin_command(cmd: String) -> void:
 	_rpc_admin_command.rpc_id(1, cmd)
 
 
+## Send the local economy (credits, resources, inventory, save_version) to the server,
+## which saves it to the backend. The answer comes as economy_save_result.
+func save_economy(economy: Dictionary) -> void:
+	if is_server() or not is_connected_to_server():
+		return
+	_rpc_save_economy.rpc_id(1, economy, AuthManager.get_access_token())
+
+
 # =========================================================================
 # RPCs — ALL @rpc methods MUST stay in this file (order is frozen)
 # =========================================================================
@@ -431,7 +446,7 @@ func _rpc_register_player(player_name: String, ship_id_str: String, player_uuid:
 		"galaxies": galaxy_servers,
 	}
 	_rpc_server_config.rpc_id(sender_id, config)
-	_chat_server.send_history_to_peer(sender_id, spawn_sys)
+	_chat_server.welcome_peer(sender_id, spawn_sys, player_uuid)
 
 	# Always sync fleet status for UUID players — not just reconnects.
 	# After a server restart, is_reconnect is false (UUID maps are empty),
@@ -1098,3 +1113,21 @@ func _rpc_scanner_pulse(scan_pos: Array) -> void:
 @rpc("authority", "reliable")
 func _rpc_remote_scanner_pulse(peer_id: int, scan_pos: Array) -> void:
 	remote_scanner_pulse_received.emit(peer_id, scan_pos)
+
+
+# =============================================================================
+# ECONOMY SAVES (credits/inventory go to the backend through the server)
+# =============================================================================
+
+## Client -> Server: Save the sender's economy (checked against its access token).
+@rpc("any_peer", "reliable")
+func _rpc_save_economy(economy: Dictionary, token: String) -> void:
+	if not is_server():
+		return
+	_economy_server.handle_save(multiplayer.get_remote_sender_id(), economy, token)
+
+
+## Server -> Client: Result of _rpc_save_economy (backend response + "_status_code").
+@rpc("authority", "reliable")
+func _rpc_economy_saved(result: Dictionary) -> void:
+	economy_save_result.emit(result)